@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/cmd"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/metrics"
+)
+
+// AdminServer serves the operational endpoints on their own listener, so they are neither
+// exposed with the API nor counted in its metrics. Only `/metrics` is open, for the scrapers, the
+// rest asks for the admin token and is off without one.
+type AdminServer struct {
+	router *gin.Engine
+	srv    *http.Server
+}
+
+func NewAdminServer(setup *cmd.SetupConfig) *AdminServer {
+	conf := setup.Conf
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.GET("/metrics", gin.WrapH(metrics.Handler(setup.Metrics)))
+
+	if conf.Core.Admin.Token == "" {
+		setup.Logger.Warn(nil, "The admin token is not set, only /metrics is served on the admin listener.")
+	} else {
+		authorized := r.Group("/", adminTokenMiddleware(conf.Core.Admin.Token))
+		// the secrets of the config are masked by their `mask` tags
+		authorized.GET("/config", func(c *gin.Context) {
+			c.JSON(http.StatusOK, config.Masked(conf))
+		})
+		authorized.GET("/log/level", getLogLevels(setup.LogLevels))
+		authorized.PUT("/log/level", setLogLevel(setup.LogLevels))
+		// the probes of the API leave the errors of the checks out
+		authorized.GET("/healthz", setup.Health.Details)
+		registerDiagnostics(authorized, setup.DB.DB)
+	}
+
+	return &AdminServer{
+		router: r,
+		srv: &http.Server{
+			Addr:              conf.Core.Admin.Address,
+			Handler:           r,
+			ReadHeaderTimeout: optionalDuration(conf.Core.Http.ReadHeaderTimeout),
+		},
+	}
+}
+
+// adminTokenMiddleware lets the requests with the `Authorization: Bearer <token>` header through
+func adminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "a valid admin token is required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Serve listens and serves until Shutdown, which is not an error
+func (s *AdminServer) Serve() error {
+	err := s.srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *AdminServer) Shutdown(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	log.Println("Admin server shut down gracefully.")
+	return nil
+}
+
+type logLevelsResponse struct {
+	Default  string            `json:"default"`
+	Services map[string]string `json:"services"`
+}
+
+// setLogLevelRequest sets the default level without a service, an empty level resets the service
+// to the default
+type setLogLevelRequest struct {
+	Service string `json:"service"`
+	Level   string `json:"level"`
+}
+
+func getLogLevels(levels *logger.Levels) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res := logLevelsResponse{
+			Default:  levels.Default().String(),
+			Services: map[string]string{},
+		}
+		for service, level := range levels.Services() {
+			res.Services[service] = level.String()
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func setLogLevel(levels *logger.Levels) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req setLogLevelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Service != "" && req.Level == "" {
+			levels.Reset(req.Service)
+			getLogLevels(levels)(c)
+			return
+		}
+
+		level, err := logger.ParseLevel(req.Level)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Service == "" {
+			levels.SetDefault(level)
+		} else {
+			levels.Set(req.Service, level)
+		}
+
+		getLogLevels(levels)(c)
+	}
+}
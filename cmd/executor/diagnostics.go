@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var startedAt = time.Now()
+
+type buildInfoResponse struct {
+	GoVersion   string `json:"go_version"`
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	VcsRevision string `json:"vcs_revision,omitempty"`
+	VcsTime     string `json:"vcs_time,omitempty"`
+	VcsModified bool   `json:"vcs_modified"`
+}
+
+type runtimeResponse struct {
+	Uptime     string `json:"uptime"`
+	Goroutines int    `json:"goroutines"`
+	NumCPU     int    `json:"num_cpu"`
+	GoMaxProcs int    `json:"gomaxprocs"`
+	Heap       struct {
+		AllocBytes   uint64 `json:"alloc_bytes"`
+		InuseBytes   uint64 `json:"inuse_bytes"`
+		IdleBytes    uint64 `json:"idle_bytes"`
+		SysBytes     uint64 `json:"sys_bytes"`
+		Objects      uint64 `json:"objects"`
+		NextGCBytes  uint64 `json:"next_gc_bytes"`
+		NumGC        uint32 `json:"num_gc"`
+		LastGCPause  string `json:"last_gc_pause"`
+		TotalGCPause string `json:"total_gc_pause"`
+	} `json:"heap"`
+}
+
+type dbStatsResponse struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// registerDiagnostics serves pprof, the build info, the runtime summary and the pool stats of
+// gormDB under `/debug`
+func registerDiagnostics(r gin.IRoutes, gormDB *gorm.DB) {
+	r.GET("/debug/pprof/*profile", pprofHandler)
+	r.POST("/debug/pprof/*profile", pprofHandler)
+	r.GET("/debug/buildinfo", buildInfo)
+	r.GET("/debug/runtime", runtimeSummary)
+	r.GET("/debug/db", dbStats(gormDB))
+}
+
+// pprofHandler routes the profiles like the default mux of net/http/pprof does
+func pprofHandler(c *gin.Context) {
+	switch c.Param("profile") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
+
+func buildInfo(c *gin.Context) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "the binary has no build info"})
+		return
+	}
+
+	res := buildInfoResponse{
+		GoVersion: info.GoVersion,
+		Path:      info.Main.Path,
+		Version:   info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			res.VcsRevision = setting.Value
+		case "vcs.time":
+			res.VcsTime = setting.Value
+		case "vcs.modified":
+			res.VcsModified = setting.Value == "true"
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func runtimeSummary(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	res := runtimeResponse{
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		NumCPU:     runtime.NumCPU(),
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+	}
+	res.Heap.AllocBytes = mem.HeapAlloc
+	res.Heap.InuseBytes = mem.HeapInuse
+	res.Heap.IdleBytes = mem.HeapIdle
+	res.Heap.SysBytes = mem.HeapSys
+	res.Heap.Objects = mem.HeapObjects
+	res.Heap.NextGCBytes = mem.NextGC
+	res.Heap.NumGC = mem.NumGC
+	res.Heap.LastGCPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String()
+	res.Heap.TotalGCPause = time.Duration(mem.PauseTotalNs).String()
+
+	c.JSON(http.StatusOK, res)
+}
+
+// dbStats is not found for the storages without a connection pool, e.g. `memory`
+func dbStats(gormDB *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sqlDB, err := gormDB.DB()
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		stats := sqlDB.Stats()
+		c.JSON(http.StatusOK, dbStatsResponse{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		})
+	}
+}
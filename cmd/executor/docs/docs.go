@@ -1,5 +1,5 @@
-// Package docs GENERATED BY SWAG; DO NOT EDIT
-// This file was generated by swaggo/swag
+// Code generated by swaggo/swag. DO NOT EDIT.
+
 package docs
 
 import "github.com/swaggo/swag"
@@ -15,45 +15,708 @@ const docTemplate = `{
             "name": "TodoAPP",
             "url": "https://swagger.io/support"
         },
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/todo-items": {
-            "post": {
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/todo-items": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api lists todo items, newest first. Without ` + "`" + `page` + "`" + ` the list is read with\ncursors: pass ` + "`" + `nextCursor` + "`" + ` or ` + "`" + `prevCursor` + "`" + ` of a response as ` + "`" + `cursor` + "`" + ` to get the\npage after or before it. Cursor lists only have ` + "`" + `totalItems` + "`" + ` when ` + "`" + `count` + "`" + ` asks for\nit, ` + "`" + `estimated` + "`" + ` may be off but does not count the rows.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "List TodoItems",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "TodoItem Ids",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "page",
+                            "cursor"
+                        ],
+                        "type": "string",
+                        "description": "cursor pages the list with cursors instead of page, a cursor implies it",
+                        "name": "paging",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page to read, cannot be used with page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "description": "Total count of a cursor list",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.ListResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.TodoItem"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api for create poll",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Create TodoItem",
+                "parameters": [
+                    {
+                        "description": "Contains information to set data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateTodoItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TodoItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true
+            }
+        },
+        "/todo-items/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api streams the todo items of the user as CSV or newline delimited JSON of v1 items",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Export TodoItems",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true
+            }
+        },
+        "/todo-items/ics/feeds": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api creates an iCalendar subscription url, the token in it is only shown once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Create TodoItem calendar feed",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TodoItemFeed"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/ics/feeds/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api revokes a calendar subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Revoke TodoItem calendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/ics/feeds/{token}": {
+            "get": {
+                "description": "This api renders todo items as iCalendar VTODO components, calendar apps subscribe to it with the feed token",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Get TodoItem calendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/ics/import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api creates or updates todo items from the VTODO components of an .ics file, matched by UID",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/calendar"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Import TodoItems from iCalendar",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "The .ics file, the raw request body is used when omitted",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportTodoItemsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api creates todo items from CSV or newline delimited JSON, rows with the id of an existing item update it. Every row is validated and reported on its own",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Import TodoItems",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Import format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "The file to import, the raw request body is used when omitted",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportTodoItemsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/purge/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api for purge poll",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Purge TodoItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "TodoItem Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/stream": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "This api for create poll",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "This api streams todo item changes over Server-Sent Events, or over WebSocket when the request is an upgrade",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "todo-items"
                 ],
-                "summary": "Create TodoItem",
+                "summary": "Stream TodoItem changes",
                 "parameters": [
                     {
-                        "description": "Contains information to set data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateTodoItemRequest"
-                        }
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Event types: created, updated, completed, deleted, purged",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "TodoItem Ids",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id, same as the Last-Event-ID header",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.TodoItem"
+                            "$ref": "#/definitions/dto.TodoItemEvent"
                         }
                     },
                     "400": {
@@ -86,17 +749,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
-                }
+                },
+                "x-api-v1": true
             }
         },
-        "/todo-items/purge/{id}": {
-            "delete": {
+        "/todo-items/{id}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "This api for purge poll",
+                "description": "This api for poll by id",
                 "consumes": [
                     "application/json"
                 ],
@@ -106,7 +770,7 @@ const docTemplate = `{
                 "tags": [
                     "todo-items"
                 ],
-                "summary": "Purge TodoItem",
+                "summary": "Get TodoItem By Id",
                 "parameters": [
                     {
                         "type": "string",
@@ -117,8 +781,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TodoItem"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -150,17 +817,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
-                }
-            }
-        },
-        "/todo-items/{id}": {
-            "get": {
+                },
+                "x-api-v1": true
+            },
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "This api for poll by id",
+                "description": "This api for update poll",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,7 +836,7 @@ const docTemplate = `{
                 "tags": [
                     "todo-items"
                 ],
-                "summary": "Get TodoItem By Id",
+                "summary": "Update TodoItem",
                 "parameters": [
                     {
                         "type": "string",
@@ -178,6 +844,15 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Contains information to set data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateTodoItemRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -217,15 +892,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
-                }
+                },
+                "x-api-v1": true
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "This api for update poll",
+                "description": "This api for delete poll",
                 "consumes": [
                     "application/json"
                 ],
@@ -235,7 +911,7 @@ const docTemplate = `{
                 "tags": [
                     "todo-items"
                 ],
-                "summary": "Update TodoItem",
+                "summary": "Delete TodoItem",
                 "parameters": [
                     {
                         "type": "string",
@@ -243,16 +919,79 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
-                    {
-                        "description": "Contains information to set data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateTodoItemRequest"
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrValidationSwaggerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
+                },
+                "x-api-v1": true,
+                "x-api-v2": true
+            }
+        },
+        "/todo-items/{id}/done": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "This api marks a todo item done, an item that is done already keeps its completion time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todo-items"
+                ],
+                "summary": "Complete TodoItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "TodoItem Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -279,6 +1018,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -291,7 +1036,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
-                }
+                },
+                "x-api-v1": true
             },
             "delete": {
                 "security": [
@@ -299,7 +1045,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "This api for delete poll",
+                "description": "This api marks a done todo item open again",
                 "consumes": [
                     "application/json"
                 ],
@@ -309,7 +1055,7 @@ const docTemplate = `{
                 "tags": [
                     "todo-items"
                 ],
-                "summary": "Delete TodoItem",
+                "summary": "Reopen TodoItem",
                 "parameters": [
                     {
                         "type": "string",
@@ -320,8 +1066,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TodoItem"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -341,6 +1090,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrSwaggerResponse"
+                        }
+                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -353,7 +1108,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrSwaggerResponse"
                         }
                     }
-                }
+                },
+                "x-api-v1": true
             }
         }
     },
@@ -373,9 +1129,54 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.ImportTodoItemResult": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "causes": {},
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the 1-based position of the record in the uploaded file",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ImportTodoItemsResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ImportTodoItemResult"
+                    }
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
         "dto.TodoItem": {
             "type": "object",
             "properties": {
+                "completedAt": {
+                    "type": "string"
+                },
                 "createdAt": {
                     "type": "string"
                 },
@@ -393,6 +1194,41 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.TodoItemEvent": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "item": {
+                    "$ref": "#/definitions/dto.TodoItem"
+                },
+                "occurredAt": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TodoItemFeed": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is only returned once, keep the url secret like a password",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.UpdateTodoItemRequest": {
             "type": "object",
             "required": [
@@ -408,6 +1244,17 @@ const docTemplate = `{
                 }
             }
         },
+        "response.DefaultSort": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "response.ErrSwaggerResponse": {
             "type": "object",
             "properties": {
@@ -465,6 +1312,41 @@ const docTemplate = `{
                     "additionalProperties": true
                 }
             }
+        },
+        "response.ListResponse": {
+            "type": "object",
+            "properties": {
+                "defaultSort": {
+                    "$ref": "#/definitions/response.DefaultSort"
+                },
+                "items": {},
+                "pagination": {
+                    "$ref": "#/definitions/response.PaginationInfo"
+                }
+            }
+        },
+        "response.PaginationInfo": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                },
+                "totalEstimated": {
+                    "type": "boolean"
+                },
+                "totalItems": {
+                    "type": "integer"
+                }
+            }
         }
     },
     "securityDefinitions": {
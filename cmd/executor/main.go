@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	logger "log"
 	"os"
 	"os/signal"
@@ -14,6 +15,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	conf := cmd.Setup()
 
 	ctx, cancel := context.WithCancel(conf.Ctx)
@@ -22,8 +27,14 @@ func main() {
 	var healthy int32 = 1
 	errGroup, ctx := errgroup.WithContext(ctx)
 
-	// NOTE: Run the http and gRPC Server
+	// NOTE: Run the http and gRPC Server, a failing one stops the others through the group
 	server := httpServer(conf)
+	errGroup.Go(server.Serve)
+	admin := adminServer(conf)
+	if admin != nil {
+		errGroup.Go(admin.Serve)
+	}
+	conf.Health.Started()
 	// Handle OS signals for graceful shutdown
 	errGroup.Go(func() error {
 		sigCh := make(chan os.Signal, 1)
@@ -32,25 +43,63 @@ func main() {
 		case sig := <-sigCh:
 			logger.Printf("Received signal: %v, shutting down...", sig)
 			atomic.StoreInt32(&healthy, 0)
-
-			shutdownCtx, cancel := context.WithTimeout(conf.Ctx, 5*time.Second)
-			defer cancel()
-			if err := server.Shutdown(shutdownCtx); err != nil {
-				return err
-			}
-			cancel()
-			return nil
+			return shutdown(conf, server, admin)
 		case <-ctx.Done():
-			return ctx.Err()
+			// a server failed, the others are not left serving
+			return errors.Join(ctx.Err(), shutdown(conf, server, admin))
 		}
 	})
 
 	// Wait for all goroutines to finish
-	if err := errGroup.Wait(); err != nil && atomic.LoadInt32(&healthy) == 1 {
+	err := errGroup.Wait()
+	switch {
+	case err != nil && atomic.LoadInt32(&healthy) == 1:
 		logger.Fatalf("Error occurred: %v", err)
-	} else {
+	case err != nil:
+		conf.Logger.Errorf(nil, "Shutdown incomplete: %v", err)
+	default:
 		conf.Logger.Info(nil, "Shutdown complete.")
 	}
+	_ = conf.LogCloser.Close()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// shutdown drains the servers and closes what they used, every step runs even when one before it
+// failed, so a failing broker still leaves the DB pool closed. The errors of all steps are joined.
+func shutdown(conf *cmd.SetupConfig, server *Server, admin *AdminServer) error {
+	// Fail the readiness first and give the load balancers the time to take us out
+	conf.Health.Drain()
+	if conf.Conf.Core.Health.DrainDelay != "" {
+		time.Sleep(conf.Conf.Core.Health.DrainDelay.Duration())
+	}
+
+	var errs []error
+	// Streams never finish by themselves, close them first so Shutdown can drain the rest
+	errs = append(errs, conf.EventBroker.Close())
+
+	shutdownTimeout := 5 * time.Second
+	if conf.Conf.Core.Http.ShutdownTimeout != "" {
+		shutdownTimeout = conf.Conf.Core.Http.ShutdownTimeout.Duration()
+	}
+	// conf.Ctx is done with the workers, the drain must not be cut by it
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and drain the requests in flight
+	errs = append(errs, server.Shutdown(shutdownCtx))
+	if admin != nil {
+		errs = append(errs, admin.Shutdown(shutdownCtx))
+	}
+	// no request is left to enqueue work, the background workers can stop
+	conf.StopWorkers()
+	// flushes the spans of the requests that were drained
+	errs = append(errs, conf.TracerProvider.Shutdown(shutdownCtx))
+	// the DB pool goes last, the workers and the drained requests used it
+	errs = append(errs, conf.Close())
+
+	return errors.Join(errs...)
 }
 
 // @termsOfService  http://swagger.io/terms/
@@ -64,12 +113,31 @@ func main() {
 func httpServer(conf *cmd.SetupConfig) *Server {
 	server := NewServer(
 		conf.Conf,
+		conf.Metrics,
+		conf.LogHandler,
+		conf.RateLimitStore,
+		conf.IdempotencyStore,
 		conf.HttpAdaptorStorage.TodoItemAdaptor,
+		conf.HttpAdaptorStorage.TodoItemCalendarAdaptor,
 	)
 
-	server.HealthCheck()
+	server.HealthCheck(conf.Health)
 	server.SwaggerApi()
-	go server.Start()
+	if err := server.Start(conf.Ctx); err != nil {
+		logger.Fatalf("Failed to start the HTTP server: %v", err)
+	}
 
 	return server
 }
+
+// adminServer builds the admin listener, nil when no address is configured
+func adminServer(conf *cmd.SetupConfig) *AdminServer {
+	if conf.Conf.Core.Admin.Address == "" {
+		return nil
+	}
+	if conf.Conf.Core.Admin.Address == conf.Conf.Core.Http.Address {
+		logger.Fatalf("The admin listener must not share the address %s of the API.", conf.Conf.Core.Http.Address)
+	}
+
+	return NewAdminServer(conf)
+}
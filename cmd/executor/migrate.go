@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/thealiakbari/todoapp/cmd"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	glog "gorm.io/gorm/logger"
+)
+
+const migrateUsage = `usage: executor migrate <command>
+
+commands:
+  up          apply every pending migration
+  down N      roll back the last N migrations
+  goto V      migrate up or down to version V
+  force V     set version V without running it and clear the dirty flag, -1 for none
+  status      list the migrations and report drift between the models and the schema
+`
+
+// runMigrate runs `executor migrate ...` with the database of the config file and returns the exit
+// code, 2 on a wrong invocation. Every command but status prints the status it left behind.
+func runMigrate(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, migrateUsage)
+		return 2
+	}
+
+	command, args := args[0], args[1:]
+	wantArgs := 0
+	switch command {
+	case "down", "goto", "force":
+		wantArgs = 1
+	case "up", "status":
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", command, migrateUsage)
+		return 2
+	}
+	if len(args) != wantArgs {
+		fmt.Fprintf(stderr, "%s takes %d argument(s)\n\n%s", command, wantArgs, migrateUsage)
+		return 2
+	}
+
+	var n int
+	if wantArgs == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || (command == "down" && n <= 0) || (command == "goto" && n < 0) || n < -1 {
+			fmt.Fprintf(stderr, "invalid %s argument %q\n\n%s", command, args[0], migrateUsage)
+			return 2
+		}
+	}
+
+	conf := config.LoadConfig(cmd.ConfigPath)
+	if conf.DB.Driver == config.DriverMemory {
+		fmt.Fprintln(stderr, "the memory storage has nothing to migrate")
+		return 1
+	}
+
+	logInfra, err := logger.NewInfra(conf.Mode, conf.ServiceName, "todoapp", logger.WithHandler(slog.NewJSONHandler(stderr, nil)))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	// the statements would clutter the status, they are not logged
+	gormDB, err := cmd.OpenDB(context.Background(), conf, glog.Discard, logInfra)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer func() {
+		if sdb, err := gormDB.DB(); err == nil {
+			_ = sdb.Close()
+		}
+	}()
+
+	migrator, err := cmd.NewMigrator(conf, gormDB)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down(n)
+	case "goto":
+		err = migrator.Goto(uint(n))
+	case "force":
+		err = migrator.Force(n)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if err = printMigrationStatus(stdout, migrator); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if command == "status" {
+		drift, err := db.SchemaDrift(gormDB, cmd.Models()...)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		if len(drift) == 0 {
+			fmt.Fprintln(stdout, "the schema matches the models")
+		}
+		for _, d := range drift {
+			fmt.Fprintf(stdout, "drift: %s\n", d)
+		}
+	}
+
+	return 0
+}
+
+func printMigrationStatus(w io.Writer, migrator *db.Migrator) error {
+	version, dirty, migrations, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, m := range migrations {
+		fmt.Fprintf(tw, "%d\t%s\t%t\n", m.Version, m.Name, m.Applied)
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "version %d, dirty %t\n", version, dirty)
+
+	return nil
+}
@@ -2,81 +2,185 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	swaggerfiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	"github.com/thealiakbari/todoapp/cmd/executor/docs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thealiakbari/todoapp/pkg/common/certs"
 	"github.com/thealiakbari/todoapp/pkg/common/config"
 	"github.com/thealiakbari/todoapp/pkg/common/ginh"
+	"github.com/thealiakbari/todoapp/pkg/common/health"
+	"github.com/thealiakbari/todoapp/pkg/common/idempotency"
+	"github.com/thealiakbari/todoapp/pkg/common/metrics"
+	"github.com/thealiakbari/todoapp/pkg/common/ratelimit"
+	"github.com/thealiakbari/todoapp/pkg/common/redact"
 	"github.com/thealiakbari/todoapp/pkg/common/response"
 )
 
+// Handler registers its routes once per API version, with the DTOs of that version
 type Handler interface {
-	RegisterRoutes(c *gin.RouterGroup)
+	RegisterRoutes(version ginh.APIVersion, c *gin.RouterGroup)
 }
 
 type Server struct {
-	router *gin.Engine
-	conf   *config.AppConfig
+	router           *gin.Engine
+	srv              *http.Server
+	certs            *certs.Reloader
+	listener         net.Listener
+	conf             *config.AppConfig
+	rateLimitStore   ratelimit.Store
+	idempotencyStore idempotency.Store
 }
 
-func NewServer(conf *config.AppConfig, handlers ...Handler) *Server {
-	r := ginh.NewGinEngine(conf.Mode)
+func NewServer(
+	conf *config.AppConfig,
+	reg prometheus.Registerer,
+	logHandler slog.Handler,
+	rateLimitStore ratelimit.Store,
+	idempotencyStore idempotency.Store,
+	handlers ...Handler,
+) *Server {
+	r := ginh.NewGinEngine(conf.Mode, slog.New(logHandler), conf.Core.Http.RequestLog, redact.New(conf.Redaction), metrics.HTTPMiddleware(reg))
+	// the client IP is only read from the headers of the trusted proxies, it could be spoofed
+	if err := r.SetTrustedProxies(conf.Core.Http.TrustedProxies); err != nil {
+		panic(err)
+	}
+
+	srv, reloader, err := newHTTPServer(conf.Core.Http, r)
+	if err != nil {
+		panic(err)
+	}
 
 	server := &Server{
-		router: r,
-		conf:   conf,
+		router:           r,
+		srv:              srv,
+		certs:            reloader,
+		conf:             conf,
+		rateLimitStore:   rateLimitStore,
+		idempotencyStore: idempotencyStore,
 	}
 
-	server.registerRoutes(handlers...)
+	if err := server.registerRoutes(handlers...); err != nil {
+		panic(err)
+	}
 	return server
 }
 
-func (s *Server) registerRoutes(handlers ...Handler) {
-	subRouter := s.router.Group("/api/v1")
-	for _, handler := range handlers {
-		handler.RegisterRoutes(subRouter)
+// registerRoutes serves each version under `/api/<version>`, with the deprecation headers of the
+// versions configured in `core.http.versions`, the rate limits of `core.http.rate_limit` and the
+// idempotency keys of `core.http.idempotency`. The limited requests do not claim a key.
+func (s *Server) registerRoutes(handlers ...Handler) error {
+	idempotent, err := ginh.NewIdempotencyMiddleware(s.conf.Core.Http.Idempotency, s.idempotencyStore)
+	if err != nil {
+		return err
 	}
+
+	for _, version := range ginh.APIVersions {
+		deprecation, err := ginh.NewDeprecationMiddleware(s.conf.Core.Http.Versions[string(version)])
+		if err != nil {
+			return fmt.Errorf("api version %s: %w", version, err)
+		}
+
+		rateLimit, err := ginh.NewRateLimitMiddleware(s.conf.Core.Http.RateLimit, s.rateLimitStore, version.Path())
+		if err != nil {
+			return err
+		}
+
+		subRouter := s.router.Group(version.Path())
+		if deprecation != nil {
+			subRouter.Use(deprecation)
+		}
+		if rateLimit != nil {
+			subRouter.Use(rateLimit)
+		}
+		if idempotent != nil {
+			subRouter.Use(idempotent)
+		}
+		for _, handler := range handlers {
+			handler.RegisterRoutes(version, subRouter)
+		}
+	}
+
+	return nil
 }
 
-func (s *Server) Start() {
-	err := s.router.Run(s.conf.Core.Http.Address)
+// newHTTPServer serves handler with the timeouts of conf, over TLS when conf has a certificate
+func newHTTPServer(conf config.Http, handler http.Handler) (*http.Server, *certs.Reloader, error) {
+	srv := &http.Server{
+		Addr:              conf.Address,
+		Handler:           handler,
+		ReadTimeout:       optionalDuration(conf.ReadTimeout),
+		ReadHeaderTimeout: optionalDuration(conf.ReadHeaderTimeout),
+		WriteTimeout:      optionalDuration(conf.WriteTimeout),
+		IdleTimeout:       optionalDuration(conf.IdleTimeout),
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
+
+	reloader, err := certs.New(conf.TLS)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	}
+	if reloader != nil {
+		srv.TLSConfig = reloader.Config()
+	}
+
+	return srv, reloader, nil
+}
+
+func optionalDuration(d config.TimeDuration) time.Duration {
+	if d == "" {
+		return 0
+	}
+	return d.Duration()
+}
+
+// Start listens right away, so a taken address fails the start, Serve serves the connections. The
+// certificate is reloaded until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
 	}
+
+	s.listener = listener
+	if s.certs != nil {
+		go s.certs.Watch(ctx)
+	}
+	return nil
 }
 
-func (s *Server) SwaggerApi() {
-	s.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
-	docs.SwaggerInfo.Title = "Todo APP Service"
-	docs.SwaggerInfo.Description = "Todo App Service: This is a Todo App service."
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.BasePath = "/api/v1"
-	if s.conf.Mode == config.ModeLocal {
-		docs.SwaggerInfo.Host = fmt.Sprintf("localhost:%v", s.conf.Core.Http.Port)
-		docs.SwaggerInfo.Schemes = []string{"http"}
+// Serve serves on the listener of Start until Shutdown, which is not an error
+func (s *Server) Serve() error {
+	var err error
+	if s.certs != nil {
+		err = s.srv.ServeTLS(s.listener, "", "")
 	} else {
-		docs.SwaggerInfo.Schemes = []string{"https"}
+		err = s.srv.Serve(s.listener)
 	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func (s *Server) HealthCheck() {
+// HealthCheck serves `/ping`, which only tells the process answers, and the `/healthz` probes
+func (s *Server) HealthCheck(registry *health.Registry) {
 	s.router.GET("/ping", func(ctx *gin.Context) {
 		response.OKResponse(ctx, map[string]string{"message": "pong"})
 	})
+	registry.RegisterRoutes(s.router)
 }
 
+// Shutdown stops accepting connections and waits for the requests in flight until ctx is done. The
+// hijacked connections, i.e. the WebSockets, are not waited for.
 func (s *Server) Shutdown(ctx context.Context) error {
-	srv := &http.Server{
-		Addr:    s.conf.Core.Http.Address,
-		Handler: s.router,
-	}
-
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := s.srv.Shutdown(ctx); err != nil {
 		return err
 	}
 	log.Println("HTTP server shut down gracefully.")
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerfiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
+	"github.com/thealiakbari/todoapp/cmd/executor/docs"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/ginh"
+)
+
+// The general info of the v2 docs, they are generated from this file and the v1 ones from main.go
+//
+// @termsOfService  http://swagger.io/terms/
+// @contact.name   TodoAPP
+// @contact.url    https://swagger.io/support
+// @BasePath  /api/v2
+// @securityDefinitions.apikey Bearer
+// @in header
+// @name Authorization
+// @description "Type 'Bearer TOKEN' to correctly set the Authorization Bearer"
+
+// SwaggerApi serves the docs of each API version under `/swagger/<version>`, `/swagger/index.html`
+// still opens the v1 ones
+func (s *Server) SwaggerApi() {
+	specs := map[ginh.APIVersion]*swag.Spec{
+		ginh.APIV1: docs.SwaggerInfo,
+		ginh.APIV2: docs.SwaggerInfov2,
+	}
+	for version, spec := range specs {
+		spec.Title = "Todo APP Service"
+		spec.Description = fmt.Sprintf("Todo App Service: This is a Todo App service, API %s.", version)
+		spec.Version = "1.0"
+		spec.BasePath = version.Path()
+		if s.conf.Mode == config.ModeLocal {
+			spec.Host = fmt.Sprintf("localhost:%v", s.conf.Core.Http.Port)
+			spec.Schemes = []string{"http"}
+		} else {
+			spec.Schemes = []string{"https"}
+		}
+
+		s.router.GET("/swagger/"+string(version)+"/*any", ginSwagger.WrapHandler(swaggerfiles.Handler, ginSwagger.InstanceName(spec.InstanceName())))
+	}
+
+	s.router.GET("/swagger/index.html", func(ctx *gin.Context) {
+		ctx.Redirect(http.StatusMovedPermanently, "/swagger/"+string(ginh.APIV1)+"/index.html")
+	})
+}
@@ -0,0 +1,33 @@
+// Package migration embeds the migration scripts of every storage driver, so the binary does not
+// depend on the directory it is started from
+package migration
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed scripts/*.sql
+var postgresScripts embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteScripts embed.FS
+
+// Postgres returns the scripts of `cmd/migration/scripts`
+func Postgres() fs.FS {
+	return mustSub(postgresScripts, "scripts")
+}
+
+// Sqlite returns the scripts of `cmd/migration/sqlite`
+func Sqlite() fs.FS {
+	return mustSub(sqliteScripts, "sqlite")
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
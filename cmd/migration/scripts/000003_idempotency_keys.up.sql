@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys (
+                       scope TEXT NOT NULL,
+                       idempotency_key TEXT NOT NULL,
+                       fingerprint TEXT NOT NULL,
+                       status INTEGER NOT NULL,
+                       header TEXT NOT NULL,
+                       body BYTEA,
+                       created_at timestamp with time zone NOT NULL,
+                       expires_at timestamp with time zone NOT NULL,
+                       PRIMARY KEY (scope, idempotency_key)
+);
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1 @@
+ALTER TABLE todo_items DROP CONSTRAINT todo_items_pkey;
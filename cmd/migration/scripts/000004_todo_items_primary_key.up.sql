@@ -0,0 +1,8 @@
+-- imports could insert an id twice, the newest live row of an id is kept
+DELETE FROM todo_items WHERE ctid IN (
+    SELECT ctid FROM (
+        SELECT ctid, row_number() OVER (PARTITION BY id ORDER BY deleted_at IS NULL DESC, updated_at DESC) AS n
+        FROM todo_items
+    ) duplicates WHERE n > 1
+);
+ALTER TABLE todo_items ADD CONSTRAINT todo_items_pkey PRIMARY KEY (id);
@@ -0,0 +1,8 @@
+CREATE TABLE todo_items (
+                       id TEXT DEFAULT (uuid_generate_v4()) NOT NULL,
+                       created_at DATETIME NOT NULL,
+                       updated_at DATETIME NOT NULL,
+                       deleted_at DATETIME,
+                       due_date TIMESTAMP NOT NULL,
+                       description TEXT NOT NULL
+);
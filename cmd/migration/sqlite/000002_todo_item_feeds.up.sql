@@ -0,0 +1,9 @@
+CREATE TABLE todo_item_feeds (
+                       id TEXT DEFAULT (uuid_generate_v4()) NOT NULL PRIMARY KEY,
+                       created_at DATETIME NOT NULL,
+                       updated_at DATETIME NOT NULL,
+                       deleted_at DATETIME,
+                       user_reference_id TEXT,
+                       token_hash TEXT NOT NULL
+);
+CREATE UNIQUE INDEX todo_item_feeds_token_hash_idx ON todo_item_feeds (token_hash);
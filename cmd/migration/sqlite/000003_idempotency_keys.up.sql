@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys (
+                       scope TEXT NOT NULL,
+                       idempotency_key TEXT NOT NULL,
+                       fingerprint TEXT NOT NULL,
+                       status INTEGER NOT NULL,
+                       header TEXT NOT NULL,
+                       body BLOB,
+                       created_at DATETIME NOT NULL,
+                       expires_at DATETIME NOT NULL,
+                       PRIMARY KEY (scope, idempotency_key)
+);
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1 @@
+DROP INDEX todo_items_id_idx;
@@ -0,0 +1,9 @@
+-- imports could insert an id twice, the newest live row of an id is kept. A table cannot get a
+-- primary key afterwards in SQLite, a unique index serves the upserts the same way.
+DELETE FROM todo_items WHERE rowid IN (
+    SELECT rowid FROM (
+        SELECT rowid, row_number() OVER (PARTITION BY id ORDER BY deleted_at IS NULL DESC, updated_at DESC) AS n
+        FROM todo_items
+    ) WHERE n > 1
+);
+CREATE UNIQUE INDEX todo_items_id_idx ON todo_items (id);
@@ -0,0 +1 @@
+ALTER TABLE todo_items ADD COLUMN completed_at DATETIME;
@@ -0,0 +1,2 @@
+DROP INDEX todo_items_user_reference_id_idx;
+ALTER TABLE todo_items DROP COLUMN user_reference_id;
@@ -0,0 +1,2 @@
+ALTER TABLE todo_items ADD COLUMN user_reference_id TEXT;
+CREATE INDEX todo_items_user_reference_id_idx ON todo_items (user_reference_id);
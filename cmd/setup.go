@@ -2,52 +2,134 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/thealiakbari/todoapp/cmd/migration"
 	todoItemHttpAdaptor "github.com/thealiakbari/todoapp/internal/adapters/inbound/http/todo"
+	todoItemEventBroker "github.com/thealiakbari/todoapp/internal/adapters/outbound/broker/memory"
+	cacheMemory "github.com/thealiakbari/todoapp/internal/adapters/outbound/cache/memory"
+	cacheRedis "github.com/thealiakbari/todoapp/internal/adapters/outbound/cache/redis"
+	todoItemCachedRepo "github.com/thealiakbari/todoapp/internal/adapters/outbound/db/cached"
+	todoItemMemoryRepo "github.com/thealiakbari/todoapp/internal/adapters/outbound/db/memory"
 	todoItemOutboundRepo "github.com/thealiakbari/todoapp/internal/adapters/outbound/db/pg"
+	todoItemSqliteRepo "github.com/thealiakbari/todoapp/internal/adapters/outbound/db/sqlite"
+	todoItemMetrics "github.com/thealiakbari/todoapp/internal/adapters/outbound/metrics/prometheus"
 	todoItemApp "github.com/thealiakbari/todoapp/internal/application/todo"
 	todoItemService "github.com/thealiakbari/todoapp/internal/domain/todo"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
 	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/cache"
 	todoItemRepo "github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/transaction"
 	"github.com/thealiakbari/todoapp/pkg/common/config"
 	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/health"
 	"github.com/thealiakbari/todoapp/pkg/common/i18next"
+	"github.com/thealiakbari/todoapp/pkg/common/idempotency"
 	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/metrics"
+	"github.com/thealiakbari/todoapp/pkg/common/ratelimit"
+	"github.com/thealiakbari/todoapp/pkg/common/redact"
+	"github.com/thealiakbari/todoapp/pkg/common/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"golang.org/x/text/language"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
 )
 
+// ConfigPath is relative to the working directory, like the assets
+const ConfigPath = "./config/todoapp.yml"
+
 type RepositoryStorage struct {
-	todoItemRepo todoItemRepo.TodoItemRepository
+	unitOfWork          transaction.UnitOfWork
+	todoItemRepo        todoItemRepo.TodoItemRepository
+	todoItemFeedRepo    todoItemRepo.TodoItemFeedRepository
+	todoItemEventBroker todoItemRepo.TodoItemEventBroker
+	todoItemMetrics     todoItemRepo.TodoItemMetrics
 }
 
 type ServiceStorage struct {
-	todoItemSvc todoInterface.TodoItemService
+	todoItemSvc         todoInterface.TodoItemService
+	todoItemStreamSvc   todoInterface.TodoItemStreamService
+	todoItemCalendarSvc todoInterface.TodoItemCalendarService
 }
 
 type ApplicationStorage struct {
-	todoItemApp todoItemApp.TodoItemHttpApp
+	todoItemApp         todoItemApp.TodoItemHttpApp
+	todoItemCalendarApp todoItemApp.TodoItemCalendarHttpApp
 }
 
 type HttpAdaptorStorage struct {
-	TodoItemAdaptor todoItemHttpAdaptor.Adaptor
+	TodoItemAdaptor         todoItemHttpAdaptor.Adaptor
+	TodoItemCalendarAdaptor todoItemHttpAdaptor.CalendarAdaptor
 }
 
 type SetupConfig struct {
 	Ctx                context.Context
 	Conf               *config.AppConfig
 	Logger             logger.Logger
+	LogHandler         slog.Handler
+	LogLevels          *logger.Levels
+	LogCloser          io.Closer
 	DB                 db.DBWrapper
+	EventBroker        todoItemRepo.TodoItemEventBroker
+	Metrics            *prometheus.Registry
+	TracerProvider     *sdktrace.TracerProvider
+	Health             *health.Registry
+	RateLimitStore     ratelimit.Store
+	IdempotencyStore   idempotency.Store
 	HttpAdaptorStorage HttpAdaptorStorage
+
+	stopWorkers context.CancelFunc
+	redisClient *goredis.Client
+}
+
+// StopWorkers stops the background workers of Ctx, e.g. the replica checks and the certificate
+// reloads
+func (s *SetupConfig) StopWorkers() {
+	s.stopWorkers()
+}
+
+// Close closes the connections to Redis and the database pools, once nothing uses them anymore
+func (s *SetupConfig) Close() error {
+	var errs []error
+	if s.redisClient != nil {
+		errs = append(errs, s.redisClient.Close())
+	}
+	errs = append(errs, db.Close(s.DB.DB))
+
+	return errors.Join(errs...)
 }
 
 func Setup() *SetupConfig {
-	ctx := context.Background()
-	conf := config.LoadConfig("./config/todoapp.yml")
+	// the background workers run until StopWorkers
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	conf := config.LoadConfig(ConfigPath)
+
+	logHandler, logCloser, err := logger.NewHandler(conf.Log)
+	if err != nil {
+		panic(err)
+	}
+
+	logLevels, err := logger.NewLevels(conf.Log, conf.Mode)
+	if err != nil {
+		panic(err)
+	}
 
 	log, err := logger.New(
 		conf.Mode,
 		conf.ServiceName,
 		"todoapp",
+		logger.WithHandler(logHandler),
+		logger.WithLevels(logLevels),
+		logger.WithRedactor(redact.New(conf.Redaction)),
 	)
 	if err != nil {
 		panic(err)
@@ -58,53 +140,365 @@ func Setup() *SetupConfig {
 		panic(err)
 	}
 
-	logInfra := log.CloneAsInfra()
-	err = db.Migrate(conf.DB.Postgres, logInfra)
+	// installed first, the gorm tracing plugin and the HTTP middleware use the global provider
+	tracerProvider, err := tracing.NewTracerProvider(ctx, conf.Tracing, conf.ServiceName, conf.Mode)
 	if err != nil {
-		logInfra.Panicf("Migration failed: %s\n", err.Error())
+		panic(err)
+	}
+
+	reg := metrics.NewRegistry()
+
+	queryLogger, err := NewQueryLogger(conf, log)
+	if err != nil {
+		panic(err)
 	}
-	logInfra.Info("Migrations successfully done.")
 
-	gormDB, err := db.NewPostgresConn(ctx, conf.DB.Postgres)
+	gormDB, err := NewDBConn(ctx, conf, queryLogger, log.CloneAsInfra())
 	if err != nil {
 		panic(err)
 	}
 
+	if err = InstrumentDB(conf, gormDB, reg); err != nil {
+		panic(err)
+	}
+
 	dbw := db.NewDBWrapper(gormDB)
 
-	repos := NewRepositoryStorage(dbw)
+	redisClient, err := NewRedisClient(ctx, conf)
+	if err != nil {
+		panic(err)
+	}
+
+	todoItemCache, err := NewCache(conf, redisClient)
+	if err != nil {
+		panic(err)
+	}
+
+	healthRegistry, err := NewHealth(conf, gormDB, redisClient)
+	if err != nil {
+		panic(err)
+	}
+
+	rateLimitStore, err := NewRateLimitStore(conf, redisClient)
+	if err != nil {
+		panic(err)
+	}
+	idempotencyStore := NewIdempotencyStore(conf, gormDB)
+
+	repos := NewRepositoryStorage(conf, log, dbw, todoItemCache, reg)
 	services := NewServiceStorage(log, repos)
+	repos.todoItemMetrics.WatchOverdue(func(ctx context.Context) (int64, error) {
+		return services.todoItemSvc.CountOverdue(ctx, time.Now())
+	})
 
-	httpApps := NewHttpAppStorage(dbw, services)
+	httpApps := NewHttpAppStorage(conf, services)
 	httpAdaptors := NewHttpAdaptorStorage(httpApps)
 
 	return &SetupConfig{
 		Ctx:                ctx,
 		Conf:               conf,
 		Logger:             log,
+		LogHandler:         logHandler,
+		LogLevels:          logLevels,
+		LogCloser:          logCloser,
 		DB:                 dbw,
+		EventBroker:        repos.todoItemEventBroker,
+		Metrics:            reg,
+		TracerProvider:     tracerProvider,
+		Health:             healthRegistry,
+		RateLimitStore:     rateLimitStore,
+		IdempotencyStore:   idempotencyStore,
 		HttpAdaptorStorage: httpAdaptors,
+		stopWorkers:        stopWorkers,
+		redisClient:        redisClient,
 	}
 }
 
+// NewDBConn connects and migrates the configured database, the memory driver needs neither
+// and gets a connection whose transactions are no-ops. A schema which drifted from the models
+// is only reported, the service still starts.
+func NewDBConn(ctx context.Context, conf *config.AppConfig, queryLogger glog.Interface, logInfra logger.InfraLogger) (*gorm.DB, error) {
+	gormDB, err := OpenDB(ctx, conf, queryLogger, logInfra)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.DB.Driver == config.DriverMemory {
+		logInfra.Info("Using the in-memory storage, nothing is persisted.")
+		return gormDB, nil
+	}
+
+	migrator, err := NewMigrator(conf, gormDB)
+	if err != nil {
+		return nil, err
+	}
+	defer migrator.Close()
+
+	if err = migrator.Up(); err != nil {
+		logInfra.Panicf("Migration failed: %s\n", err.Error())
+	}
+	logInfra.Info("Migrations successfully done.")
+
+	drift, err := db.SchemaDrift(gormDB, Models()...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drift {
+		logInfra.Warnf("Schema drift: %s", d)
+	}
+
+	return gormDB, nil
+}
+
+// InstrumentDB observes the statements of gormDB and exposes its connection pool on reg, the
+// memory driver has no database to observe
+func InstrumentDB(conf *config.AppConfig, gormDB *gorm.DB, reg prometheus.Registerer) error {
+	if conf.DB.Driver == config.DriverMemory {
+		return nil
+	}
+
+	if err := gormDB.Use(metrics.NewGormPlugin(reg)); err != nil {
+		return err
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+
+	driver := conf.DB.Driver
+	if driver == "" {
+		driver = config.DriverPostgres
+	}
+
+	return reg.Register(collectors.NewDBStatsCollector(sqlDB, driver))
+}
+
+// NewQueryLogger logs the SQL statements with log as configured in db.query_log, the stacks of the
+// console output are the trace_stacks setting of Postgres
+func NewQueryLogger(conf *config.AppConfig, log logger.Logger) (glog.Interface, error) {
+	traceStacks := conf.DB.Postgres.TraceStacks && (conf.DB.Driver == "" || conf.DB.Driver == config.DriverPostgres)
+	return db.NewGormLogger(conf.DB.QueryLog, conf.Mode, log, traceStacks)
+}
+
+// OpenDB connects the configured database without migrating it
+func OpenDB(ctx context.Context, conf *config.AppConfig, queryLogger glog.Interface, logInfra logger.InfraLogger) (*gorm.DB, error) {
+	switch conf.DB.Driver {
+	case config.DriverMemory:
+		return db.NewNoopConn()
+	case "", config.DriverPostgres:
+		return db.NewPostgresConn(ctx, conf.DB.Postgres, queryLogger, logInfra)
+	case config.DriverSqlite:
+		return db.NewSqliteConn(ctx, conf.DB.Sqlite, queryLogger)
+	default:
+		return nil, fmt.Errorf("unknown db driver %q", conf.DB.Driver)
+	}
+}
+
+// NewMigrator migrates the configured database with the embedded scripts. SQLite is migrated on
+// gormDB since an in-memory database only lives as long as its connection.
+func NewMigrator(conf *config.AppConfig, gormDB *gorm.DB) (*db.Migrator, error) {
+	switch conf.DB.Driver {
+	case "", config.DriverPostgres:
+		return db.NewPostgresMigrator(conf.DB.Postgres, migration.Postgres())
+	case config.DriverSqlite:
+		return db.NewSqliteMigrator(gormDB, conf.DB.Sqlite, migration.Sqlite())
+	default:
+		return nil, fmt.Errorf("db driver %q has no migrations", conf.DB.Driver)
+	}
+}
+
+// Models are the gorm models the schema is checked against
+func Models() []any {
+	return []any{&entity.TodoItem{}, &entity.TodoItemFeed{}, &idempotency.Record{}}
+}
+
 func NewHttpAppStorage(
-	db db.DBWrapper,
+	conf *config.AppConfig,
 	services ServiceStorage,
 ) ApplicationStorage {
 	return ApplicationStorage{
-		todoItemApp: todoItemApp.NewTodoItemHttpApp(services.todoItemSvc, db),
+		todoItemApp:         todoItemApp.NewTodoItemHttpApp(services.todoItemSvc, services.todoItemStreamSvc, conf.Core.Stream),
+		todoItemCalendarApp: todoItemApp.NewTodoItemCalendarHttpApp(services.todoItemCalendarSvc, services.todoItemStreamSvc),
+	}
+}
+
+// NewRedisClient connects the Redis of the todo item cache and the rate limits, it is nil when
+// neither is in Redis
+func NewRedisClient(ctx context.Context, conf *config.AppConfig) (*goredis.Client, error) {
+	cached := conf.DB.Driver != config.DriverMemory && conf.DB.Cache.Driver == config.CacheRedis
+	if !cached && conf.Core.Http.RateLimit.Store != config.RateLimitRedis {
+		return nil, nil
+	}
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     conf.DB.Redis.Address,
+		Password: conf.DB.Redis.Password,
+		DB:       conf.DB.Redis.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("cannot connect to redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// NewCache builds the configured todo item cache, it is nil when caching is off. Items of the
+// memory storage are not cached, they are in memory already.
+func NewCache(conf *config.AppConfig, redisClient *goredis.Client) (cache.Cache, error) {
+	if conf.DB.Driver == config.DriverMemory {
+		return nil, nil
+	}
+
+	switch conf.DB.Cache.Driver {
+	case "":
+		return nil, nil
+	case config.CacheMemory:
+		return cacheMemory.NewLRUCache(conf.DB.Cache.Size), nil
+	case config.CacheRedis:
+		return cacheRedis.NewRedisCache(redisClient), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", conf.DB.Cache.Driver)
+	}
+}
+
+// NewRateLimitStore builds the configured store of the rate limit buckets, it is nil when the
+// limits are off
+func NewRateLimitStore(conf *config.AppConfig, redisClient *goredis.Client) (ratelimit.Store, error) {
+	switch conf.Core.Http.RateLimit.Store {
+	case "":
+		return nil, nil
+	case config.RateLimitMemory:
+		return ratelimit.NewMemoryStore(), nil
+	case config.RateLimitRedis:
+		return ratelimit.NewRedisStore(redisClient, conf.Core.Http.RateLimit.Prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", conf.Core.Http.RateLimit.Store)
+	}
+}
+
+// NewIdempotencyStore keeps the idempotency keys in the database, or in the process for the memory
+// storage. It is nil when the keys are off.
+func NewIdempotencyStore(conf *config.AppConfig, gormDB *gorm.DB) idempotency.Store {
+	switch {
+	case conf.Core.Http.Idempotency.TTL == "":
+		return nil
+	case conf.DB.Driver == config.DriverMemory:
+		return idempotency.NewMemoryStore()
+	default:
+		return idempotency.NewGormStore(gormDB)
 	}
 }
 
-func NewRepositoryStorage(db db.DBWrapper) RepositoryStorage {
-	return RepositoryStorage{
-		todoItemRepo: todoItemOutboundRepo.NewTodoItemRepository(db),
+// NewHealth checks the database, that its migration is the latest embedded one, and Redis when the
+// cache or the rate limits are in it. The memory storage has no dependency to check.
+func NewHealth(conf *config.AppConfig, gormDB *gorm.DB, redisClient *goredis.Client) (*health.Registry, error) {
+	var timeout time.Duration
+	if conf.Core.Health.Timeout != "" {
+		timeout = conf.Core.Health.Timeout.Duration()
 	}
+	cacheTTL := time.Second
+	if conf.Core.Health.CacheTTL != "" {
+		cacheTTL = conf.Core.Health.CacheTTL.Duration()
+	}
+	registry := health.NewRegistry(timeout, cacheTTL)
+
+	if conf.DB.Driver != config.DriverMemory {
+		sqlDB, err := gormDB.DB()
+		if err != nil {
+			return nil, err
+		}
+
+		var latest uint
+		switch conf.DB.Driver {
+		case config.DriverSqlite:
+			latest, err = db.LatestMigration(conf.DB.Sqlite.MigrationsURL, migration.Sqlite())
+		default:
+			latest, err = db.LatestMigration(conf.DB.Postgres.MigrationsURL, migration.Postgres())
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		registry.Register("db", health.CheckerFunc(sqlDB.PingContext))
+		registry.Register("migrations", health.CheckerFunc(func(ctx context.Context) error {
+			version, dirty, err := db.MigrationVersion(ctx, sqlDB)
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("migration %d is dirty", version)
+			}
+			if version != latest {
+				return fmt.Errorf("migration is at version %d, the latest is %d", version, latest)
+			}
+			return nil
+		}))
+	}
+
+	if redisClient != nil {
+		registry.Register("redis", health.CheckerFunc(func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}))
+	}
+
+	return registry, nil
+}
+
+func NewRepositoryStorage(conf *config.AppConfig, log logger.Logger, dbw db.DBWrapper, todoItemCache cache.Cache, reg prometheus.Registerer) RepositoryStorage {
+	var repos RepositoryStorage
+	switch conf.DB.Driver {
+	case config.DriverMemory:
+		repos = RepositoryStorage{
+			unitOfWork:       db.NewNoopUnitOfWork(),
+			todoItemRepo:     todoItemMemoryRepo.NewTodoItemRepository(),
+			todoItemFeedRepo: todoItemMemoryRepo.NewTodoItemFeedRepository(),
+		}
+	case config.DriverSqlite:
+		repos = RepositoryStorage{
+			unitOfWork:       db.NewGormUnitOfWork(dbw.DB),
+			todoItemRepo:     todoItemSqliteRepo.NewTodoItemRepository(dbw),
+			todoItemFeedRepo: todoItemSqliteRepo.NewTodoItemFeedRepository(dbw),
+		}
+	default:
+		repos = RepositoryStorage{
+			unitOfWork:       db.NewGormUnitOfWork(dbw.DB),
+			todoItemRepo:     todoItemOutboundRepo.NewTodoItemRepository(dbw),
+			todoItemFeedRepo: todoItemOutboundRepo.NewTodoItemFeedRepository(dbw),
+		}
+	}
+	repos.todoItemEventBroker = todoItemEventBroker.NewTodoItemEventBroker(conf.Core.Stream.HistorySize, conf.Core.Stream.BufferSize)
+	repos.todoItemMetrics = todoItemMetrics.NewTodoItemMetrics(reg)
+
+	if todoItemCache != nil {
+		var ttl time.Duration
+		if conf.DB.Cache.TTL != "" {
+			ttl = conf.DB.Cache.TTL.Duration()
+		}
+		repos.todoItemRepo = todoItemCachedRepo.NewTodoItemRepository(repos.todoItemRepo, todoItemCache, ttl, log.CloneAsInfra())
+	}
+
+	return repos
 }
 
 func NewServiceStorage(log logger.Logger, repos RepositoryStorage) ServiceStorage {
 	return ServiceStorage{
-		todoItemSvc: todoItemService.NewTodoItemService(todoItemService.TodoItemConfig{Logger: log, TodoItemRepo: repos.todoItemRepo}),
+		todoItemSvc: todoItemService.NewTodoItemService(todoItemService.TodoItemConfig{
+			Logger:       log,
+			UnitOfWork:   repos.unitOfWork,
+			TodoItemRepo: repos.todoItemRepo,
+		}),
+		todoItemStreamSvc: todoItemService.NewTodoItemStreamService(todoItemService.TodoItemStreamConfig{
+			Logger:          log,
+			TodoItemEvent:   repos.todoItemEventBroker,
+			TodoItemMetrics: repos.todoItemMetrics,
+		}),
+		todoItemCalendarSvc: todoItemService.NewTodoItemCalendarService(todoItemService.TodoItemCalendarConfig{
+			Logger:           log,
+			UnitOfWork:       repos.unitOfWork,
+			TodoItemRepo:     repos.todoItemRepo,
+			TodoItemFeedRepo: repos.todoItemFeedRepo,
+		}),
 	}
 }
 
@@ -112,6 +506,7 @@ func NewHttpAdaptorStorage(
 	httpApps ApplicationStorage,
 ) HttpAdaptorStorage {
 	return HttpAdaptorStorage{
-		TodoItemAdaptor: todoItemHttpAdaptor.Adaptor{TodoItemHttpApp: httpApps.todoItemApp},
+		TodoItemAdaptor:         todoItemHttpAdaptor.Adaptor{TodoItemHttpApp: httpApps.todoItemApp},
+		TodoItemCalendarAdaptor: todoItemHttpAdaptor.CalendarAdaptor{TodoItemCalendarHttpApp: httpApps.todoItemCalendarApp},
 	}
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+// Client is a thin wrapper over the todo-items endpoints, it unwraps the `payload`/`meta` envelope
+type Client struct {
+	baseUrl string
+	token   string
+	traceId string
+	http    *http.Client
+
+	// OnTrace is called with the trace id the server echoes for every request
+	OnTrace func(method, path, traceId string)
+}
+
+// APIError is a non 2xx answer of the server
+type APIError struct {
+	Status  int
+	Message string
+	Causes  any
+	TraceId string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+
+	if e.TraceId == "" {
+		return fmt.Sprintf("%s (status %d)", msg, e.Status)
+	}
+
+	return fmt.Sprintf("%s (status %d, trace id %s)", msg, e.Status, e.TraceId)
+}
+
+func NewClient(server, token, traceId string, timeout time.Duration) *Client {
+	return &Client{
+		baseUrl: strings.TrimSuffix(server, "/"),
+		token:   token,
+		traceId: traceId,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) Create(ctx context.Context, req dto.CreateTodoItemRequest) (res dto.TodoItem, err error) {
+	err = c.do(ctx, http.MethodPost, "/todo-items", nil, req, &res)
+	return res, err
+}
+
+func (c *Client) Update(ctx context.Context, id string, req dto.UpdateTodoItemRequest) (res dto.TodoItem, err error) {
+	err = c.do(ctx, http.MethodPut, "/todo-items/"+url.PathEscape(id), nil, req, &res)
+	return res, err
+}
+
+func (c *Client) Get(ctx context.Context, id string) (res dto.TodoItem, err error) {
+	err = c.do(ctx, http.MethodGet, "/todo-items/"+url.PathEscape(id), nil, nil, &res)
+	return res, err
+}
+
+// TodoItemList is response.ListResponse with typed items
+type TodoItemList struct {
+	Pagination response.PaginationInfo `json:"pagination"`
+	Items      []dto.TodoItem          `json:"items"`
+}
+
+func (c *Client) List(ctx context.Context, ids []string, page, pageSize int) (res TodoItemList, err error) {
+	query := url.Values{}
+	if len(ids) > 0 {
+		query.Set("ids", strings.Join(ids, ","))
+	}
+	if page > 0 {
+		query.Set("page", fmt.Sprint(page))
+	}
+	if pageSize > 0 {
+		query.Set("pageSize", fmt.Sprint(pageSize))
+	}
+
+	err = c.do(ctx, http.MethodGet, "/todo-items", query, nil, &res)
+	return res, err
+}
+
+// Complete marks the item done, or open again when done is false
+func (c *Client) Complete(ctx context.Context, id string, done bool) (res dto.TodoItem, err error) {
+	method := http.MethodPut
+	if !done {
+		method = http.MethodDelete
+	}
+
+	err = c.do(ctx, method, "/todo-items/"+url.PathEscape(id)+"/done", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/todo-items/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) Purge(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/todo-items/purge/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	target := c.baseUrl + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(middleware.AuthorizationHeader, middleware.Bearer+" "+c.token)
+	}
+	if c.traceId != "" {
+		req.Header.Set(middleware.XTraceIdKey, c.traceId)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	traceId := resp.Header.Get(middleware.XTraceIdKey)
+	if c.OnTrace != nil && traceId != "" {
+		c.OnTrace(method, path, traceId)
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	var envelope struct {
+		Payload json.RawMessage      `json:"payload"`
+		Meta    response.ErrResponse `json:"meta"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&envelope)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{
+			Status:  resp.StatusCode,
+			Message: envelope.Meta.Message,
+			Causes:  envelope.Meta.Causes,
+			TraceId: traceId,
+		}
+	}
+
+	if decodeErr != nil && decodeErr != io.EOF {
+		return fmt.Errorf("cannot decode response: %w", decodeErr)
+	}
+
+	if out == nil || len(envelope.Payload) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(envelope.Payload, out)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+)
+
+func runAdd(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	var description, due string
+	fs.StringVar(&description, "d", "", "description, the positional arguments are used when omitted")
+	fs.StringVar(&description, "description", "", "description, the positional arguments are used when omitted")
+	fs.StringVar(&due, "due", "", "due date, e.g. 2025-01-31")
+
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if description == "" {
+		description = strings.Join(positional, " ")
+	}
+	if description == "" || due == "" {
+		return usageError{msg: "add needs a description and --due"}
+	}
+
+	if err = c.connect(); err != nil {
+		return err
+	}
+
+	item, err := c.client.Create(ctx, dto.CreateTodoItemRequest{Description: description, DueDate: due})
+	if err != nil {
+		return err
+	}
+
+	return c.out.item(item)
+}
+
+func runList(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	var ids string
+	var page, pageSize int
+	fs.StringVar(&ids, "ids", "", "only these ids, comma separated")
+	fs.IntVar(&page, "page", 1, "page, starts from 1")
+	fs.IntVar(&pageSize, "page-size", 0, "items per page (server default when 0)")
+
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError{msg: "ls takes no arguments"}
+	}
+
+	if err = c.connect(); err != nil {
+		return err
+	}
+
+	list, err := c.client.List(ctx, splitList(ids), page, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return c.out.list(list)
+}
+
+func runShow(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	id, err := c.parseId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.out.item(item)
+}
+
+func runEdit(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	var description, due string
+	fs.StringVar(&description, "d", "", "new description")
+	fs.StringVar(&description, "description", "", "new description")
+	fs.StringVar(&due, "due", "", "new due date")
+
+	id, err := c.parseId(fs, args)
+	if err != nil {
+		return err
+	}
+	if description == "" && due == "" {
+		return usageError{msg: "edit needs -d or --due"}
+	}
+
+	// The API only replaces whole items, unchanged fields are taken from the current state
+	item, err := c.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	req := dto.UpdateTodoItemRequest{Description: item.Description, DueDate: item.DueDate}
+	if description != "" {
+		req.Description = description
+	}
+	if due != "" {
+		req.DueDate = due
+	}
+
+	item, err = c.client.Update(ctx, id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.out.item(item)
+}
+
+func runDone(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	var undo bool
+	fs.BoolVar(&undo, "undo", false, "mark the items open again")
+
+	ids, err := c.parseIds(fs, args)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		item, err := c.client.Complete(ctx, id, !undo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+
+		if err = c.out.item(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runRemove(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	ids, err := c.parseIds(fs, args)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err = c.client.Delete(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+
+		if err = c.out.removed(id, "deleted"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runPurge(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error {
+	var yes bool
+	fs.BoolVar(&yes, "y", false, "do not ask for confirmation")
+	fs.BoolVar(&yes, "yes", false, "do not ask for confirmation")
+
+	ids, err := c.parseIds(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !yes && !c.confirm(fmt.Sprintf("Purge %d item(s) permanently? [y/N] ", len(ids))) {
+		return errors.New("aborted")
+	}
+
+	for _, id := range ids {
+		if err = c.client.Purge(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+
+		if err = c.out.removed(id, "purged"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseId parses a command that takes exactly one id and connects
+func (c *cli) parseId(fs *flag.FlagSet, args []string) (string, error) {
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return "", err
+	}
+	if len(positional) != 1 {
+		return "", usageError{msg: fs.Name() + " needs exactly one id"}
+	}
+
+	return positional[0], c.connect()
+}
+
+// parseIds parses a command that takes one or more ids and connects
+func (c *cli) parseIds(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) == 0 {
+		return nil, usageError{msg: fs.Name() + " needs at least one id"}
+	}
+
+	return positional, c.connect()
+}
+
+func (c *cli) confirm(prompt string) bool {
+	fmt.Fprint(c.stderr, prompt)
+
+	answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
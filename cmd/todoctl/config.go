@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultServer = "http://localhost:1212/api/v1"
+	envPrefix     = "TODOCTL"
+)
+
+// Config is read from the config file first, TODOCTL_* environment variables and flags win over it
+type Config struct {
+	Server  string `mapstructure:"server"`
+	Token   string `mapstructure:"token"`
+	Output  string `mapstructure:"output"`
+	TraceId string `mapstructure:"trace_id"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "todoctl", "config.yml")
+}
+
+// LoadConfig reads path, a missing file is only an error when the path was asked for explicitly
+func LoadConfig(path string) (Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	v.SetDefault("server", defaultServer)
+	v.SetDefault("token", "")
+	v.SetDefault("output", outputTable)
+	v.SetDefault("trace_id", "")
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envPrefix + "_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			var notFound viper.ConfigFileNotFoundError
+			if explicit || !(errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist)) {
+				return Config{}, err
+			}
+		}
+	}
+
+	var conf Config
+	if err := v.Unmarshal(&conf); err != nil {
+		return Config{}, err
+	}
+
+	return conf, nil
+}
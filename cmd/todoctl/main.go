@@ -0,0 +1,269 @@
+// Command todoctl manages todo items through the HTTP API.
+//
+//	todoctl [flags] <command> [command flags] [args]
+//
+// The server url and bearer token come from the config file (~/.config/todoctl/config.yml),
+// TODOCTL_SERVER/TODOCTL_TOKEN or the --server/--token flags, later ones win.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// options are accepted before and after the command name
+type options struct {
+	configPath string
+	server     string
+	token      string
+	output     string
+	traceId    string
+	timeout    time.Duration
+	verbose    bool
+}
+
+// register binds the options to fs, the current values are the defaults so a second
+// flag set does not reset what the first one parsed
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", o.configPath, "config file (default ~/.config/todoctl/config.yml, env TODOCTL_CONFIG)")
+	fs.StringVar(&o.server, "server", o.server, "API base url (env TODOCTL_SERVER, default "+defaultServer+")")
+	fs.StringVar(&o.token, "token", o.token, "bearer token (env TODOCTL_TOKEN)")
+	fs.StringVar(&o.output, "o", o.output, "output format: table or json (env TODOCTL_OUTPUT)")
+	fs.StringVar(&o.output, "output", o.output, "output format: table or json (env TODOCTL_OUTPUT)")
+	fs.StringVar(&o.traceId, "trace-id", o.traceId, "X-Trace-Id sent with every request (env TODOCTL_TRACE_ID)")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "request timeout")
+	fs.BoolVar(&o.verbose, "v", o.verbose, "print the trace id of every request to stderr")
+}
+
+// usageError is a wrong invocation, it exits with 2 like flag parse errors
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+type command struct {
+	args    string
+	summary string
+	// run parses args with fs, which already carries the shared options
+	run func(ctx context.Context, c *cli, fs *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"add":   {args: "[-d] <description> --due <date>", summary: "create a todo item", run: runAdd},
+	"ls":    {args: "[--ids id,...] [--page n] [--page-size n]", summary: "list todo items", run: runList},
+	"show":  {args: "<id>", summary: "show a todo item", run: runShow},
+	"edit":  {args: "<id> [-d <description>] [--due <date>]", summary: "change a todo item", run: runEdit},
+	"done":  {args: "[--undo] <id>...", summary: "mark todo items as done, or open again with --undo", run: runDone},
+	"rm":    {args: "<id>...", summary: "delete todo items, they can still be purged later", run: runRemove},
+	"purge": {args: "[-y] <id>...", summary: "delete todo items permanently", run: runPurge},
+}
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	opts   options
+	client *Client
+	out    printer
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr, opts: options{timeout: defaultTimeout}}
+
+	global := c.flagSet("todoctl")
+	global.Usage = c.usage
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if global.NArg() == 0 {
+		c.usage()
+		return 2
+	}
+
+	name := global.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "todoctl: unknown command %q\n\n", name)
+		c.usage()
+		return 2
+	}
+
+	fs := c.flagSet(name)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: todoctl %s %s\n\n%s\n\n", name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+
+	err := cmd.run(ctx, c, fs, global.Args()[1:])
+	if err == nil {
+		return 0
+	}
+
+	if !errors.Is(err, flag.ErrHelp) {
+		c.printError(err)
+	}
+
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var usageErr usageError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.opts.register(fs)
+	return fs
+}
+
+// parse lets flags follow positional arguments, `todoctl add "Buy milk" --due 2025-01-01`
+func (c *cli) parse(fs *flag.FlagSet, args []string) (positional []string, err error) {
+	for {
+		if err = fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{msg: err.Error()}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// connect merges config file, environment and flags and builds the client
+func (c *cli) connect() error {
+	conf, err := LoadConfig(c.opts.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+
+	if c.opts.server != "" {
+		conf.Server = c.opts.server
+	}
+	if c.opts.token != "" {
+		conf.Token = c.opts.token
+	}
+	if c.opts.output != "" {
+		conf.Output = c.opts.output
+	}
+	if c.opts.traceId != "" {
+		conf.TraceId = c.opts.traceId
+	}
+
+	if conf.Output != outputTable && conf.Output != outputJSON {
+		return usageError{msg: fmt.Sprintf("unknown output format %q, use table or json", conf.Output)}
+	}
+
+	c.out = printer{w: c.stdout, format: conf.Output}
+	c.client = NewClient(conf.Server, conf.Token, conf.TraceId, c.opts.timeout)
+	if c.opts.verbose {
+		c.client.OnTrace = func(method, path, traceId string) {
+			fmt.Fprintf(c.stderr, "%s %s trace id %s\n", method, path, traceId)
+		}
+	}
+
+	return nil
+}
+
+func (c *cli) printError(err error) {
+	fmt.Fprintf(c.stderr, "todoctl: %v\n", err)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return
+	}
+
+	causes, ok := apiErr.Causes.([]any)
+	if !ok {
+		return
+	}
+
+	for _, cause := range causes {
+		if field, ok := cause.(map[string]any); ok && field["message"] != nil {
+			fmt.Fprintf(c.stderr, "  - %v\n", field["message"])
+			continue
+		}
+		fmt.Fprintf(c.stderr, "  - %v\n", cause)
+	}
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "usage: todoctl [flags] <command> [command flags] [args]")
+	fmt.Fprintln(c.stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, commands[name].summary)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(c.stderr, "\nflags:")
+	fs := flag.NewFlagSet("todoctl", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	(&options{timeout: defaultTimeout}).register(fs)
+	fs.PrintDefaults()
+
+	fmt.Fprintln(c.stderr, "\nRun 'todoctl <command> -h' for the flags of a command.")
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) json(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p printer) item(item dto.TodoItem) error {
+	if p.format == outputJSON {
+		return p.json(item)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", item.Id)
+	fmt.Fprintf(tw, "Description:\t%s\n", item.Description)
+	fmt.Fprintf(tw, "Due:\t%s\n", item.DueDate)
+	fmt.Fprintf(tw, "Done:\t%s\n", formatCompletedAt(item.CompletedAt))
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(item.CreatedAt))
+	fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(item.UpdatedAt))
+	return tw.Flush()
+}
+
+func (p printer) list(list TodoItemList) error {
+	if p.format == outputJSON {
+		return p.json(list)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDESCRIPTION\tDUE\tDONE\tCREATED")
+	for _, item := range list.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", item.Id, item.Description, item.DueDate, formatCompletedAt(item.CompletedAt), formatTime(item.CreatedAt))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	shown := int64(len(list.Items))
+	if total := list.Pagination.TotalItems; total != nil && *total > shown {
+		_, err := fmt.Fprintf(p.w, "\npage %d, %d of %d items\n", list.Pagination.Page, shown, *total)
+		return err
+	}
+
+	return nil
+}
+
+// removed reports a deleted or purged id
+func (p printer) removed(id, action string) error {
+	if p.format == outputJSON {
+		return p.json(map[string]string{"id": id, "action": action})
+	}
+
+	_, err := fmt.Fprintf(p.w, "%s %s\n", action, id)
+	return err
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatCompletedAt(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return formatTime(*t)
+}
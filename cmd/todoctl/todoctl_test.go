@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+const testToken = "secret"
+
+// fakeServer answers like the todo-items endpoints, with the same envelope and trace id echo
+type fakeServer struct {
+	mu        sync.Mutex
+	items     map[uuid.UUID]dto.TodoItem
+	traceIds  []string
+	purgedIds []string
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	f := &fakeServer{items: map[uuid.UUID]dto.TodoItem{}}
+
+	r := gin.New()
+	api := r.Group("/api/v1", func(ginCtx *gin.Context) {
+		traceId := ginCtx.GetHeader("X-Trace-Id")
+		if traceId == "" {
+			traceId = uuid.NewString()
+		}
+		f.mu.Lock()
+		f.traceIds = append(f.traceIds, traceId)
+		f.mu.Unlock()
+		ginCtx.Header("X-Trace-Id", traceId)
+
+		if ginCtx.GetHeader("Authorization") != "Bearer "+testToken {
+			err := errors.New("token is invalid")
+			appErr.HandelError(ginCtx, appErr.CodeUnauthorized.New(err))
+		}
+	})
+
+	api.POST("/todo-items", func(ginCtx *gin.Context) {
+		var req dto.CreateTodoItemRequest
+		_ = ginCtx.ShouldBindJSON(&req)
+		item := dto.TodoItem{Id: uuid.New(), Description: req.Description, DueDate: req.DueDate, CreatedAt: time.Now()}
+		f.mu.Lock()
+		f.items[item.Id] = item
+		f.mu.Unlock()
+		appErr.CreatedResponse(ginCtx, item)
+	})
+	api.GET("/todo-items", func(ginCtx *gin.Context) {
+		f.mu.Lock()
+		items := make([]dto.TodoItem, 0, len(f.items))
+		for _, item := range f.items {
+			items = append(items, item)
+		}
+		f.mu.Unlock()
+		appErr.OKResponse(ginCtx, appErr.PaginationListResponse(items, int64(len(items)), 12, 1))
+	})
+	api.GET("/todo-items/:id", func(ginCtx *gin.Context) {
+		item, ok := f.find(ginCtx.Param("id"))
+		if !ok {
+			err := errors.New("todo item not found")
+			appErr.HandelError(ginCtx, appErr.CodeNotFound.NewWithDetail(err, err.Error()))
+			return
+		}
+		appErr.OKResponse(ginCtx, item)
+	})
+	api.PUT("/todo-items/:id", func(ginCtx *gin.Context) {
+		item, _ := f.find(ginCtx.Param("id"))
+		var req dto.UpdateTodoItemRequest
+		_ = ginCtx.ShouldBindJSON(&req)
+		item.Description, item.DueDate = req.Description, req.DueDate
+		f.mu.Lock()
+		f.items[item.Id] = item
+		f.mu.Unlock()
+		appErr.OKResponse(ginCtx, item)
+	})
+	complete := func(done bool) gin.HandlerFunc {
+		return func(ginCtx *gin.Context) {
+			item, ok := f.find(ginCtx.Param("id"))
+			if !ok {
+				err := errors.New("todo item not found")
+				appErr.HandelError(ginCtx, appErr.CodeNotFound.NewWithDetail(err, err.Error()))
+				return
+			}
+			item.CompletedAt = nil
+			if done {
+				now := time.Now()
+				item.CompletedAt = &now
+			}
+			f.mu.Lock()
+			f.items[item.Id] = item
+			f.mu.Unlock()
+			appErr.OKResponse(ginCtx, item)
+		}
+	}
+	api.PUT("/todo-items/:id/done", complete(true))
+	api.DELETE("/todo-items/:id/done", complete(false))
+	api.DELETE("/todo-items/purge/:id", func(ginCtx *gin.Context) {
+		f.mu.Lock()
+		f.purgedIds = append(f.purgedIds, ginCtx.Param("id"))
+		f.mu.Unlock()
+		appErr.NoContentResponse(ginCtx)
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeServer) find(id string) (dto.TodoItem, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[uuid.MustParse(id)]
+	return item, ok
+}
+
+// isolate hides the config file and environment of the machine running the tests
+func isolate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	for _, key := range []string{"CONFIG", "SERVER", "TOKEN", "OUTPUT", "TRACE_ID"} {
+		t.Setenv("TODOCTL_"+key, "")
+	}
+}
+
+func todoctl(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestTodoctl_AddShowJSON(t *testing.T) {
+	f, srv := newFakeServer(t)
+	isolate(t)
+	t.Setenv("TODOCTL_TOKEN", testToken)
+	traceId := uuid.NewString()
+
+	code, stdout, stderr := todoctl(t, "", "--server", srv.URL+"/api/v1", "-o", "json", "--trace-id", traceId,
+		"add", "Buy", "milk", "--due", "2025-01-31")
+	assert.Equal(t, 0, code, stderr)
+
+	var created dto.TodoItem
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, "Buy milk", created.Description)
+	assert.Equal(t, "2025-01-31", created.DueDate)
+	assert.Equal(t, []string{traceId}, f.traceIds)
+
+	code, stdout, stderr = todoctl(t, "", "show", created.Id.String(), "--server", srv.URL+"/api/v1")
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Buy milk")
+	assert.Contains(t, stdout, created.Id.String())
+}
+
+func TestTodoctl_ConfigFile(t *testing.T) {
+	_, srv := newFakeServer(t)
+	isolate(t)
+	path := filepath.Join(t.TempDir(), "config.yml")
+	conf := "server: " + srv.URL + "/api/v1\ntoken: " + testToken + "\noutput: table\n"
+	assert.NoError(t, os.WriteFile(path, []byte(conf), 0o600))
+
+	code, _, stderr := todoctl(t, "", "--config", path, "add", "-d", "Call mom", "--due", "2025-02-01")
+	assert.Equal(t, 0, code, stderr)
+
+	code, stdout, stderr := todoctl(t, "", "--config", path, "ls")
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "DESCRIPTION")
+	assert.Contains(t, stdout, "Call mom")
+
+	// The environment wins over the file
+	t.Setenv("TODOCTL_TOKEN", "wrong")
+	code, _, stderr = todoctl(t, "", "--config", path, "ls")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "status 401")
+}
+
+func TestTodoctl_EditKeepsUnchangedFields(t *testing.T) {
+	f, srv := newFakeServer(t)
+	isolate(t)
+	t.Setenv("TODOCTL_SERVER", srv.URL+"/api/v1")
+	t.Setenv("TODOCTL_TOKEN", testToken)
+
+	id := uuid.New()
+	f.items[id] = dto.TodoItem{Id: id, Description: "Old", DueDate: "2025-01-01"}
+
+	code, _, stderr := todoctl(t, "", "edit", id.String(), "--due", "2025-03-01")
+	assert.Equal(t, 0, code, stderr)
+
+	item, _ := f.find(id.String())
+	assert.Equal(t, "Old", item.Description)
+	assert.Equal(t, "2025-03-01", item.DueDate)
+}
+
+func TestTodoctl_ErrorShowsTraceId(t *testing.T) {
+	_, srv := newFakeServer(t)
+	isolate(t)
+	t.Setenv("TODOCTL_SERVER", srv.URL+"/api/v1")
+	t.Setenv("TODOCTL_TOKEN", testToken)
+	traceId := uuid.NewString()
+
+	code, _, stderr := todoctl(t, "", "--trace-id", traceId, "show", uuid.NewString())
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "todo item not found")
+	assert.Contains(t, stderr, "status 404, trace id "+traceId)
+}
+
+func TestTodoctl_PurgeAsksForConfirmation(t *testing.T) {
+	f, srv := newFakeServer(t)
+	isolate(t)
+	t.Setenv("TODOCTL_SERVER", srv.URL+"/api/v1")
+	t.Setenv("TODOCTL_TOKEN", testToken)
+	id := uuid.NewString()
+
+	code, _, stderr := todoctl(t, "n\n", "purge", id)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "aborted")
+	assert.Empty(t, f.purgedIds)
+
+	code, stdout, stderr := todoctl(t, "", "purge", "-y", id)
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "purged "+id+"\n", stdout)
+	assert.Equal(t, []string{id}, f.purgedIds)
+}
+
+func TestTodoctl_Usage(t *testing.T) {
+	isolate(t)
+	code, _, stderr := todoctl(t, "", "frobnicate")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "frobnicate"`)
+
+	code, _, stderr = todoctl(t, "", "add", "--due", "2025-01-01")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "add needs a description")
+
+	code, _, stderr = todoctl(t, "", "done")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "done needs at least one id")
+}
+
+func TestTodoctl_Done(t *testing.T) {
+	f, srv := newFakeServer(t)
+	isolate(t)
+	t.Setenv("TODOCTL_SERVER", srv.URL+"/api/v1")
+	t.Setenv("TODOCTL_TOKEN", testToken)
+
+	item := dto.TodoItem{Id: uuid.New(), Description: "Buy milk", DueDate: "2025-01-31"}
+	f.items[item.Id] = item
+
+	code, stdout, stderr := todoctl(t, "", "-o", "json", "done", item.Id.String())
+	assert.Equal(t, 0, code, stderr)
+	var done dto.TodoItem
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &done))
+	assert.NotNil(t, done.CompletedAt)
+	stored, _ := f.find(item.Id.String())
+	assert.NotNil(t, stored.CompletedAt)
+
+	code, stdout, stderr = todoctl(t, "", "done", "--undo", item.Id.String())
+	assert.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `Done:\s+-`, stdout)
+	stored, _ = f.find(item.Id.String())
+	assert.Nil(t, stored.CompletedAt)
+
+	code, _, stderr = todoctl(t, "", "done", uuid.NewString())
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "todo item not found")
+}
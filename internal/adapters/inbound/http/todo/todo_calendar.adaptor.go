@@ -0,0 +1,22 @@
+package poll
+
+import (
+	"github.com/gin-gonic/gin"
+	service "github.com/thealiakbari/todoapp/internal/application/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/ginh"
+)
+
+type CalendarAdaptor struct {
+	service.TodoItemCalendarHttpApp
+}
+
+// RegisterRoutes serves the same calendar routes in every version
+func (a CalendarAdaptor) RegisterRoutes(version ginh.APIVersion, r *gin.RouterGroup) {
+	apiCalendar := r.Group("/todo-items/ics")
+
+	apiCalendar.POST("/feeds", a.MakeCreateFeed())
+	apiCalendar.GET("/feeds/:token", a.MakeGetFeed())
+	apiCalendar.DELETE("/feeds/:id", a.MakeRevokeFeed())
+
+	apiCalendar.POST("/import", ginh.LiftDeadlines, a.MakeImport())
+}
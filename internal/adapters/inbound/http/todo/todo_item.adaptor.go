@@ -3,19 +3,38 @@ package poll
 import (
 	"github.com/gin-gonic/gin"
 	service "github.com/thealiakbari/todoapp/internal/application/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/ginh"
 )
 
 type Adaptor struct {
 	service.TodoItemHttpApp
 }
 
-func (a Adaptor) RegisterRoutes(r *gin.RouterGroup) {
+func (a Adaptor) RegisterRoutes(version ginh.APIVersion, r *gin.RouterGroup) {
 	apiTodoItem := r.Group("/todo-items")
 
-	apiTodoItem.POST("", a.MakeCreate())
-	apiTodoItem.PUT("/:id", a.MakeUpdate())
+	switch version {
+	case ginh.APIV1:
+		apiTodoItem.POST("", a.MakeCreate())
+		apiTodoItem.PUT("/:id", a.MakeUpdate())
+		apiTodoItem.GET("", a.MakeList())
+		apiTodoItem.GET("/stream", ginh.LiftDeadlines, a.MakeStream())
+		apiTodoItem.GET("/export", ginh.LiftDeadlines, a.MakeExport())
+		apiTodoItem.GET("/:id", a.MakeGetById())
+		apiTodoItem.PUT("/:id/done", a.MakeDone())
+		apiTodoItem.DELETE("/:id/done", a.MakeReopen())
+	case ginh.APIV2:
+		apiTodoItem.POST("", a.MakeCreateV2())
+		apiTodoItem.PUT("/:id", a.MakeUpdateV2())
+		apiTodoItem.GET("", a.MakeListV2())
+		apiTodoItem.GET("/stream", ginh.LiftDeadlines, a.MakeStreamV2())
+		apiTodoItem.GET("/export", ginh.LiftDeadlines, a.MakeExportV2())
+		apiTodoItem.GET("/:id", a.MakeGetByIdV2())
+		apiTodoItem.PUT("/:id/done", a.MakeDoneV2())
+		apiTodoItem.DELETE("/:id/done", a.MakeReopenV2())
+	}
 
-	apiTodoItem.GET("/:id", a.MakeGetById())
+	apiTodoItem.POST("/import", ginh.LiftDeadlines, a.MakeImport())
 
 	apiTodoItem.DELETE("/:id", a.MakeDelete())
 	apiTodoItem.DELETE("/purge/:id", a.MakePurge())
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+)
+
+var ErrBrokerClosed = errors.New("todo item event broker is closed")
+
+const (
+	defaultHistorySize = 1024
+	defaultBufferSize  = 64
+)
+
+type subscriber struct {
+	events chan entity.TodoItemEvent
+}
+
+type todoItemEventBroker struct {
+	mu          sync.Mutex
+	closed      bool
+	lastId      uint64
+	history     []entity.TodoItemEvent
+	historySize int
+	bufferSize  int
+	subscribers map[*subscriber]struct{}
+}
+
+// NewTodoItemEventBroker keeps the last `historySize` events in memory so reconnecting
+// subscribers can resume, `bufferSize` is how far a subscriber may lag before it is dropped
+func NewTodoItemEventBroker(historySize int, bufferSize int) todo.TodoItemEventBroker {
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	return &todoItemEventBroker{
+		history:     make([]entity.TodoItemEvent, 0, historySize),
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (b *todoItemEventBroker) Publish(ctx context.Context, event entity.TodoItemEvent) (res entity.TodoItemEvent, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return entity.TodoItemEvent{}, ErrBrokerClosed
+	}
+
+	b.lastId++
+	event.Id = b.lastId
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	if len(b.history) == b.historySize {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, event)
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			// The subscriber is too slow, drop it so it reconnects and resumes from its cursor
+			b.removeLocked(sub)
+		}
+	}
+
+	return event, nil
+}
+
+func (b *todoItemEventBroker) Subscribe(ctx context.Context, lastEventId uint64) (res <-chan entity.TodoItemEvent, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	backlog := b.backlogLocked(lastEventId)
+	sub := &subscriber{events: make(chan entity.TodoItemEvent, len(backlog)+b.bufferSize)}
+	for _, event := range backlog {
+		sub.events <- event
+	}
+	b.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.removeLocked(sub)
+	}()
+
+	return sub.events, nil
+}
+
+func (b *todoItemEventBroker) Close() (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.removeLocked(sub)
+	}
+
+	return nil
+}
+
+// backlogLocked returns the retained events after `lastEventId`, prefixed with a reset
+// event when the cursor is older than the history or belongs to a previous process
+func (b *todoItemEventBroker) backlogLocked(lastEventId uint64) []entity.TodoItemEvent {
+	if lastEventId == 0 {
+		return nil
+	}
+
+	if lastEventId > b.lastId || (len(b.history) > 0 && lastEventId < b.history[0].Id-1) {
+		reset := entity.TodoItemEvent{Id: b.lastId, Type: entity.TodoItemReset, OccurredAt: time.Now()}
+		return append([]entity.TodoItemEvent{reset}, b.history...)
+	}
+
+	res := make([]entity.TodoItemEvent, 0, b.lastId-lastEventId)
+	for _, event := range b.history {
+		if event.Id > lastEventId {
+			res = append(res, event)
+		}
+	}
+
+	return res
+}
+
+func (b *todoItemEventBroker) removeLocked(sub *subscriber) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+func TestTodoItemEventBroker_PublishSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := NewTodoItemEventBroker(4, 4)
+
+	events, err := broker.Subscribe(ctx, 0)
+	assert.NoError(t, err)
+
+	published, err := broker.Publish(ctx, entity.TodoItemEvent{Type: entity.TodoItemCreated})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), published.Id)
+
+	event := <-events
+	assert.Equal(t, published.Id, event.Id)
+	assert.Equal(t, entity.TodoItemCreated, event.Type)
+}
+
+func TestTodoItemEventBroker_Resume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := NewTodoItemEventBroker(4, 4)
+
+	for i := 0; i < 3; i++ {
+		_, err := broker.Publish(ctx, entity.TodoItemEvent{Type: entity.TodoItemUpdated})
+		assert.NoError(t, err)
+	}
+
+	events, err := broker.Subscribe(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), (<-events).Id)
+	assert.Equal(t, uint64(3), (<-events).Id)
+}
+
+func TestTodoItemEventBroker_ResumeExpiredCursor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := NewTodoItemEventBroker(2, 4)
+
+	for i := 0; i < 5; i++ {
+		_, err := broker.Publish(ctx, entity.TodoItemEvent{Type: entity.TodoItemUpdated})
+		assert.NoError(t, err)
+	}
+
+	events, err := broker.Subscribe(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.TodoItemReset, (<-events).Type)
+	assert.Equal(t, uint64(4), (<-events).Id)
+	assert.Equal(t, uint64(5), (<-events).Id)
+}
+
+func TestTodoItemEventBroker_Close(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := NewTodoItemEventBroker(4, 4)
+
+	events, err := broker.Subscribe(ctx, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, broker.Close())
+	_, ok := <-events
+	assert.False(t, ok)
+
+	_, err = broker.Subscribe(ctx, 0)
+	assert.ErrorIs(t, err, ErrBrokerClosed)
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/cache"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// lruCache keeps at most `size` entries in process, the least recently used one is evicted first
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+func NewLRUCache(size int) cache.Cache {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &lruCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+func (c *lruCache) GetMany(ctx context.Context, keys []string) (res map[string][]byte, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	res = make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		elem, ok := c.entries[key]
+		if !ok {
+			continue
+		}
+
+		e := elem.Value.(*entry)
+		if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+			c.remove(elem)
+			continue
+		}
+
+		c.order.MoveToFront(elem)
+		res[key] = e.value
+	}
+
+	return res, nil
+}
+
+// SetMany keeps the entries for ttl, a zero ttl keeps them until they are evicted
+func (c *lruCache) SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	for key, value := range entries {
+		if elem, ok := c.entries[key]; ok {
+			e := elem.Value.(*entry)
+			e.value, e.expiresAt = value, expiresAt
+			c.order.MoveToFront(elem)
+			continue
+		}
+
+		c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+		for c.order.Len() > c.size {
+			c.remove(c.order.Back())
+		}
+	}
+
+	return nil
+}
+
+func (c *lruCache) Delete(ctx context.Context, keys ...string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+
+	return nil
+}
+
+func (c *lruCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).key)
+}
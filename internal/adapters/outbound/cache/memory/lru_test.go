@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(2).(*lruCache)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	assert.NoError(t, c.SetMany(ctx, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, time.Minute))
+
+	// reading a makes b the least recently used
+	res, err := c.GetMany(ctx, []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), res["a"])
+
+	assert.NoError(t, c.SetMany(ctx, map[string][]byte{"c": []byte("3")}, time.Minute))
+	res, err = c.GetMany(ctx, []string{"a", "b", "c"})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.NotContains(t, res, "b")
+
+	now = now.Add(time.Minute)
+	res, err = c.GetMany(ctx, []string{"a", "c"})
+	assert.NoError(t, err)
+	assert.Empty(t, res, "expired")
+
+	assert.NoError(t, c.SetMany(ctx, map[string][]byte{"a": []byte("1")}, 0))
+	assert.NoError(t, c.Delete(ctx, "a", "missing"))
+	res, err = c.GetMany(ctx, []string{"a"})
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/cache"
+)
+
+type redisCache struct {
+	client goredis.UniversalClient
+}
+
+func NewRedisCache(client goredis.UniversalClient) cache.Cache {
+	return redisCache{
+		client: client,
+	}
+}
+
+func (c redisCache) GetMany(ctx context.Context, keys []string) (res map[string][]byte, err error) {
+	res = make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		// MGET answers nil for missing keys
+		if s, ok := value.(string); ok {
+			res[keys[i]] = []byte(s)
+		}
+	}
+
+	return res, nil
+}
+
+// SetMany writes the entries in one round trip, a zero ttl keeps them until they are evicted
+func (c redisCache) SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) (err error) {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	_, err = c.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for key, value := range entries {
+			pipe.Set(ctx, key, value, ttl)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (c redisCache) Delete(ctx context.Context, keys ...string) (err error) {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return c.client.Del(ctx, keys...).Err()
+}
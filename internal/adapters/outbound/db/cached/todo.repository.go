@@ -0,0 +1,217 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/cache"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+const todoItemKeyPrefix = "todoapp:todo_item:"
+
+// todoItemRepository serves FindByIdOrEmpty and FindByIds from the cache and reads the misses
+// through the wrapped repository, concurrent misses of the same ids share one query. Missing items
+// are not cached. Writes drop the cached item once their transaction commits, reads inside a
+// transaction skip the cache so they neither see nor store uncommitted rows. A read racing a write
+// can still store the old row, the ttl bounds how long it is served.
+type todoItemRepository struct {
+	todo.TodoItemRepository
+	cache cache.Cache
+	ttl   time.Duration
+	log   logger.InfraLogger
+	group *singleflight.Group
+}
+
+func NewTodoItemRepository(repo todo.TodoItemRepository, cache cache.Cache, ttl time.Duration, log logger.InfraLogger) todo.TodoItemRepository {
+	u := todoItemRepository{
+		TodoItemRepository: repo,
+		cache:              cache,
+		ttl:                ttl,
+		group:              &singleflight.Group{},
+	}
+	u.log = log.ForService(u)
+	return u
+}
+
+func (u todoItemRepository) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if db.InTx(ctx) {
+		return u.TodoItemRepository.FindByIdOrEmpty(ctx, id)
+	}
+
+	items, err := u.findByIds(ctx, []string{id})
+	if err != nil {
+		return entity.TodoItem{}, err
+	}
+	if len(items) == 0 {
+		return entity.TodoItem{}, nil
+	}
+
+	return items[0], nil
+}
+
+func (u todoItemRepository) FindByIds(ctx context.Context, ids []string) (res []entity.TodoItem, err error) {
+	if db.InTx(ctx) {
+		return u.TodoItemRepository.FindByIds(ctx, ids)
+	}
+
+	return u.findByIds(ctx, ids)
+}
+
+func (u todoItemRepository) Update(ctx context.Context, in entity.TodoItem) (err error) {
+	if err = u.TodoItemRepository.Update(ctx, in); err != nil {
+		return err
+	}
+
+	u.invalidate(ctx, in.Id.String())
+	return nil
+}
+
+func (u todoItemRepository) Upsert(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	if res, created, err = u.TodoItemRepository.Upsert(ctx, in); err != nil {
+		return entity.TodoItem{}, false, err
+	}
+
+	u.invalidate(ctx, res.Id.String())
+	return res, created, nil
+}
+
+func (u todoItemRepository) Delete(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if res, err = u.TodoItemRepository.Delete(ctx, id); err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	u.invalidate(ctx, id)
+	return res, nil
+}
+
+func (u todoItemRepository) Purge(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if res, err = u.TodoItemRepository.Purge(ctx, id); err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	u.invalidate(ctx, id)
+	return res, nil
+}
+
+// findByIds answers in the order of ids, each item once
+func (u todoItemRepository) findByIds(ctx context.Context, ids []string) ([]entity.TodoItem, error) {
+	ids = unique(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = todoItemKeyPrefix + id
+	}
+
+	found := make(map[string]entity.TodoItem, len(ids))
+	cached, err := u.cache.GetMany(ctx, keys)
+	if err != nil {
+		// the cache is an optimization, fall back to the repository
+		u.log.Warnf("Cannot read todo items from cache: %v", err)
+	}
+
+	var missing []string
+	for i, id := range ids {
+		value, ok := cached[keys[i]]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+
+		var item entity.TodoItem
+		if err := json.Unmarshal(value, &item); err != nil {
+			u.log.Warnf("Cannot decode cached todo item %s: %v", id, err)
+			missing = append(missing, id)
+			continue
+		}
+		found[id] = item
+	}
+
+	if len(missing) > 0 {
+		loaded, err := u.load(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range loaded {
+			found[item.Id.String()] = item
+		}
+	}
+
+	res := make([]entity.TodoItem, 0, len(found))
+	for _, id := range ids {
+		if item, ok := found[id]; ok {
+			res = append(res, item)
+		}
+	}
+
+	return res, nil
+}
+
+// load reads ids from the repository and caches what it finds, callers asking for the same ids at
+// the same time wait for the first one instead of querying again
+func (u todoItemRepository) load(ctx context.Context, ids []string) ([]entity.TodoItem, error) {
+	sorted := slices.Sorted(slices.Values(ids))
+	res, err, _ := u.group.Do(strings.Join(sorted, ","), func() (any, error) {
+		// a caller that gives up must not fail the others waiting on the same query, and a replica
+		// that has not replayed a write yet must not put the old row back in the cache
+		ctx := db.WithPrimary(context.WithoutCancel(ctx))
+
+		items, err := u.TodoItemRepository.FindByIds(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make(map[string][]byte, len(items))
+		for _, item := range items {
+			value, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			entries[todoItemKeyPrefix+item.Id.String()] = value
+		}
+
+		if err := u.cache.SetMany(ctx, entries, u.ttl); err != nil {
+			u.log.Warnf("Cannot cache todo items: %v", err)
+		}
+
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]entity.TodoItem), nil
+}
+
+func (u todoItemRepository) invalidate(ctx context.Context, id string) {
+	ctx = context.WithoutCancel(ctx)
+	db.AfterCommit(ctx, func() {
+		if err := u.cache.Delete(ctx, todoItemKeyPrefix+id); err != nil {
+			u.log.Errorf("Cannot drop todo item %s from cache: %v", id, err)
+		}
+	})
+}
+
+func unique(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res = append(res, id)
+	}
+
+	return res
+}
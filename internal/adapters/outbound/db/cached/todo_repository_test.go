@@ -0,0 +1,143 @@
+package cached
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cacheMemory "github.com/thealiakbari/todoapp/internal/adapters/outbound/cache/memory"
+	"github.com/thealiakbari/todoapp/internal/adapters/outbound/db/memory"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+)
+
+// countingRepository counts the reads reaching the wrapped repository
+type countingRepository struct {
+	todo.TodoItemRepository
+	reads atomic.Int32
+	delay time.Duration
+}
+
+func (r *countingRepository) FindByIds(ctx context.Context, ids []string) ([]entity.TodoItem, error) {
+	r.reads.Add(1)
+	time.Sleep(r.delay)
+	return r.TodoItemRepository.FindByIds(ctx, ids)
+}
+
+func setupRepository(t *testing.T) (todo.TodoItemRepository, *countingRepository) {
+	log, err := logger.NewInfra(config.ModeLocal, "todoapp", "todoapp")
+	require.NoError(t, err)
+
+	inner := &countingRepository{TodoItemRepository: memory.NewTodoItemRepository()}
+	return NewTodoItemRepository(inner, cacheMemory.NewLRUCache(100), time.Minute, log), inner
+}
+
+func TestTodoItemRepository_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := setupRepository(t)
+
+	first, err := repo.Create(ctx, entity.TodoItem{Description: "first", DueDate: "2025-01-01"})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, entity.TodoItem{Description: "second", DueDate: "2025-01-02"})
+	require.NoError(t, err)
+
+	found, err := repo.FindByIdOrEmpty(ctx, first.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", found.Description)
+	assert.Equal(t, int32(1), inner.reads.Load())
+
+	found, err = repo.FindByIdOrEmpty(ctx, first.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, found.Id)
+	assert.Equal(t, int32(1), inner.reads.Load(), "served from the cache")
+
+	// only the miss is read, the answer keeps the order of ids
+	list, err := repo.FindByIds(ctx, []string{second.Id.String(), first.Id.String(), second.Id.String()})
+	assert.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, second.Id, list[0].Id)
+	assert.Equal(t, first.Id, list[1].Id)
+	assert.Equal(t, int32(2), inner.reads.Load())
+
+	// a delete without a transaction drops the item right away
+	_, err = repo.Delete(ctx, first.Id.String())
+	assert.NoError(t, err)
+
+	found, err = repo.FindByIdOrEmpty(ctx, first.Id.String())
+	assert.NoError(t, err)
+	assert.Empty(t, found.Id)
+}
+
+func TestTodoItemRepository_InvalidateAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := setupRepository(t)
+	uow := db.NewNoopUnitOfWork()
+
+	item, err := repo.Create(ctx, entity.TodoItem{Description: "before", DueDate: "2025-01-01"})
+	require.NoError(t, err)
+	_, err = repo.FindByIdOrEmpty(ctx, item.Id.String())
+	require.NoError(t, err)
+
+	// rolled back, the cached item stays
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		item.Description = "rolled back"
+		assert.NoError(t, repo.Update(ctx, item))
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	found, err := repo.FindByIdOrEmpty(ctx, item.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "before", found.Description, "nothing is dropped on rollback")
+
+	// committed, the cached item is dropped only by the commit
+	err = uow.Do(ctx, func(txCtx context.Context) error {
+		item.Description = "after"
+		assert.NoError(t, repo.Update(txCtx, item))
+
+		found, err := repo.FindByIdOrEmpty(txCtx, item.Id.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "after", found.Description, "reads in a transaction skip the cache")
+
+		found, err = repo.FindByIdOrEmpty(ctx, item.Id.String())
+		assert.NoError(t, err)
+		assert.Equal(t, "before", found.Description)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	found, err = repo.FindByIdOrEmpty(ctx, item.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "after", found.Description)
+}
+
+func TestTodoItemRepository_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := setupRepository(t)
+	inner.delay = 50 * time.Millisecond
+
+	item, err := repo.Create(ctx, entity.TodoItem{Description: "hot", DueDate: "2025-01-01"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := repo.FindByIdOrEmpty(ctx, item.Id.String())
+			assert.NoError(t, err)
+			assert.Equal(t, item.Id, found.Id)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.reads.Load())
+}
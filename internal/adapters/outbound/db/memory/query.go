@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+)
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// match evaluates c on item the way the SQL of the gorm adapter does, c was checked already
+func match(c todo.Criteria, item entity.TodoItem) (bool, error) {
+	switch c := c.(type) {
+	case nil:
+		return true, nil
+	case todo.Eq:
+		value := columnValue(item, c.Field)
+		if c.Value == nil || value == nil {
+			// NULL never compares
+			return false, nil
+		}
+		cmp, err := compareValue(value, c.Value)
+		return cmp == 0, err
+	case todo.In:
+		for _, v := range c.Values {
+			ok, err := match(todo.Eq{Field: c.Field, Value: v}, item)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case todo.Range:
+		value := columnValue(item, c.Field)
+		if value == nil {
+			return false, nil
+		}
+		if c.From != nil {
+			cmp, err := compareValue(value, c.From)
+			if err != nil || cmp < 0 {
+				return false, err
+			}
+		}
+		if c.To != nil {
+			cmp, err := compareValue(value, c.To)
+			if err != nil || cmp > 0 {
+				return false, err
+			}
+		}
+		return true, nil
+	case todo.Contains:
+		text, ok := columnValue(item, c.Field).(string)
+		if !ok {
+			return false, fmt.Errorf("%s is not a text field", c.Field)
+		}
+		return strings.Contains(strings.ToLower(text), strings.ToLower(c.Text)), nil
+	case todo.Null:
+		return columnValue(item, c.Field) == nil, nil
+	case todo.And:
+		for _, sub := range c {
+			ok, err := match(sub, item)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case todo.Or:
+		for _, sub := range c {
+			ok, err := match(sub, item)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case todo.Not:
+		ok, err := match(c.Criteria, item)
+		return !ok, err
+	default:
+		return false, fmt.Errorf("unsupported criteria %T", c)
+	}
+}
+
+// columnValue maps fields to a string or a time.Time, due dates are kept as text like the entity.
+// A missing value is nil.
+func columnValue(item entity.TodoItem, field todo.Field) any {
+	switch field {
+	case todo.FieldId:
+		return item.Id.String()
+	case todo.FieldDescription:
+		return item.Description
+	case todo.FieldDueDate:
+		return item.DueDate
+	case todo.FieldCreatedAt:
+		return item.CreatedAt
+	case todo.FieldUpdatedAt:
+		return item.UpdatedAt
+	case todo.FieldCompletedAt:
+		if item.CompletedAt == nil {
+			return nil
+		}
+		return *item.CompletedAt
+	case todo.FieldUserReferenceId:
+		if item.UserReferenceId == nil {
+			return nil
+		}
+		return *item.UserReferenceId
+	default:
+		return nil
+	}
+}
+
+func compareValue(value any, arg any) (int, error) {
+	switch v := value.(type) {
+	case time.Time:
+		t, err := toTime(arg)
+		if err != nil {
+			return 0, err
+		}
+		return v.Compare(t), nil
+	case string:
+		// a due date compared with a time is read as the timestamp it is in Postgres
+		if t, ok := arg.(time.Time); ok {
+			vt, err := toTime(v)
+			if err != nil {
+				return 0, err
+			}
+			return vt.Compare(t), nil
+		}
+
+		s, err := toString(arg)
+		if err != nil {
+			return 0, err
+		}
+		return strings.Compare(v, s), nil
+	default:
+		return 0, fmt.Errorf("cannot compare %T", value)
+	}
+}
+
+func toString(arg any) (string, error) {
+	switch a := arg.(type) {
+	case string:
+		return a, nil
+	case *string:
+		if a == nil {
+			return "", fmt.Errorf("cannot compare with NULL")
+		}
+		return *a, nil
+	case uuid.UUID:
+		return a.String(), nil
+	case fmt.Stringer:
+		return a.String(), nil
+	default:
+		return "", fmt.Errorf("cannot compare text with %T", arg)
+	}
+}
+
+func toTime(arg any) (time.Time, error) {
+	switch a := arg.(type) {
+	case time.Time:
+		return a, nil
+	case *time.Time:
+		if a == nil {
+			return time.Time{}, fmt.Errorf("cannot compare with NULL")
+		}
+		return *a, nil
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, a); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid input syntax for type timestamp: %q", a)
+	default:
+		return time.Time{}, fmt.Errorf("cannot compare timestamp with %T", arg)
+	}
+}
+
+// checkOrder rejects unknown fields, items are ordered by id when order is empty
+func checkOrder(order []todo.Order) ([]todo.Order, error) {
+	for _, o := range order {
+		if !o.Field.Valid() {
+			return nil, fmt.Errorf("unknown field %q", o.Field)
+		}
+	}
+
+	if len(order) == 0 {
+		order = []todo.Order{{Field: todo.FieldId}}
+	}
+
+	return order, nil
+}
+
+func sortItems(items []entity.TodoItem, order []todo.Order) {
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, by := range order {
+			cmp, _ := compareValue(columnValue(items[i], by.Field), columnValue(items[j], by.Field))
+			if cmp == 0 {
+				continue
+			}
+			if by.Desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+
+		return false
+	})
+}
+
+// seekAfter reports whether item comes after the seek position in the seek order
+func seekAfter(item entity.TodoItem, seek todo.Seek) (bool, error) {
+	cmp, err := compareValue(columnValue(item, seek.Field), seek.Key)
+	if err != nil {
+		return false, err
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(item.Id.String(), seek.Id)
+	}
+
+	if seek.Desc {
+		return cmp < 0, nil
+	}
+	return cmp > 0, nil
+}
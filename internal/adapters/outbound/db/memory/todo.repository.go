@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"gorm.io/gorm"
+)
+
+// todoItemRepository keeps items in a map and follows what the gorm adapter does: deleted
+// items are only marked, Update saves the whole item and creates it when it is missing
+type todoItemRepository struct {
+	mu    sync.RWMutex
+	items map[uuid.UUID]entity.TodoItem
+}
+
+func NewTodoItemRepository() todo.TodoItemRepository {
+	return &todoItemRepository{
+		items: make(map[uuid.UUID]entity.TodoItem),
+	}
+}
+
+func (r *todoItemRepository) Create(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if in.Id == uuid.Nil {
+		in.Id = uuid.New()
+	}
+	if _, ok := r.items[in.Id]; ok {
+		return entity.TodoItem{}, gorm.ErrDuplicatedKey
+	}
+
+	now := time.Now()
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = now
+	}
+	if in.UpdatedAt.IsZero() {
+		in.UpdatedAt = now
+	}
+
+	r.items[in.Id] = in
+	return in, nil
+}
+
+func (r *todoItemRepository) Update(ctx context.Context, in entity.TodoItem) (err error) {
+	if in.Id == uuid.Nil {
+		_, err = r.Create(ctx, in)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if in.CreatedAt.IsZero() {
+		in.CreatedAt = now
+		if existing, ok := r.items[in.Id]; ok {
+			in.CreatedAt = existing.CreatedAt
+		}
+	}
+	in.UpdatedAt = now
+
+	r.items[in.Id] = in
+	return nil
+}
+
+func (r *todoItemRepository) Upsert(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	if in.Id == uuid.Nil {
+		res, err = r.Create(ctx, in)
+		return res, err == nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	existing, ok := r.items[in.Id]
+	if !ok {
+		in.CreatedAt, in.UpdatedAt, in.DeletedAt = now, now, gorm.DeletedAt{}
+		r.items[in.Id] = in
+		return in, true, nil
+	}
+
+	if !entity.SameOwner(existing.UserReferenceId, in.UserReferenceId) {
+		return entity.TodoItem{}, false, todo.ErrOwnedByAnother
+	}
+
+	existing.Description = in.Description
+	existing.DueDate = in.DueDate
+	existing.UpdatedAt = now
+	existing.DeletedAt = gorm.DeletedAt{}
+	r.items[in.Id] = existing
+	return existing, false, nil
+}
+
+func (r *todoItemRepository) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	key, err := parseId(id)
+	if err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[key]
+	if !ok || item.DeletedAt.Valid {
+		return entity.TodoItem{}, nil
+	}
+
+	return item, nil
+}
+
+func (r *todoItemRepository) FindByIds(ctx context.Context, ids []string) (res []entity.TodoItem, err error) {
+	keys := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		key, err := parseId(id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[uuid.UUID]bool, len(keys))
+	for _, key := range keys {
+		item, ok := r.items[key]
+		if !ok || item.DeletedAt.Valid || seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+func (r *todoItemRepository) Purge(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	key, err := parseId(id)
+	if err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[key]
+	if !ok {
+		return entity.TodoItem{}, nil
+	}
+
+	delete(r.items, key)
+	return item, nil
+}
+
+func (r *todoItemRepository) Delete(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	key, err := parseId(id)
+	if err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[key]
+	if !ok || item.DeletedAt.Valid {
+		return entity.TodoItem{}, nil
+	}
+
+	item.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.items[key] = item
+	return item, nil
+}
+
+func (r *todoItemRepository) FilterFind(ctx context.Context, criteria todo.Criteria, order []todo.Order, limit int, offset int) (res []entity.TodoItem, err error) {
+	order, err = checkOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = r.filter(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	sortItems(res, order)
+	return page(res, limit, offset), nil
+}
+
+func (r *todoItemRepository) FilterCount(ctx context.Context, criteria todo.Criteria) (res int64, err error) {
+	items, err := r.filter(criteria)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(items)), nil
+}
+
+func (r *todoItemRepository) FilterSeek(ctx context.Context, criteria todo.Criteria, seek todo.Seek, limit int) (res []entity.TodoItem, err error) {
+	order, err := checkOrder([]todo.Order{{Field: seek.Field, Desc: seek.Desc}, {Field: todo.FieldId, Desc: seek.Desc}})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = r.filter(criteria)
+	if err != nil {
+		return nil, err
+	}
+	sortItems(res, order)
+
+	if seek.Key != nil {
+		start := len(res)
+		for i, item := range res {
+			after, err := seekAfter(item, seek)
+			if err != nil {
+				return nil, err
+			}
+			if after {
+				start = i
+				break
+			}
+		}
+		res = res[start:]
+	}
+
+	return page(res, limit, 0), nil
+}
+
+// EstimateCount counts exactly, the items are at hand
+func (r *todoItemRepository) EstimateCount(ctx context.Context, criteria todo.Criteria) (res int64, err error) {
+	return r.FilterCount(ctx, criteria)
+}
+
+// FindInBatches walks a snapshot in id order, fc runs without the lock held so it may use the repository
+func (r *todoItemRepository) FindInBatches(ctx context.Context, criteria todo.Criteria, batchSize int, fc func(batch []entity.TodoItem) error) (err error) {
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	items, err := r.filter(criteria)
+	if err != nil {
+		return err
+	}
+	sortItems(items, []todo.Order{{Field: todo.FieldId}})
+
+	for start := 0; start < len(items); start += batchSize {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		end := min(start+batchSize, len(items))
+		if err = fc(items[start:end:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filter returns copies of the live items matching criteria
+func (r *todoItemRepository) filter(criteria todo.Criteria) ([]entity.TodoItem, error) {
+	if err := todo.CheckCriteria(criteria); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]entity.TodoItem, 0, len(r.items))
+	for _, item := range r.items {
+		if item.DeletedAt.Valid {
+			continue
+		}
+
+		ok, err := match(criteria, item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, item)
+		}
+	}
+
+	return res, nil
+}
+
+// page applies limit and offset the way SQL does, a negative limit means no limit
+func page[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return []T{}
+		}
+		items = items[offset:]
+	}
+
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// parseId fails like Postgres does for ids that are not uuids
+func parseId(id string) (uuid.UUID, error) {
+	key, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid input syntax for type uuid: %q", id)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"gorm.io/gorm"
+)
+
+type todoItemFeedRepository struct {
+	mu    sync.RWMutex
+	feeds map[uuid.UUID]entity.TodoItemFeed
+}
+
+func NewTodoItemFeedRepository() todo.TodoItemFeedRepository {
+	return &todoItemFeedRepository{
+		feeds: make(map[uuid.UUID]entity.TodoItemFeed),
+	}
+}
+
+func (r *todoItemFeedRepository) Create(ctx context.Context, in entity.TodoItemFeed) (res entity.TodoItemFeed, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if in.Id == uuid.Nil {
+		in.Id = uuid.New()
+	}
+	if _, ok := r.feeds[in.Id]; ok {
+		return entity.TodoItemFeed{}, gorm.ErrDuplicatedKey
+	}
+
+	// token_hash is unique, revoked feeds included
+	for _, feed := range r.feeds {
+		if feed.TokenHash == in.TokenHash {
+			return entity.TodoItemFeed{}, gorm.ErrDuplicatedKey
+		}
+	}
+
+	now := time.Now()
+	in.CreatedAt, in.UpdatedAt = now, now
+	r.feeds[in.Id] = in
+	return in, nil
+}
+
+func (r *todoItemFeedRepository) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItemFeed, err error) {
+	key, err := parseId(id)
+	if err != nil {
+		return entity.TodoItemFeed{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	feed, ok := r.feeds[key]
+	if !ok || feed.DeletedAt.Valid {
+		return entity.TodoItemFeed{}, nil
+	}
+
+	return feed, nil
+}
+
+func (r *todoItemFeedRepository) FindByTokenHashOrEmpty(ctx context.Context, tokenHash string) (res entity.TodoItemFeed, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, feed := range r.feeds {
+		if feed.TokenHash == tokenHash && !feed.DeletedAt.Valid {
+			return feed, nil
+		}
+	}
+
+	return entity.TodoItemFeed{}, nil
+}
+
+func (r *todoItemFeedRepository) Delete(ctx context.Context, id string) (err error) {
+	key, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	feed, ok := r.feeds[key]
+	if !ok || feed.DeletedAt.Valid {
+		return nil
+	}
+
+	feed.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.feeds[key] = feed
+	return nil
+}
@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo/todotest"
+)
+
+func TestTodoItemRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository()
+
+	// Create
+	item := entity.TodoItem{
+		Description: "Test Task",
+		DueDate:     time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	created, err := repo.Create(ctx, item)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, created.Id)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	// FindByIdOrEmpty
+	found, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, created.Id, found.Id)
+
+	// Update
+	created.Description = "Updated Task"
+	err = repo.Update(ctx, created)
+	assert.NoError(t, err)
+
+	updated, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated Task", updated.Description)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	// FindByIds
+	list, err := repo.FindByIds(ctx, []string{created.Id.String()})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// FilterFind
+	results, err := repo.FilterFind(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"}, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}}, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// FilterCount
+	count, err := repo.FilterCount(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Delete only marks the item
+	_, err = repo.Delete(ctx, created.Id.String())
+	assert.NoError(t, err)
+
+	deleted, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, deleted.Id)
+
+	count, err = repo.FilterCount(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	_, err = repo.Create(ctx, entity.TodoItem{UniversalModel: created.UniversalModel, Description: "again", DueDate: "2025-01-01"})
+	assert.Error(t, err, "a soft deleted id is still taken")
+
+	// Purge removes it for good
+	_, err = repo.Purge(ctx, created.Id.String())
+	assert.NoError(t, err)
+
+	_, err = repo.Create(ctx, entity.TodoItem{UniversalModel: created.UniversalModel, Description: "again", DueDate: "2025-01-01"})
+	assert.NoError(t, err)
+}
+
+func TestTodoItemRepository_FilterFind(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository()
+
+	var ids []string
+	for _, due := range []string{"2025-03-01", "2025-01-01", "2025-02-01"} {
+		item, err := repo.Create(ctx, entity.TodoItem{Description: "Task " + due, DueDate: due})
+		assert.NoError(t, err)
+		ids = append(ids, item.Id.String())
+	}
+
+	res, err := repo.FilterFind(ctx, nil, []todo.Order{{Field: todo.FieldDueDate}, {Field: todo.FieldId}}, 2, 0)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "2025-01-01", res[0].DueDate)
+	assert.Equal(t, "2025-02-01", res[1].DueDate)
+
+	res, err = repo.FilterFind(ctx, nil, []todo.Order{{Field: todo.FieldDueDate}}, 2, 2)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "2025-03-01", res[0].DueDate)
+
+	res, err = repo.FilterFind(ctx, todo.And{
+		todo.InValues(todo.FieldId, ids[:2]),
+		todo.Range{Field: todo.FieldDueDate, From: "2025-02-01"},
+	}, nil, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, ids[0], res[0].Id.String())
+
+	res, err = repo.FilterFind(ctx, todo.Contains{Field: todo.FieldDescription, Text: "task 2025-01"}, nil, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	_, err = repo.FilterFind(ctx, todo.And{nil}, nil, 10, 0)
+	assert.Error(t, err)
+
+	_, err = repo.FilterFind(ctx, todo.Eq{Field: "owner", Value: "a"}, nil, 10, 0)
+	assert.Error(t, err)
+
+	_, err = repo.FindByIdOrEmpty(ctx, "not-a-uuid")
+	assert.Error(t, err)
+}
+
+func TestTodoItemRepository_FindInBatches(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository()
+	for i := 0; i < 5; i++ {
+		_, err := repo.Create(ctx, entity.TodoItem{Description: "Task", DueDate: "2025-01-01"})
+		assert.NoError(t, err)
+	}
+
+	var sizes []int
+	err := repo.FindInBatches(ctx, nil, 2, func(batch []entity.TodoItem) error {
+		sizes = append(sizes, len(batch))
+		// The lock is not held while the callback runs
+		_, err := repo.FilterCount(ctx, nil)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+}
+
+func TestTodoItemRepository_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, err := repo.Create(ctx, entity.TodoItem{Description: "Task", DueDate: "2025-01-01"})
+			assert.NoError(t, err)
+			assert.NoError(t, repo.Update(ctx, item))
+			_, err = repo.FilterFind(ctx, nil, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}}, 5, 0)
+			assert.NoError(t, err)
+			_, err = repo.Delete(ctx, item.Id.String())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	count, err := repo.FilterCount(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestTodoItemRepository_FilterSeek(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository()
+
+	// two items share a created_at, the id breaks the tie
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []int{0, 1, 1, 2, 3} {
+		item := entity.TodoItem{Description: "Task", DueDate: "2025-01-01"}
+		item.CreatedAt = base.Add(time.Duration(offset) * time.Hour)
+		_, err := repo.Create(ctx, item)
+		assert.NoError(t, err)
+	}
+
+	all, err := repo.FilterFind(ctx, nil, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}, {Field: todo.FieldId, Desc: true}}, 10, 0)
+	assert.NoError(t, err)
+
+	var walked []entity.TodoItem
+	seek := todo.Seek{Field: todo.FieldCreatedAt, Desc: true}
+	for {
+		res, err := repo.FilterSeek(ctx, nil, seek, 2)
+		assert.NoError(t, err)
+		if len(res) == 0 {
+			break
+		}
+		walked = append(walked, res...)
+		last := res[len(res)-1]
+		seek.Key, seek.Id = last.CreatedAt, last.Id.String()
+	}
+	assert.Equal(t, all, walked)
+
+	// ascending from the third item returns the two before it, nearest first
+	seek = todo.Seek{Field: todo.FieldCreatedAt, Key: all[2].CreatedAt, Id: all[2].Id.String()}
+	res, err := repo.FilterSeek(ctx, nil, seek, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.TodoItem{all[1], all[0]}, res)
+
+	_, err = repo.FilterSeek(ctx, nil, todo.Seek{Field: "owner"}, 5)
+	assert.Error(t, err)
+}
+
+func TestTodoItemRepository_Conformance(t *testing.T) {
+	todotest.TestTodoItemRepository(t, func(t *testing.T) todo.TodoItemRepository {
+		return NewTodoItemRepository()
+	})
+}
@@ -0,0 +1,138 @@
+package pg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"gorm.io/gorm/clause"
+)
+
+// Dialect adapts the SQL criteria are translated to for databases other than Postgres
+type Dialect struct {
+	// Time wraps both sides of a comparison on a time field, the column or the value, nil
+	// compares them as they are
+	Time func(v any) clause.Expression
+}
+
+var (
+	matchAll     = clause.Expr{SQL: "1 = 1"}
+	matchNothing = clause.Expr{SQL: "1 = 0"}
+	likeEscaper  = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+)
+
+// where translates c to a condition, nil when it selects everything
+func (d Dialect) where(c todo.Criteria) (clause.Expression, error) {
+	if err := todo.CheckCriteria(c); err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		return nil, nil
+	}
+
+	return d.expression(c), nil
+}
+
+func (d Dialect) expression(c todo.Criteria) clause.Expression {
+	switch c := c.(type) {
+	case todo.Eq:
+		return clause.Expr{SQL: "? = ?", Vars: []any{d.column(c.Field), d.value(c.Field, c.Value)}}
+	case todo.In:
+		if len(c.Values) == 0 {
+			return matchNothing
+		}
+		values := make([]any, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = d.value(c.Field, v)
+		}
+		return clause.Expr{SQL: "? IN ?", Vars: []any{d.column(c.Field), values}}
+	case todo.Range:
+		var exprs []clause.Expression
+		if c.From != nil {
+			exprs = append(exprs, clause.Expr{SQL: "? >= ?", Vars: []any{d.column(c.Field), d.value(c.Field, c.From)}})
+		}
+		if c.To != nil {
+			exprs = append(exprs, clause.Expr{SQL: "? <= ?", Vars: []any{d.column(c.Field), d.value(c.Field, c.To)}})
+		}
+		if len(exprs) == 0 {
+			return matchAll
+		}
+		return clause.And(exprs...)
+	case todo.Contains:
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(c.Text)) + "%"
+		return clause.Expr{SQL: `LOWER(?) LIKE ? ESCAPE '\'`, Vars: []any{clause.Column{Name: string(c.Field)}, pattern}}
+	case todo.Null:
+		return clause.Expr{SQL: "? IS NULL", Vars: []any{clause.Column{Name: string(c.Field)}}}
+	case todo.And:
+		if len(c) == 0 {
+			return matchAll
+		}
+		return clause.And(d.expressions(c)...)
+	case todo.Or:
+		if len(c) == 0 {
+			return matchNothing
+		}
+		return clause.Or(d.expressions(c)...)
+	case todo.Not:
+		return clause.Expr{SQL: "NOT (?)", Vars: []any{d.expression(c.Criteria)}}
+	default:
+		// CheckCriteria let it through
+		panic(fmt.Sprintf("unsupported criteria %T", c))
+	}
+}
+
+func (d Dialect) expressions(all []todo.Criteria) []clause.Expression {
+	res := make([]clause.Expression, len(all))
+	for i, c := range all {
+		// a nested And or Or builds its own parentheses
+		res[i] = clause.Expr{SQL: "(?)", Vars: []any{d.expression(c)}}
+	}
+
+	return res
+}
+
+func (d Dialect) column(field todo.Field) any {
+	if !d.wrapsTime(field) {
+		return clause.Column{Name: string(field)}
+	}
+
+	return d.Time(clause.Column{Name: string(field)})
+}
+
+func (d Dialect) value(field todo.Field, value any) any {
+	if !d.wrapsTime(field) {
+		return value
+	}
+
+	return d.Time(value)
+}
+
+func (d Dialect) wrapsTime(field todo.Field) bool {
+	if d.Time == nil {
+		return false
+	}
+
+	switch field {
+	case todo.FieldDueDate, todo.FieldCreatedAt, todo.FieldUpdatedAt, todo.FieldCompletedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d Dialect) orderBy(order []todo.Order) (clause.OrderBy, error) {
+	if len(order) == 0 {
+		order = []todo.Order{{Field: todo.FieldId}}
+	}
+
+	res := clause.OrderBy{Columns: make([]clause.OrderByColumn, len(order))}
+	for i, o := range order {
+		if !o.Field.Valid() {
+			return clause.OrderBy{}, fmt.Errorf("unknown field %q", o.Field)
+		}
+		res.Columns[i] = clause.OrderByColumn{Column: clause.Column{Name: string(o.Field)}, Desc: o.Desc}
+	}
+
+	return res, nil
+}
@@ -3,23 +3,33 @@ package pg
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
 	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
 	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type todoItemConfig struct {
-	db db.DBWrapper
+	db      db.DBWrapper
+	dialect Dialect
 }
 
 func NewTodoItemRepository(db db.DBWrapper) todo.TodoItemRepository {
+	return NewTodoItemRepositoryWithDialect(db, Dialect{})
+}
+
+// NewTodoItemRepositoryWithDialect runs the queries of this adapter on another database, see SQLite
+func NewTodoItemRepositoryWithDialect(db db.DBWrapper, dialect Dialect) todo.TodoItemRepository {
 	return todoItemConfig{
-		db: db,
+		db:      db,
+		dialect: dialect,
 	}
 }
 
 func (u todoItemConfig) Create(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, err error) {
-	err = db.GormConnection(ctx, u.db.DB).Save(&in).Error
+	err = db.GormConnection(ctx, u.db.DB).Create(&in).Error
 	if err != nil {
 		return entity.TodoItem{}, err
 	}
@@ -36,6 +46,48 @@ func (u todoItemConfig) Update(ctx context.Context, in entity.TodoItem) (err err
 	return nil
 }
 
+func (u todoItemConfig) Upsert(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	conn := db.GormConnection(ctx, u.db.DB)
+
+	var existing entity.TodoItem
+	err = conn.Unscoped().Model(&existing).Limit(1).Find(&existing, "id = ?", in.Id).Error
+	if err != nil {
+		return entity.TodoItem{}, false, err
+	}
+
+	if existing.Id != uuid.Nil && !entity.SameOwner(existing.UserReferenceId, in.UserReferenceId) {
+		return entity.TodoItem{}, false, todo.ErrOwnedByAnother
+	}
+
+	// the conflict covers an item created since it was looked up, its other columns are kept and
+	// so is an item of another owner
+	in.CreatedAt = existing.CreatedAt
+	in.DeletedAt = gorm.DeletedAt{}
+	tx := conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "due_date", "updated_at", "deleted_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL: "todo_items.user_reference_id = excluded.user_reference_id OR (todo_items.user_reference_id IS NULL AND excluded.user_reference_id IS NULL)",
+		}}},
+	}).Create(&in)
+	if tx.Error != nil {
+		return entity.TodoItem{}, false, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return entity.TodoItem{}, false, todo.ErrOwnedByAnother
+	}
+
+	if existing.Id == uuid.Nil {
+		return in, true, nil
+	}
+
+	existing.Description = in.Description
+	existing.DueDate = in.DueDate
+	existing.UpdatedAt = in.UpdatedAt
+	existing.DeletedAt = gorm.DeletedAt{}
+	return existing, false, nil
+}
+
 func (u todoItemConfig) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error) {
 	err = db.GormConnection(ctx, u.db.DB).Model(&res).Order("created_at desc").Find(&res, "id = ?", id).Limit(1).Error
 	if err != nil {
@@ -54,29 +106,53 @@ func (u todoItemConfig) FindByIds(ctx context.Context, ids []string) (res []enti
 	return res, nil
 }
 
-func (u todoItemConfig) Purge(ctx context.Context, id string) (err error) {
-	err = db.GormConnection(ctx, u.db.DB).Exec("DELETE FROM todo_items WHERE id = ?", id).Error
+// Purge and Delete return the rows they changed, what was removed is known without another read
+func (u todoItemConfig) Purge(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	var removed []entity.TodoItem
+	err = db.GormConnection(ctx, u.db.DB).Unscoped().Clauses(clause.Returning{}).Delete(&removed, "id = ?", id).Error
 	if err != nil {
-		return err
+		return entity.TodoItem{}, err
 	}
 
-	return nil
+	if len(removed) == 0 {
+		return entity.TodoItem{}, nil
+	}
+	return removed[0], nil
 }
 
-func (u todoItemConfig) Delete(ctx context.Context, id string) (err error) {
-	err = db.GormConnection(ctx, u.db.DB).Delete(&entity.TodoItem{}, "id = ?", id).Error
+func (u todoItemConfig) Delete(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	var removed []entity.TodoItem
+	err = db.GormConnection(ctx, u.db.DB).Clauses(clause.Returning{}).Delete(&removed, "id = ?", id).Error
 	if err != nil {
-		return err
+		return entity.TodoItem{}, err
 	}
 
-	return nil
+	if len(removed) == 0 {
+		return entity.TodoItem{}, nil
+	}
+	return removed[0], nil
 }
 
-func (u todoItemConfig) FilterFind(ctx context.Context, query []any, order string, limit int, offset int) (res []entity.TodoItem, err error) {
-	err = db.GormConnection(ctx, u.db.DB).Model(&res).Order(order).
+func (u todoItemConfig) FilterFind(ctx context.Context, criteria todo.Criteria, order []todo.Order, limit int, offset int) (res []entity.TodoItem, err error) {
+	where, err := u.dialect.where(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := u.dialect.orderBy(order)
+	if err != nil {
+		return nil, err
+	}
+
+	findQuery := db.GormConnection(ctx, u.db.DB).Model(&res)
+	if where != nil {
+		findQuery = findQuery.Where(where)
+	}
+
+	err = findQuery.Order(orderBy).
 		Limit(limit).
 		Offset(offset).
-		Find(&res, query...).Error
+		Find(&res).Error
 	if err != nil {
 		return nil, err
 	}
@@ -84,12 +160,15 @@ func (u todoItemConfig) FilterFind(ctx context.Context, query []any, order strin
 	return res, nil
 }
 
-func (u todoItemConfig) FilterCount(ctx context.Context, query []any) (res int64, err error) {
+func (u todoItemConfig) FilterCount(ctx context.Context, criteria todo.Criteria) (res int64, err error) {
+	where, err := u.dialect.where(criteria)
+	if err != nil {
+		return 0, err
+	}
+
 	countQuery := db.GormConnection(ctx, u.db.DB).Model(&entity.TodoItem{})
-	if len(query) > 1 {
-		countQuery = countQuery.Where(query[0], query[1:]...)
-	} else if len(query) == 1 {
-		countQuery = countQuery.Where(query[0])
+	if where != nil {
+		countQuery = countQuery.Where(where)
 	}
 
 	err = countQuery.Count(&res).Error
@@ -99,3 +178,84 @@ func (u todoItemConfig) FilterCount(ctx context.Context, query []any) (res int64
 
 	return res, nil
 }
+
+func (u todoItemConfig) FilterSeek(ctx context.Context, criteria todo.Criteria, seek todo.Seek, limit int) (res []entity.TodoItem, err error) {
+	where, err := u.dialect.where(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := u.dialect.orderBy([]todo.Order{{Field: seek.Field, Desc: seek.Desc}, {Field: todo.FieldId, Desc: seek.Desc}})
+	if err != nil {
+		return nil, err
+	}
+
+	seekQuery := db.GormConnection(ctx, u.db.DB).Model(&res)
+	if where != nil {
+		seekQuery = seekQuery.Where(where)
+	}
+
+	if seek.Key != nil {
+		op := ">"
+		if seek.Desc {
+			op = "<"
+		}
+		// a row comparison walks the (field, id) index instead of skipping rows like OFFSET does. The
+		// dialect is left out so the comparison agrees with the order, the key comes from a row.
+		seekQuery = seekQuery.Where("(?, id) "+op+" (?, ?)", clause.Column{Name: string(seek.Field)}, seek.Key, seek.Id)
+	}
+
+	err = seekQuery.Order(orderBy).
+		Limit(limit).
+		Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// EstimateCount reads the row count of the planner statistics when nothing is filtered, it counts
+// soft deleted rows too and lags until the next (auto)analyze. Filtered queries are counted.
+func (u todoItemConfig) EstimateCount(ctx context.Context, criteria todo.Criteria) (res int64, err error) {
+	if criteria != nil {
+		return u.FilterCount(ctx, criteria)
+	}
+
+	err = db.GormConnection(ctx, u.db.DB).
+		Raw("SELECT reltuples::bigint FROM pg_class WHERE oid = 'todo_items'::regclass").
+		Scan(&res).Error
+	if err != nil {
+		return 0, err
+	}
+
+	// -1 until the table was analyzed once
+	if res < 0 {
+		return u.FilterCount(ctx, criteria)
+	}
+
+	return res, nil
+}
+
+func (u todoItemConfig) FindInBatches(ctx context.Context, criteria todo.Criteria, batchSize int, fc func(batch []entity.TodoItem) error) (err error) {
+	where, err := u.dialect.where(criteria)
+	if err != nil {
+		return err
+	}
+
+	findQuery := db.GormConnection(ctx, u.db.DB).Model(&entity.TodoItem{})
+	if where != nil {
+		findQuery = findQuery.Where(where)
+	}
+
+	var batch []entity.TodoItem
+	err = findQuery.
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fc(batch)
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+)
+
+type todoItemFeedConfig struct {
+	db db.DBWrapper
+}
+
+func NewTodoItemFeedRepository(db db.DBWrapper) todo.TodoItemFeedRepository {
+	return todoItemFeedConfig{
+		db: db,
+	}
+}
+
+func (u todoItemFeedConfig) Create(ctx context.Context, in entity.TodoItemFeed) (res entity.TodoItemFeed, err error) {
+	err = db.GormConnection(ctx, u.db.DB).Create(&in).Error
+	if err != nil {
+		return entity.TodoItemFeed{}, err
+	}
+
+	return in, nil
+}
+
+func (u todoItemFeedConfig) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItemFeed, err error) {
+	err = db.GormConnection(ctx, u.db.DB).Model(&res).Limit(1).Find(&res, "id = ?", id).Error
+	if err != nil {
+		return entity.TodoItemFeed{}, err
+	}
+
+	return res, nil
+}
+
+func (u todoItemFeedConfig) FindByTokenHashOrEmpty(ctx context.Context, tokenHash string) (res entity.TodoItemFeed, err error) {
+	err = db.GormConnection(ctx, u.db.DB).Model(&res).Limit(1).Find(&res, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		return entity.TodoItemFeed{}, err
+	}
+
+	return res, nil
+}
+
+func (u todoItemFeedConfig) Delete(ctx context.Context, id string) (err error) {
+	err = db.GormConnection(ctx, u.db.DB).Delete(&entity.TodoItemFeed{}, "id = ?", id).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
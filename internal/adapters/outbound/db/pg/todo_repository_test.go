@@ -7,13 +7,19 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo/todotest"
 	"github.com/thealiakbari/todoapp/pkg/common/config"
 	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	glog "gorm.io/gorm/logger"
 )
 
 func setupTestDB(t *testing.T) db.DBWrapper {
 	conf := config.LoadConfig("../../../../../config/todoapp.yml")
-	gormDB, err := db.NewPostgresConn(context.Background(), conf.DB.Postgres)
+	log, err := logger.NewInfra(conf.Mode, conf.ServiceName, "todoapp")
+	assert.NoError(t, err)
+	gormDB, err := db.NewPostgresConn(context.Background(), conf.DB.Postgres, glog.Discard, log)
 	assert.NoError(t, err)
 	dbw := db.NewDBWrapper(gormDB)
 
@@ -54,17 +60,17 @@ func TestTodoItemRepository_CRUD(t *testing.T) {
 	assert.Len(t, list, 1)
 
 	// FilterFind
-	results, err := repo.FilterFind(ctx, []any{"description LIKE ?", "%Task%"}, "created_at desc", 10, 0)
+	results, err := repo.FilterFind(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"}, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}}, 10, 0)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 
 	// FilterCount
-	count, err := repo.FilterCount(ctx, []any{"description LIKE ?", "%Task%"})
+	count, err := repo.FilterCount(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), count)
 
 	// Delete
-	err = repo.Delete(ctx, created.Id.String())
+	_, err = repo.Delete(ctx, created.Id.String())
 	assert.NoError(t, err)
 
 	_, err = repo.FindByIdOrEmpty(ctx, created.Id.String())
@@ -77,6 +83,12 @@ func TestTodoItemRepository_CRUD(t *testing.T) {
 		DueDate:     time.Now().Format(time.RFC3339),
 	}
 	created2, _ := repo.Create(ctx, item2)
-	err = repo.Purge(ctx, created2.Id.String())
+	_, err = repo.Purge(ctx, created2.Id.String())
 	assert.NoError(t, err)
 }
+
+func TestTodoItemRepository_Conformance(t *testing.T) {
+	todotest.TestTodoItemRepository(t, func(t *testing.T) todo.TodoItemRepository {
+		return NewTodoItemRepository(setupTestDB(t))
+	})
+}
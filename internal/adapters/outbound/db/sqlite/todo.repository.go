@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/adapters/outbound/db/pg"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"gorm.io/gorm/clause"
+)
+
+// todoItemRepository runs the gorm queries of the pg adapter, the SQL they build is valid on
+// SQLite as well. ids are stored as text there, so they are checked the way a Postgres uuid
+// column would check them instead of just matching nothing.
+type todoItemRepository struct {
+	todo.TodoItemRepository
+}
+
+// dialect compares times in one format, the driver writes time.Time values with a space and an
+// offset while due dates keep the RFC 3339 text they were given in
+var dialect = pg.Dialect{
+	Time: func(v any) clause.Expression {
+		return clause.Expr{SQL: "strftime('%Y-%m-%d %H:%M:%f', ?)", Vars: []any{v}}
+	},
+}
+
+func NewTodoItemRepository(db db.DBWrapper) todo.TodoItemRepository {
+	return todoItemRepository{
+		TodoItemRepository: pg.NewTodoItemRepositoryWithDialect(db, dialect),
+	}
+}
+
+func (u todoItemRepository) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if err = checkId(id); err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	return u.TodoItemRepository.FindByIdOrEmpty(ctx, id)
+}
+
+func (u todoItemRepository) Upsert(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	if err = checkId(in.Id.String()); err != nil {
+		return entity.TodoItem{}, false, err
+	}
+
+	return u.TodoItemRepository.Upsert(ctx, in)
+}
+
+func (u todoItemRepository) FindByIds(ctx context.Context, ids []string) (res []entity.TodoItem, err error) {
+	for _, id := range ids {
+		if err = checkId(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return u.TodoItemRepository.FindByIds(ctx, ids)
+}
+
+func (u todoItemRepository) Purge(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if err = checkId(id); err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	return u.TodoItemRepository.Purge(ctx, id)
+}
+
+func (u todoItemRepository) Delete(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if err = checkId(id); err != nil {
+		return entity.TodoItem{}, err
+	}
+
+	return u.TodoItemRepository.Delete(ctx, id)
+}
+
+// EstimateCount counts exactly, SQLite keeps no row estimates like pg_class
+func (u todoItemRepository) EstimateCount(ctx context.Context, criteria todo.Criteria) (res int64, err error) {
+	return u.FilterCount(ctx, criteria)
+}
+
+// checkId fails like Postgres does for ids that are not uuids
+func checkId(id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return fmt.Errorf("invalid input syntax for type uuid: %q", id)
+	}
+
+	return nil
+}
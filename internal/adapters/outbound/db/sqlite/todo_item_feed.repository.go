@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/adapters/outbound/db/pg"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+)
+
+type todoItemFeedRepository struct {
+	todo.TodoItemFeedRepository
+}
+
+func NewTodoItemFeedRepository(db db.DBWrapper) todo.TodoItemFeedRepository {
+	return todoItemFeedRepository{
+		TodoItemFeedRepository: pg.NewTodoItemFeedRepository(db),
+	}
+}
+
+func (u todoItemFeedRepository) FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItemFeed, err error) {
+	if err = checkId(id); err != nil {
+		return entity.TodoItemFeed{}, err
+	}
+
+	return u.TodoItemFeedRepository.FindByIdOrEmpty(ctx, id)
+}
+
+func (u todoItemFeedRepository) Delete(ctx context.Context, id string) (err error) {
+	if err = checkId(id); err != nil {
+		return err
+	}
+
+	return u.TodoItemFeedRepository.Delete(ctx, id)
+}
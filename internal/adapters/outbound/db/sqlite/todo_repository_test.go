@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/cmd/migration"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo/todotest"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	glog "gorm.io/gorm/logger"
+)
+
+func setupTestDB(t *testing.T) db.DBWrapper {
+	conf := config.Sqlite{
+		Path:               filepath.Join(t.TempDir(), "todoapp.db"),
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}
+
+	gormDB, err := db.NewSqliteConn(context.Background(), conf, glog.Discard)
+	assert.NoError(t, err)
+
+	migrator, err := db.NewSqliteMigrator(gormDB, conf, migration.Sqlite())
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Up())
+	// running it again finds nothing to do
+	assert.NoError(t, migrator.Up())
+	assert.NoError(t, migrator.Close())
+
+	drift, err := db.SchemaDrift(gormDB, &entity.TodoItem{}, &entity.TodoItemFeed{})
+	assert.NoError(t, err)
+	assert.Empty(t, drift)
+
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+
+	return db.NewDBWrapper(gormDB)
+}
+
+func TestTodoItemRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	testDB := setupTestDB(t)
+	repo := NewTodoItemRepository(testDB)
+
+	// Create, the id comes from the uuid_generate_v4() default
+	item := entity.TodoItem{
+		Description: "Test Task",
+		DueDate:     time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	}
+	created, err := repo.Create(ctx, item)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, created.Id)
+
+	// FindByIdOrEmpty
+	found, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, created.Id, found.Id)
+	assert.Equal(t, item.DueDate, found.DueDate)
+
+	// Update
+	found.Description = "Updated Task"
+	err = repo.Update(ctx, found)
+	assert.NoError(t, err)
+
+	updated, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated Task", updated.Description)
+
+	// FindByIds
+	list, err := repo.FindByIds(ctx, []string{created.Id.String()})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// FilterFind
+	results, err := repo.FilterFind(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"}, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}}, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// FilterCount
+	count, err := repo.FilterCount(ctx, todo.Contains{Field: todo.FieldDescription, Text: "Task"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Delete
+	_, err = repo.Delete(ctx, created.Id.String())
+	assert.NoError(t, err)
+
+	deleted, err := repo.FindByIdOrEmpty(ctx, created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, deleted.Id)
+
+	// Purge
+	_, err = repo.Purge(ctx, created.Id.String())
+	assert.NoError(t, err)
+
+	_, err = repo.FindByIdOrEmpty(ctx, "not-a-uuid")
+	assert.Error(t, err)
+}
+
+func TestTodoItemRepository_FilterSeek(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemRepository(setupTestDB(t))
+
+	// two items share a created_at, the id breaks the tie
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []int{0, 1, 1, 2, 3} {
+		item := entity.TodoItem{Description: "Task", DueDate: "2025-01-01"}
+		item.CreatedAt = base.Add(time.Duration(offset) * time.Hour)
+		_, err := repo.Create(ctx, item)
+		assert.NoError(t, err)
+	}
+
+	all, err := repo.FilterFind(ctx, nil, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}, {Field: todo.FieldId, Desc: true}}, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, all, 5)
+
+	var walked []entity.TodoItem
+	seek := todo.Seek{Field: todo.FieldCreatedAt, Desc: true}
+	for {
+		res, err := repo.FilterSeek(ctx, todo.Eq{Field: todo.FieldDescription, Value: "Task"}, seek, 2)
+		assert.NoError(t, err)
+		if len(res) == 0 {
+			break
+		}
+		walked = append(walked, res...)
+		last := res[len(res)-1]
+		seek.Key, seek.Id = last.CreatedAt, last.Id.String()
+	}
+	assert.Equal(t, all, walked)
+
+	count, err := repo.EstimateCount(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+}
+
+func TestTodoItemFeedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTodoItemFeedRepository(setupTestDB(t))
+
+	feed, err := repo.Create(ctx, entity.TodoItemFeed{TokenHash: "hash"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, feed.Id)
+
+	_, err = repo.Create(ctx, entity.TodoItemFeed{TokenHash: "hash"})
+	assert.Error(t, err, "token_hash is unique")
+
+	found, err := repo.FindByTokenHashOrEmpty(ctx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, feed.Id, found.Id)
+
+	err = repo.Delete(ctx, feed.Id.String())
+	assert.NoError(t, err)
+
+	found, err = repo.FindByIdOrEmpty(ctx, feed.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, found.Id)
+}
+
+func TestTodoItemRepository_Conformance(t *testing.T) {
+	todotest.TestTodoItemRepository(t, func(t *testing.T) todo.TodoItemRepository {
+		return NewTodoItemRepository(setupTestDB(t))
+	})
+}
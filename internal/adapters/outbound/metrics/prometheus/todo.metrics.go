@@ -0,0 +1,63 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+)
+
+// overdueTimeout bounds the count behind a scrape, a slow database must not hang the scraper
+const overdueTimeout = 5 * time.Second
+
+var overdueDesc = prometheus.NewDesc("todo_items_overdue", "Todo items whose due date has passed.", nil, nil)
+
+type todoItemMetrics struct {
+	reg     prometheus.Registerer
+	changes *prometheus.CounterVec
+}
+
+func NewTodoItemMetrics(reg prometheus.Registerer) todo.TodoItemMetrics {
+	changes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "todo_item_changes_total",
+		Help: "Committed todo item changes by type.",
+	}, []string{"type"})
+	reg.MustRegister(changes)
+
+	// the series exist before the first change so rates start from zero
+	for _, eventType := range []entity.TodoItemEventType{entity.TodoItemCreated, entity.TodoItemUpdated, entity.TodoItemCompleted, entity.TodoItemDeleted, entity.TodoItemPurged} {
+		changes.WithLabelValues(eventType)
+	}
+
+	return &todoItemMetrics{reg: reg, changes: changes}
+}
+
+func (m *todoItemMetrics) Changed(eventType entity.TodoItemEventType) {
+	m.changes.WithLabelValues(eventType).Inc()
+}
+
+func (m *todoItemMetrics) WatchOverdue(count func(ctx context.Context) (int64, error)) {
+	m.reg.MustRegister(overdueCollector(count))
+}
+
+// overdueCollector counts on every scrape, a failed count is reported as an invalid metric
+type overdueCollector func(ctx context.Context) (int64, error)
+
+func (c overdueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- overdueDesc
+}
+
+func (c overdueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), overdueTimeout)
+	defer cancel()
+
+	count, err := c(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(overdueDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(overdueDesc, prometheus.GaugeValue, float64(count))
+}
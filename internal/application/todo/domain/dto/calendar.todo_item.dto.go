@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TodoItemFeed struct {
+	Id uuid.UUID `json:"id"`
+	// Token is only returned once, keep the url secret like a password
+	Token     string    `json:"token"`
+	Url       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 
 	"github.com/thealiakbari/todoapp/pkg/common/request"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
 )
 
 type GetTodoItemRequest struct {
-	Ids         []string `form:"ids"`
+	Ids         []string `form:"ids" validate:"dive,uuid"`
 	Description []string `json:"description"`
 	DueDate     []string `json:"dueDate"`
 
+	// Paging `cursor` pages through the list with cursors instead of page, a Cursor implies it
+	Paging request.PagingMode `form:"paging" validate:"omitempty,oneof=page cursor"`
+	// Cursor comes from the previous response of a cursor list
+	Cursor string `form:"cursor"`
+	// Count asks cursor lists for the total, counting every page is what cursors avoid
+	Count request.CountMode `form:"count" validate:"omitempty,oneof=exact estimated"`
+
 	request.Pagination `json:"-"`
 }
 
 func (g GetTodoItemRequest) Validate(ctx context.Context) error {
-	return nil
+	return validation.Validate(ctx, g)
 }
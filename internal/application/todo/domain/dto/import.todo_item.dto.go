@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+	ImportActionFailed  = "failed"
+)
+
+type ImportTodoItemResult struct {
+	// Row is the 1-based position of the record in the uploaded file
+	Row     int        `json:"row"`
+	Uid     string     `json:"uid,omitempty"`
+	Id      *uuid.UUID `json:"id,omitempty"`
+	Action  string     `json:"action"`
+	Message string     `json:"message,omitempty"`
+	Causes  any        `json:"causes,omitempty"`
+}
+
+type ImportTodoItemsResponse struct {
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Failed  int                    `json:"failed"`
+	Items   []ImportTodoItemResult `json:"items"`
+}
+
+func (r *ImportTodoItemsResponse) Add(result ImportTodoItemResult) {
+	switch result.Action {
+	case ImportActionCreated:
+		r.Created++
+	case ImportActionUpdated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+
+	r.Items = append(r.Items, result)
+}
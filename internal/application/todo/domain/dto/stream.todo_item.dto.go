@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"context"
+	"time"
+
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+type StreamTodoItemRequest struct {
+	Types []string `form:"types" validate:"dive,oneof=created updated completed deleted purged"`
+	Ids   []string `form:"ids" validate:"dive,uuid"`
+	// LastEventId is for clients that cannot set the `Last-Event-ID` header, e.g. browser WebSockets
+	LastEventId uint64 `form:"lastEventId"`
+}
+
+func (s StreamTodoItemRequest) Validate(ctx context.Context) error {
+	return validation.Validate(ctx, s)
+}
+
+type TodoItemEvent struct {
+	Id         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	Item       *TodoItem `json:"item,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type TodoItem struct {
-	Id          uuid.UUID `json:"id"`
-	Description string    `json:"description"`
-	DueDate     string    `json:"dueDate"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	Id          uuid.UUID  `json:"id"`
+	Description string     `json:"description"`
+	DueDate     string     `json:"dueDate"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
 }
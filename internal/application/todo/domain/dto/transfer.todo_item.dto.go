@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+const (
+	TransferFormatCSV    = "csv"
+	TransferFormatNDJSON = "ndjson"
+)
+
+// TodoItemCSVHeader is the column order of exports, imports match columns by name
+var TodoItemCSVHeader = []string{"id", "description", "dueDate", "createdAt", "updatedAt", "completedAt"}
+
+type TransferTodoItemRequest struct {
+	Format string `form:"format" validate:"omitempty,oneof=csv ndjson"`
+}
+
+func (t TransferTodoItemRequest) Validate(ctx context.Context) error {
+	return validation.Validate(ctx, t)
+}
+
+// ImportTodoItemRow is one record of an import, Id is optional and updates the item when it exists
+type ImportTodoItemRow struct {
+	Id string `json:"id"`
+	CreateTodoItemRequest
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+const (
+	TodoItemStatusOpen    = "open"
+	TodoItemStatusOverdue = "overdue"
+	TodoItemStatusDone    = "done"
+)
+
+// TodoItemV2 is the item of the v2 API, its due date is typed and its status follows from it and
+// the completion
+type TodoItemV2 struct {
+	Id          uuid.UUID  `json:"id"`
+	Description string     `json:"description"`
+	DueDate     time.Time  `json:"dueDate"`
+	Status      string     `json:"status" enums:"open,overdue,done"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type CreateTodoItemRequestV2 struct {
+	Description string    `json:"description" validate:"required"`
+	DueDate     time.Time `json:"dueDate" validate:"required" example:"2026-01-02T15:04:05Z"`
+}
+
+func (c CreateTodoItemRequestV2) Validate(ctx context.Context) error {
+	return validation.Validate(ctx, c)
+}
+
+type UpdateTodoItemRequestV2 struct {
+	Description string    `json:"description" validate:"required"`
+	DueDate     time.Time `json:"dueDate" validate:"required" example:"2026-01-02T15:04:05Z"`
+}
+
+func (u UpdateTodoItemRequestV2) Validate(ctx context.Context) error {
+	return validation.Validate(ctx, u)
+}
+
+type TodoItemEventV2 struct {
+	Id         uint64      `json:"id"`
+	Type       string      `json:"type"`
+	Item       *TodoItemV2 `json:"item,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+}
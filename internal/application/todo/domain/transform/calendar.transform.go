@@ -0,0 +1,96 @@
+package transform
+
+import (
+	"time"
+
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/pkg/common/ical"
+)
+
+const calendarProductId = "-//TodoApp//Todo Items//EN"
+
+// dueDateLayouts are the shapes a due date comes back in, postgres returns RFC 3339
+// while items that were never stored keep whatever the client sent
+var dueDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+const dueDateOnlyLayout = "2006-01-02"
+
+func TodoItemFeedEntityToTodoItemFeedDto(in entity.TodoItemFeed, token string, url string) dto.TodoItemFeed {
+	return dto.TodoItemFeed{
+		Id:        in.Id,
+		Token:     token,
+		Url:       url,
+		CreatedAt: in.CreatedAt,
+	}
+}
+
+func TodoItemsEntityToCalendar(in []entity.TodoItem, now time.Time) ical.Component {
+	cal := ical.NewComponent("VCALENDAR")
+	cal.Add("VERSION", "2.0")
+	cal.Add("PRODID", calendarProductId)
+	cal.Add("CALSCALE", "GREGORIAN")
+	cal.AddText("X-WR-CALNAME", "Todo items")
+
+	for _, item := range in {
+		cal.Components = append(cal.Components, TodoItemEntityToVTodo(item, now))
+	}
+
+	return cal
+}
+
+// TodoItemEntityToVTodo renders an item as an RFC 5545 VTODO, items have no priority, category
+// or recurrence so PRIORITY, CATEGORIES and RRULE are left out
+func TodoItemEntityToVTodo(in entity.TodoItem, now time.Time) ical.Component {
+	todo := ical.NewComponent("VTODO")
+	todo.Add("UID", in.Id.String())
+	todo.AddTime("DTSTAMP", now)
+	if !in.CreatedAt.IsZero() {
+		todo.AddTime("CREATED", in.CreatedAt)
+	}
+	if !in.UpdatedAt.IsZero() {
+		todo.AddTime("LAST-MODIFIED", in.UpdatedAt)
+	}
+	todo.AddText("SUMMARY", in.Description)
+
+	if due, err := time.Parse(dueDateOnlyLayout, in.DueDate); err == nil {
+		todo.Add("DUE", due.Format(ical.DateLayout), "VALUE", "DATE")
+	} else {
+		for _, layout := range dueDateLayouts {
+			if due, err := time.Parse(layout, in.DueDate); err == nil {
+				todo.AddTime("DUE", due)
+				break
+			}
+		}
+	}
+
+	if in.CompletedAt != nil {
+		todo.Add("STATUS", "COMPLETED")
+		todo.AddTime("COMPLETED", *in.CompletedAt)
+	} else {
+		todo.Add("STATUS", "NEEDS-ACTION")
+	}
+	return todo
+}
+
+func VTodoToCreateTodoItemRequest(in ical.Component) (uid string, out dto.CreateTodoItemRequest, err error) {
+	uid = in.GetText("UID")
+	out.Description = in.GetText("SUMMARY")
+	if out.Description == "" {
+		out.Description = in.GetText("DESCRIPTION")
+	}
+
+	due, ok, err := in.GetTime("DUE")
+	if err != nil {
+		return uid, out, err
+	}
+	if ok {
+		out.DueDate = due.UTC().Format(time.RFC3339)
+	}
+
+	return uid, out, nil
+}
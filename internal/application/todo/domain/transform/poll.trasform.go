@@ -1,9 +1,12 @@
 package transform
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/pkg/common/utiles"
 )
 
 func CreateTodoItemRequestToEntity(in dto.CreateTodoItemRequest) entity.TodoItem {
@@ -35,6 +38,7 @@ func TodoItemEntityToTodoItemDto(in entity.TodoItem) dto.TodoItem {
 		Id:          in.Id,
 		Description: in.Description,
 		DueDate:     in.DueDate,
+		CompletedAt: in.CompletedAt,
 		CreatedAt:   in.CreatedAt,
 		UpdatedAt:   in.UpdatedAt,
 	}
@@ -48,3 +52,58 @@ func TodoItemsEntityToTodoItemsDto(in []entity.TodoItem) []dto.TodoItem {
 
 	return items
 }
+
+func StreamTodoItemRequestToFilter(in dto.StreamTodoItemRequest) (out entity.TodoItemEventFilter, err error) {
+	ids, err := utiles.ConvertToUUID(in.Ids)
+	if err != nil {
+		return out, err
+	}
+
+	out = entity.TodoItemEventFilter{
+		Types: in.Types,
+		Ids:   ids,
+	}
+
+	return out, nil
+}
+
+func TodoItemEventEntityToTodoItemEventDto(in entity.TodoItemEvent) dto.TodoItemEvent {
+	out := dto.TodoItemEvent{
+		Id:         in.Id,
+		Type:       in.Type,
+		OccurredAt: in.OccurredAt,
+	}
+
+	if in.Type != entity.TodoItemReset {
+		item := TodoItemEntityToTodoItemDto(in.Item)
+		out.Item = &item
+	}
+
+	return out
+}
+
+func ImportTodoItemRowToEntity(in dto.ImportTodoItemRow) (out entity.TodoItem, err error) {
+	out = CreateTodoItemRequestToEntity(in.CreateTodoItemRequest)
+	if in.Id == "" {
+		return out, nil
+	}
+
+	out.Id, err = uuid.Parse(in.Id)
+	return out, err
+}
+
+func TodoItemEntityToCSVRecord(in entity.TodoItem) []string {
+	var completedAt string
+	if in.CompletedAt != nil {
+		completedAt = in.CompletedAt.Format(time.RFC3339Nano)
+	}
+
+	return []string{
+		in.Id.String(),
+		in.Description,
+		in.DueDate,
+		in.CreatedAt.Format(time.RFC3339Nano),
+		in.UpdatedAt.Format(time.RFC3339Nano),
+		completedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package transform
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+func CreateTodoItemRequestV2ToEntity(in dto.CreateTodoItemRequestV2) entity.TodoItem {
+	return entity.TodoItem{
+		Description: in.Description,
+		DueDate:     in.DueDate.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func UpdateTodoItemRequestV2ToEntity(in dto.UpdateTodoItemRequestV2, id string) (out entity.TodoItem, err error) {
+	out = entity.TodoItem{
+		Description: in.Description,
+		DueDate:     in.DueDate.UTC().Format(time.RFC3339Nano),
+	}
+
+	out.Id, err = uuid.Parse(id)
+	return out, err
+}
+
+// TodoItemEntityToTodoItemV2Dto is overdue once the due date is before now unless it is done, a
+// due date that does not parse is left zero
+func TodoItemEntityToTodoItemV2Dto(in entity.TodoItem, now time.Time) dto.TodoItemV2 {
+	out := dto.TodoItemV2{
+		Id:          in.Id,
+		Description: in.Description,
+		Status:      dto.TodoItemStatusOpen,
+		CompletedAt: in.CompletedAt,
+		CreatedAt:   in.CreatedAt,
+		UpdatedAt:   in.UpdatedAt,
+	}
+
+	if due, ok := parseDueDate(in.DueDate); ok {
+		out.DueDate = due
+		if due.Before(now) {
+			out.Status = dto.TodoItemStatusOverdue
+		}
+	}
+	if in.Done() {
+		out.Status = dto.TodoItemStatusDone
+	}
+
+	return out
+}
+
+func TodoItemsEntityToTodoItemsV2Dto(in []entity.TodoItem, now time.Time) []dto.TodoItemV2 {
+	items := make([]dto.TodoItemV2, 0, len(in))
+	for _, v := range in {
+		items = append(items, TodoItemEntityToTodoItemV2Dto(v, now))
+	}
+
+	return items
+}
+
+func TodoItemEventEntityToTodoItemEventV2Dto(in entity.TodoItemEvent, now time.Time) dto.TodoItemEventV2 {
+	out := dto.TodoItemEventV2{
+		Id:         in.Id,
+		Type:       in.Type,
+		OccurredAt: in.OccurredAt,
+	}
+
+	if in.Type != entity.TodoItemReset {
+		item := TodoItemEntityToTodoItemV2Dto(in.Item, now)
+		out.Item = &item
+	}
+
+	return out
+}
+
+func parseDueDate(s string) (time.Time, bool) {
+	if due, err := time.Parse(dueDateOnlyLayout, s); err == nil {
+		return due, true
+	}
+	for _, layout := range dueDateLayouts {
+		if due, err := time.Parse(layout, s); err == nil {
+			return due, true
+		}
+	}
+
+	return time.Time{}, false
+}
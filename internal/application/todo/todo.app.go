@@ -1,188 +1,38 @@
 package service
 
 import (
+	"context"
+	"errors"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
-	"github.com/thealiakbari/todoapp/internal/application/todo/domain/transform"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
 	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
-	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/request"
 	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+	"github.com/thealiakbari/todoapp/pkg/common/utiles"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
 )
 
 type TodoItemHttpApp struct {
-	todoItemSvc todoInterface.TodoItemService
-	db          db.DBWrapper
+	todoItemSvc       todoInterface.TodoItemService
+	todoItemStreamSvc todoInterface.TodoItemStreamService
+	streamConf        config.Stream
+	upgrader          *websocket.Upgrader
 }
 
-func NewTodoItemHttpApp(todoItemSvc todoInterface.TodoItemService, db db.DBWrapper) TodoItemHttpApp {
+func NewTodoItemHttpApp(
+	todoItemSvc todoInterface.TodoItemService,
+	todoItemStreamSvc todoInterface.TodoItemStreamService,
+	streamConf config.Stream,
+) TodoItemHttpApp {
 	return TodoItemHttpApp{
-		db:          db,
-		todoItemSvc: todoItemSvc,
-	}
-}
-
-// MakeCreate
-// @Schemes
-// @Summary Create TodoItem
-// @Description This api for create poll
-// @Tags todo-items
-// @Accept json
-// @Produce json
-// @Content-Type application/json
-// @Security Bearer
-// @Param  body body dto.CreateTodoItemRequest true "Contains information to set data"
-// @Success 201  {object}  dto.TodoItem
-// @Failure 400  {object}  appErr.ErrSwaggerResponse
-// @Failure 401  {object}  appErr.ErrSwaggerResponse
-// @Failure 403  {object}  appErr.ErrSwaggerResponse
-// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
-// @Failure 500  {object}  appErr.ErrSwaggerResponse
-// @Router /todo-items [post]
-func (t TodoItemHttpApp) MakeCreate() gin.HandlerFunc {
-	return func(ginCtx *gin.Context) {
-		var req dto.CreateTodoItemRequest
-		if err := ginCtx.ShouldBindJSON(&req); err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EBadArg,
-			})
-			return
-		}
-
-		if err := req.Validate(ginCtx.Request.Context()); err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EValidation,
-			})
-			return
-		}
-
-		tx, ctx, err := db.BeginTx(ginCtx.Request.Context(), t.db.DB)
-		if err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
-		defer func() {
-			if err != nil {
-				if err := tx.Rollback().Error; err != nil {
-					appErr.HandelError(ginCtx, &appErr.Error{
-						Cause:   err,
-						Message: err.Error(),
-						Class:   appErr.EConflict,
-					})
-					return
-				}
-				appErr.HandelError(ginCtx, err)
-				return
-			}
-		}()
-
-		pollEntityResp, err := t.todoItemSvc.Create(ctx, transform.CreateTodoItemRequestToEntity(req))
-		if err != nil {
-			appErr.HandelError(ginCtx, err)
-			return
-		}
-
-		if err = tx.Commit().Error; err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
-		appErr.CreatedResponse(ginCtx, transform.TodoItemEntityToTodoItemDto(pollEntityResp))
-	}
-}
-
-// MakeUpdate
-// @Schemes
-// @Summary Update TodoItem
-// @Description This api for update poll
-// @Tags todo-items
-// @Accept json
-// @Produce json
-// @Content-Type application/json
-// @Security Bearer
-// @Param id path string true "TodoItem Id"
-// @Param  body body dto.UpdateTodoItemRequest true "Contains information to set data"
-// @Success 200  {object}  dto.TodoItem
-// @Failure 400  {object}  appErr.ErrSwaggerResponse
-// @Failure 401  {object}  appErr.ErrSwaggerResponse
-// @Failure 403  {object}  appErr.ErrSwaggerResponse
-// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
-// @Failure 500  {object}  appErr.ErrSwaggerResponse
-// @Router /todo-items/{id} [put]
-func (t TodoItemHttpApp) MakeUpdate() gin.HandlerFunc {
-	return func(ginCtx *gin.Context) {
-		var req dto.UpdateTodoItemRequest
-		if err := ginCtx.ShouldBindJSON(&req); err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EBadArg,
-			})
-			return
-		}
-
-		tx, ctx, err := db.BeginTx(ginCtx.Request.Context(), t.db.DB)
-		if err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
-		defer func() {
-			if err != nil {
-				if err := tx.Rollback().Error; err != nil {
-					appErr.HandelError(ginCtx, &appErr.Error{
-						Cause:   err,
-						Message: err.Error(),
-						Class:   appErr.EConflict,
-					})
-					return
-				}
-				appErr.HandelError(ginCtx, err)
-				return
-			}
-		}()
-
-		updateReq, err := transform.UpdateTodoItemRequestToEntity(req, ginCtx.Param("id"))
-		if err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EBadArg,
-			})
-			return
-		}
-		pollEntityResp, err := t.todoItemSvc.Update(ctx, updateReq)
-		if err != nil {
-			appErr.HandelError(ginCtx, err)
-			return
-		}
-
-		if err = tx.Commit().Error; err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
-		appErr.OKResponse(ginCtx, transform.TodoItemEntityToTodoItemDto(pollEntityResp))
+		todoItemSvc:       todoItemSvc,
+		todoItemStreamSvc: todoItemStreamSvc,
+		streamConf:        streamConf,
+		upgrader:          &websocket.Upgrader{CheckOrigin: originChecker(streamConf.AllowedOrigins)},
 	}
 }
 
@@ -200,51 +50,22 @@ func (t TodoItemHttpApp) MakeUpdate() gin.HandlerFunc {
 // @Failure 400  {object}  appErr.ErrSwaggerResponse
 // @Failure 401  {object}  appErr.ErrSwaggerResponse
 // @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
 // @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
 // @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
 // @Router /todo-items/{id} [delete]
 func (t TodoItemHttpApp) MakeDelete() gin.HandlerFunc {
 	return func(ginCtx *gin.Context) {
-		tx, ctx, err := db.BeginTx(ginCtx.Request.Context(), t.db.DB)
-		if err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
-		defer func() {
-			if err != nil {
-				if err := tx.Rollback().Error; err != nil {
-					appErr.HandelError(ginCtx, &appErr.Error{
-						Cause:   err,
-						Message: err.Error(),
-						Class:   appErr.EConflict,
-					})
-					return
-				}
-				appErr.HandelError(ginCtx, err)
-				return
-			}
-		}()
-
-		err = t.todoItemSvc.Delete(ctx, ginCtx.Param("id"))
+		ctx := ginCtx.Request.Context()
+		item, err := t.todoItemSvc.Delete(ctx, ginCtx.Param("id"))
 		if err != nil {
 			appErr.HandelError(ginCtx, err)
 			return
 		}
 
-		if err = tx.Commit().Error; err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
-
+		t.todoItemStreamSvc.Notify(ctx, entity.TodoItemDeleted, item)
 		appErr.NoContentResponse(ginCtx)
 	}
 }
@@ -263,78 +84,166 @@ func (t TodoItemHttpApp) MakeDelete() gin.HandlerFunc {
 // @Failure 400  {object}  appErr.ErrSwaggerResponse
 // @Failure 401  {object}  appErr.ErrSwaggerResponse
 // @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
 // @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
 // @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
 // @Router /todo-items/purge/{id} [delete]
 func (t TodoItemHttpApp) MakePurge() gin.HandlerFunc {
 	return func(ginCtx *gin.Context) {
-		tx, ctx, err := db.BeginTx(ginCtx.Request.Context(), t.db.DB)
+		ctx := ginCtx.Request.Context()
+		item, err := t.todoItemSvc.Purge(ctx, ginCtx.Param("id"))
 		if err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
+			appErr.HandelError(ginCtx, err)
 			return
 		}
 
-		defer func() {
-			if err != nil {
-				if err := tx.Rollback().Error; err != nil {
-					appErr.HandelError(ginCtx, &appErr.Error{
-						Cause:   err,
-						Message: err.Error(),
-						Class:   appErr.EConflict,
-					})
-					return
-				}
-			}
-		}()
+		t.todoItemStreamSvc.Notify(ctx, entity.TodoItemPurged, item)
+		appErr.NoContentResponse(ginCtx)
+	}
+}
 
-		err = t.todoItemSvc.Purge(ctx, ginCtx.Param("id"))
-		if err != nil {
-			appErr.HandelError(ginCtx, err)
-			return
-		}
+// createRequest is the body of a create request of an API version
+type createRequest interface {
+	Validate(ctx context.Context) error
+}
 
-		if err = tx.Commit().Error; err != nil {
-			appErr.HandelError(ginCtx, &appErr.Error{
-				Cause:   err,
-				Message: err.Error(),
-				Class:   appErr.EConflict,
-			})
-			return
-		}
+// create stores the item of the request body of the API version, toEntity maps the body and
+// present renders the created item in the DTO of the version
+func create[Req createRequest](t TodoItemHttpApp, ginCtx *gin.Context, toEntity func(req Req) entity.TodoItem, present func(item entity.TodoItem) any) {
+	var req Req
+	if err := ginCtx.ShouldBindJSON(&req); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return
+	}
 
-		appErr.NoContentResponse(ginCtx)
+	ctx := ginCtx.Request.Context()
+	if err := req.Validate(ctx); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeValidation.New(err))
+		return
 	}
+
+	todoItemEntityResp, err := t.todoItemSvc.Create(ctx, toEntity(req))
+	if err != nil {
+		appErr.HandelError(ginCtx, err)
+		return
+	}
+
+	t.todoItemStreamSvc.Notify(ctx, entity.TodoItemCreated, todoItemEntityResp)
+	appErr.CreatedResponse(ginCtx, present(todoItemEntityResp))
 }
 
-// MakeGetById
-// @Schemes
-// @Summary Get TodoItem By Id
-// @Description This api for poll by id
-// @Tags todo-items
-// @Accept json
-// @Produce json
-// @Content-Type application/json
-// @Security Bearer
-// @Param id path string true "TodoItem Id"
-// @Success 200  {object} dto.TodoItem
-// @Failure 400  {object}  appErr.ErrSwaggerResponse
-// @Failure 401  {object}  appErr.ErrSwaggerResponse
-// @Failure 403  {object}  appErr.ErrSwaggerResponse
-// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
-// @Failure 500  {object}  appErr.ErrSwaggerResponse
-// @Router /todo-items/{id} [get]
-func (t TodoItemHttpApp) MakeGetById() gin.HandlerFunc {
-	return func(ginCtx *gin.Context) {
-		pollEntityResp, err := t.todoItemSvc.GetByIdOrEmpty(ginCtx.Request.Context(), ginCtx.Param("id"))
-		if err != nil {
-			appErr.HandelError(ginCtx, err)
+// update changes the item of the `id` param to the request body of the API version, toEntity maps
+// the body and present renders the updated item in the DTO of the version
+func update[Req any](t TodoItemHttpApp, ginCtx *gin.Context, toEntity func(req Req, id string) (entity.TodoItem, error), present func(item entity.TodoItem) any) {
+	var req Req
+	if err := ginCtx.ShouldBindJSON(&req); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return
+	}
+
+	updateReq, err := toEntity(req, ginCtx.Param("id"))
+	if err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return
+	}
+
+	ctx := ginCtx.Request.Context()
+	todoItemEntityResp, err := t.todoItemSvc.Update(ctx, updateReq)
+	if err != nil {
+		appErr.HandelError(ginCtx, err)
+		return
+	}
+
+	t.todoItemStreamSvc.Notify(ctx, entity.TodoItemUpdated, todoItemEntityResp)
+	appErr.OKResponse(ginCtx, present(todoItemEntityResp))
+}
+
+// complete marks the item of the `id` param done or open again, present renders it in the DTO of
+// the API version
+func (t TodoItemHttpApp) complete(ginCtx *gin.Context, done bool, present func(item entity.TodoItem) any) {
+	ctx := ginCtx.Request.Context()
+	todoItemEntityResp, err := t.todoItemSvc.Complete(ctx, ginCtx.Param("id"), done)
+	if err != nil {
+		appErr.HandelError(ginCtx, err)
+		return
+	}
+
+	eventType := entity.TodoItemUpdated
+	if done {
+		eventType = entity.TodoItemCompleted
+	}
+	t.todoItemStreamSvc.Notify(ctx, eventType, todoItemEntityResp)
+	appErr.OKResponse(ginCtx, present(todoItemEntityResp))
+}
+
+// list reads a page of the items of the query, present renders them in the DTO of the API version
+func (t TodoItemHttpApp) list(ginCtx *gin.Context, present func(items []entity.TodoItem) any) {
+	var req dto.GetTodoItemRequest
+	if err := ginCtx.ShouldBindQuery(&req); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return
+	}
+
+	if err := validation.BindStringSlices(&req); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return
+	}
+
+	if err := req.Validate(ginCtx.Request.Context()); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeValidation.New(err))
+		return
+	}
+
+	pagination, err := utiles.PaginationNormalizer(req.Pagination, ginCtx.Request.Context())
+	if err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeValidation.New(err))
+		return
+	}
+
+	if req.Paging == request.PagingCursor || req.Cursor != "" {
+		if pagination.Page != 0 || req.Paging == request.PagingPage {
+			err := errors.New("cursor cannot be used with page")
+			appErr.HandelError(ginCtx, appErr.CodeValidation.NewWithDetail(err, err.Error()))
 			return
 		}
 
-		appErr.OKResponse(ginCtx, transform.TodoItemEntityToTodoItemDto(pollEntityResp))
+		t.listByCursor(ginCtx, req, pagination.PageSize, present)
+		return
+	}
+
+	items, count, err := t.todoItemSvc.List(ginCtx.Request.Context(), req.Ids, utiles.PaginationToPortion(pagination))
+	if err != nil {
+		appErr.HandelError(ginCtx, err)
+		return
+	}
+
+	appErr.OKResponse(ginCtx, appErr.PaginationListResponse(
+		present(items),
+		count,
+		int64(pagination.PageSize),
+		int64(pagination.Page),
+	))
+}
+
+func (t TodoItemHttpApp) listByCursor(ginCtx *gin.Context, req dto.GetTodoItemRequest, pageSize int, present func(items []entity.TodoItem) any) {
+	items, page, err := t.todoItemSvc.ListByCursor(ginCtx.Request.Context(), req.Ids, request.Keyset{
+		Cursor: req.Cursor,
+		Limit:  pageSize,
+		Count:  req.Count,
+	})
+	if err != nil {
+		appErr.HandelError(ginCtx, err)
+		return
 	}
+
+	appErr.OKResponse(ginCtx, appErr.CursorListResponse(
+		present(items),
+		int64(pageSize),
+		page.NextCursor,
+		page.PrevCursor,
+		page.Count,
+		page.Estimated,
+	))
 }
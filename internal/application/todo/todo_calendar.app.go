@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/transform"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/ical"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+const (
+	importFileField   = "file"
+	maxImportBodySize = 5 << 20
+)
+
+type TodoItemCalendarHttpApp struct {
+	todoItemCalendarSvc todoInterface.TodoItemCalendarService
+	todoItemStreamSvc   todoInterface.TodoItemStreamService
+}
+
+func NewTodoItemCalendarHttpApp(
+	todoItemCalendarSvc todoInterface.TodoItemCalendarService,
+	todoItemStreamSvc todoInterface.TodoItemStreamService,
+) TodoItemCalendarHttpApp {
+	return TodoItemCalendarHttpApp{
+		todoItemCalendarSvc: todoItemCalendarSvc,
+		todoItemStreamSvc:   todoItemStreamSvc,
+	}
+}
+
+// MakeCreateFeed
+// @Schemes
+// @Summary Create TodoItem calendar feed
+// @Description This api creates an iCalendar subscription url, the token in it is only shown once
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Success 201  {object}  dto.TodoItemFeed
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
+// @Router /todo-items/ics/feeds [post]
+func (t TodoItemCalendarHttpApp) MakeCreateFeed() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		feed, token, err := t.todoItemCalendarSvc.CreateFeed(ginCtx.Request.Context())
+		if err != nil {
+			appErr.HandelError(ginCtx, err)
+			return
+		}
+
+		url := strings.TrimSuffix(ginCtx.FullPath(), "/") + "/" + token
+		appErr.CreatedResponse(ginCtx, transform.TodoItemFeedEntityToTodoItemFeedDto(feed, token, url))
+	}
+}
+
+// MakeRevokeFeed
+// @Schemes
+// @Summary Revoke TodoItem calendar feed
+// @Description This api revokes a calendar subscription
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param id path string true "Feed Id"
+// @Success 204
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
+// @Router /todo-items/ics/feeds/{id} [delete]
+func (t TodoItemCalendarHttpApp) MakeRevokeFeed() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		err := t.todoItemCalendarSvc.RevokeFeed(ginCtx.Request.Context(), ginCtx.Param("id"))
+		if err != nil {
+			appErr.HandelError(ginCtx, err)
+			return
+		}
+
+		appErr.NoContentResponse(ginCtx)
+	}
+}
+
+// MakeGetFeed
+// @Schemes
+// @Summary Get TodoItem calendar feed
+// @Description This api renders todo items as iCalendar VTODO components, calendar apps subscribe to it with the feed token
+// @Tags todo-items
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Success 200  {string}  string
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
+// @Router /todo-items/ics/feeds/{token} [get]
+func (t TodoItemCalendarHttpApp) MakeGetFeed() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		items, err := t.todoItemCalendarSvc.GetFeedItems(ginCtx.Request.Context(), ginCtx.Param("token"))
+		if err != nil {
+			appErr.HandelError(ginCtx, err)
+			return
+		}
+
+		ginCtx.Header("Content-Type", ical.ContentType)
+		ginCtx.Header("Content-Disposition", `inline; filename="todo-items.ics"`)
+		ginCtx.Header("Cache-Control", "private, max-age=300")
+		ginCtx.Status(http.StatusOK)
+		_ = transform.TodoItemsEntityToCalendar(items, time.Now()).Encode(ginCtx.Writer)
+	}
+}
+
+// MakeImport
+// @Schemes
+// @Summary Import TodoItems from iCalendar
+// @Description This api creates or updates todo items from the VTODO components of an .ics file, matched by UID
+// @Tags todo-items
+// @Accept multipart/form-data
+// @Accept text/calendar
+// @Produce json
+// @Security Bearer
+// @Param file formData file false "The .ics file, the raw request body is used when omitted"
+// @Success 200  {object}  dto.ImportTodoItemsResponse
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
+// @Router /todo-items/ics/import [post]
+func (t TodoItemCalendarHttpApp) MakeImport() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		ginCtx.Request.Body = http.MaxBytesReader(ginCtx.Writer, ginCtx.Request.Body, maxImportBodySize)
+		body, err := uploadedFile(ginCtx, importFileField)
+		if err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+		defer body.Close()
+
+		calendars, err := ical.Decode(body)
+		if err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		var todos []ical.Component
+		for _, cal := range calendars {
+			todos = append(todos, cal.Find("VTODO")...)
+		}
+
+		// Every item is stored on its own, a bad component must not roll back the others
+		ctx := ginCtx.Request.Context()
+		report := dto.ImportTodoItemsResponse{Items: make([]dto.ImportTodoItemResult, 0, len(todos))}
+		for i, todo := range todos {
+			row := i + 1
+			uid, req, err := transform.VTodoToCreateTodoItemRequest(todo)
+			if err != nil {
+				report.Add(importFailure(ginCtx, row, uid, appErr.CodeBadRequest.NewWithDetail(err, err.Error())))
+				continue
+			}
+
+			if err = req.Validate(ctx); err != nil {
+				report.Add(importFailure(ginCtx, row, uid, appErr.CodeValidation.New(err)))
+				continue
+			}
+
+			item, created, err := t.todoItemCalendarSvc.Import(ctx, uid, transform.CreateTodoItemRequestToEntity(req))
+			if err != nil {
+				report.Add(importFailure(ginCtx, row, uid, err))
+				continue
+			}
+
+			action, eventType := dto.ImportActionUpdated, entity.TodoItemUpdated
+			if created {
+				action, eventType = dto.ImportActionCreated, entity.TodoItemCreated
+			}
+			t.todoItemStreamSvc.Notify(ctx, eventType, item)
+			report.Add(dto.ImportTodoItemResult{Row: row, Uid: uid, Id: &item.Id, Action: action})
+		}
+
+		appErr.OKResponse(ginCtx, report)
+	}
+}
+
+// uploadedFile reads the multipart `field` when the request is a form upload and the raw body otherwise
+func uploadedFile(ginCtx *gin.Context, field string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(ginCtx.ContentType(), "multipart/") {
+		return ginCtx.Request.Body, nil
+	}
+
+	header, err := ginCtx.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// importFailure reports a failed row like HandelError reports a request: clients see the localized
+// message and the detail of err, the rest of it is kept in the errors of ginCtx for the logs
+func importFailure(ginCtx *gin.Context, row int, uid string, err error) dto.ImportTodoItemResult {
+	var serviceError *appErr.Error
+	if !errors.As(err, &serviceError) {
+		serviceError = appErr.CodeUnknown.New(err)
+	}
+	_ = ginCtx.Error(serviceError)
+
+	res := dto.ImportTodoItemResult{
+		Row:     row,
+		Uid:     uid,
+		Action:  dto.ImportActionFailed,
+		Message: appErr.Message(ginCtx.Request.Context(), serviceError),
+	}
+
+	var errValidation validation.ErrValidation
+	if errors.As(err, &errValidation) {
+		res.Causes = errValidation
+	}
+
+	return res
+}
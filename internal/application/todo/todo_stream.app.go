@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/transform"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+const (
+	lastEventIdHeader        = "Last-Event-ID"
+	defaultHeartbeatInterval = 15 * time.Second
+	streamWriteTimeout       = 10 * time.Second
+)
+
+// originChecker lets a WebSocket upgrade through without an Origin, i.e. not from a browser, from a
+// page of the API host or from one of allowed. CORS does not apply to WebSockets, a page of any
+// site could otherwise read the stream of the user it runs for.
+func originChecker(allowed []string) func(r *http.Request) bool {
+	origins := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || origins["*"] || origins[strings.ToLower(origin)] {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// stream subscribes to the changes of the query, present renders the events in the DTO of the
+// API version
+func (t TodoItemHttpApp) stream(present func(event entity.TodoItemEvent) any) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		var req dto.StreamTodoItemRequest
+		if err := ginCtx.ShouldBindQuery(&req); err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		if err := validation.BindStringSlices(&req); err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		if err := req.Validate(ginCtx.Request.Context()); err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeValidation.New(err))
+			return
+		}
+
+		if header := ginCtx.GetHeader(lastEventIdHeader); header != "" {
+			lastEventId, err := strconv.ParseUint(header, 10, 64)
+			if err != nil {
+				appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+				return
+			}
+			req.LastEventId = lastEventId
+		}
+
+		filter, err := transform.StreamTodoItemRequestToFilter(req)
+		if err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ginCtx.Request.Context())
+		defer cancel()
+
+		events, err := t.todoItemStreamSvc.Subscribe(ctx, filter, req.LastEventId)
+		if err != nil {
+			appErr.HandelError(ginCtx, err)
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(ginCtx.Request) {
+			t.streamWebSocket(ctx, cancel, ginCtx, events, present)
+			return
+		}
+
+		t.streamSSE(ctx, ginCtx, events, present)
+	}
+}
+
+func (t TodoItemHttpApp) streamSSE(ctx context.Context, ginCtx *gin.Context, events <-chan entity.TodoItemEvent, present func(event entity.TodoItemEvent) any) {
+	ginCtx.Header("Content-Type", "text/event-stream")
+	ginCtx.Header("Cache-Control", "no-cache")
+	ginCtx.Header("Connection", "keep-alive")
+	ginCtx.Header("X-Accel-Buffering", "no")
+	ginCtx.Status(http.StatusOK)
+	ginCtx.Writer.WriteHeaderNow()
+	ginCtx.Writer.Flush()
+
+	heartbeat := time.NewTicker(t.heartbeatInterval())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// The stream was closed by the server, the client reconnects with its Last-Event-ID
+				return
+			}
+
+			ginCtx.Render(-1, sse.Event{
+				Id:    strconv.FormatUint(event.Id, 10),
+				Event: event.Type,
+				Data:  present(event),
+			})
+			ginCtx.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := io.WriteString(ginCtx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			ginCtx.Writer.Flush()
+		}
+	}
+}
+
+func (t TodoItemHttpApp) streamWebSocket(ctx context.Context, cancel context.CancelFunc, ginCtx *gin.Context, events <-chan entity.TodoItemEvent, present func(event entity.TodoItemEvent) any) {
+	// Upgrade replies with an HTTP error by itself when it fails
+	conn, err := t.upgrader.Upgrade(ginCtx.Writer, ginCtx.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	interval := t.heartbeatInterval()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * interval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * interval))
+	})
+
+	// Clients only send control frames, reading keeps pong and close handling running
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				_ = conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "stream closed, resume with lastEventId"),
+					time.Now().Add(streamWriteTimeout),
+				)
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(present(event)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t TodoItemHttpApp) heartbeatInterval() time.Duration {
+	if t.streamConf.HeartbeatInterval == "" {
+		return defaultHeartbeatInterval
+	}
+
+	return t.streamConf.HeartbeatInterval.Duration()
+}
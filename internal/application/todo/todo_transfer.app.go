@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/transform"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+const (
+	exportBatchSize       = 500
+	maxTransferBodySize   = 64 << 20
+	maxNDJSONLineSize     = 1 << 20
+	ndjsonContentType     = "application/x-ndjson"
+	csvContentType        = "text/csv; charset=utf-8"
+	transferFormatDefault = dto.TransferFormatCSV
+)
+
+// export streams the items in the format of the query, present renders an NDJSON line in the DTO
+// of the API version, the CSV columns are the same for every version
+func (t TodoItemHttpApp) export(ginCtx *gin.Context, present func(item entity.TodoItem) any) {
+	req, ok := bindTransferRequest(ginCtx)
+	if !ok {
+		return
+	}
+
+	var writeBatch func(batch []entity.TodoItem) error
+	var finish func()
+	switch req.Format {
+	case dto.TransferFormatNDJSON:
+		ginCtx.Header("Content-Type", ndjsonContentType)
+		encoder := json.NewEncoder(ginCtx.Writer)
+		writeBatch = func(batch []entity.TodoItem) error {
+			for _, item := range batch {
+				if err := encoder.Encode(present(item)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = func() {}
+	default:
+		ginCtx.Header("Content-Type", csvContentType)
+		writer := csv.NewWriter(ginCtx.Writer)
+		// The header stays buffered until the first batch, so a failing query can still answer with an error
+		_ = writer.Write(dto.TodoItemCSVHeader)
+		writeBatch = func(batch []entity.TodoItem) error {
+			for _, item := range batch {
+				if err := writer.Write(transform.TodoItemEntityToCSVRecord(item)); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+		finish = writer.Flush
+	}
+
+	ginCtx.Header("Content-Disposition", `attachment; filename="todo-items.`+req.Format+`"`)
+	ginCtx.Status(http.StatusOK)
+
+	// Headers are sent with the first batch, a later failure can only cut the stream short
+	err := t.todoItemSvc.Export(ginCtx.Request.Context(), exportBatchSize, func(batch []entity.TodoItem) error {
+		if err := writeBatch(batch); err != nil {
+			return err
+		}
+		ginCtx.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !ginCtx.Writer.Written() {
+			ginCtx.Writer.Header().Del("Content-Type")
+			ginCtx.Writer.Header().Del("Content-Disposition")
+			appErr.HandelError(ginCtx, err)
+		}
+		return
+	}
+
+	finish()
+}
+
+// MakeImport
+// @Schemes
+// @Summary Import TodoItems
+// @Description This api creates todo items from CSV or newline delimited JSON, rows with the id of an existing item update it. Every row is validated and reported on its own
+// @Tags todo-items
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param format query string false "Import format" Enums(csv, ndjson) default(csv)
+// @Param file formData file false "The file to import, the raw request body is used when omitted"
+// @Success 200  {object}  dto.ImportTodoItemsResponse
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @x-api-v2 true
+// @Router /todo-items/import [post]
+func (t TodoItemHttpApp) MakeImport() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		req, ok := bindTransferRequest(ginCtx)
+		if !ok {
+			return
+		}
+
+		ginCtx.Request.Body = http.MaxBytesReader(ginCtx.Writer, ginCtx.Request.Body, maxTransferBodySize)
+		body, err := uploadedFile(ginCtx, importFileField)
+		if err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+		defer body.Close()
+
+		// Every row is stored on its own, a bad row must not roll back the others
+		var report dto.ImportTodoItemsResponse
+		importRow := func(row int, in dto.ImportTodoItemRow) {
+			report.Add(t.importRow(ginCtx, row, in))
+		}
+		failRow := func(row int, err error) {
+			report.Add(importFailure(ginCtx, row, "", appErr.CodeBadRequest.NewWithDetail(err, err.Error())))
+		}
+
+		switch req.Format {
+		case dto.TransferFormatNDJSON:
+			err = readNDJSON(body, importRow, failRow)
+		default:
+			err = readCSV(body, importRow, failRow)
+		}
+		if err != nil {
+			appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		appErr.OKResponse(ginCtx, report)
+	}
+}
+
+func (t TodoItemHttpApp) importRow(ginCtx *gin.Context, row int, in dto.ImportTodoItemRow) dto.ImportTodoItemResult {
+	ctx := ginCtx.Request.Context()
+	if err := in.CreateTodoItemRequest.Validate(ctx); err != nil {
+		return importFailure(ginCtx, row, in.Id, appErr.CodeValidation.New(err))
+	}
+
+	item, err := transform.ImportTodoItemRowToEntity(in)
+	if err != nil {
+		return importFailure(ginCtx, row, in.Id, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+	}
+
+	item, created, err := t.todoItemSvc.Upsert(ctx, item)
+	if err != nil {
+		return importFailure(ginCtx, row, in.Id, err)
+	}
+
+	action, eventType := dto.ImportActionUpdated, entity.TodoItemUpdated
+	if created {
+		action, eventType = dto.ImportActionCreated, entity.TodoItemCreated
+	}
+	t.todoItemStreamSvc.Notify(ctx, eventType, item)
+
+	return dto.ImportTodoItemResult{Row: row, Uid: in.Id, Id: &item.Id, Action: action}
+}
+
+func bindTransferRequest(ginCtx *gin.Context) (dto.TransferTodoItemRequest, bool) {
+	var req dto.TransferTodoItemRequest
+	if err := ginCtx.ShouldBindQuery(&req); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeBadRequest.NewWithDetail(err, err.Error()))
+		return req, false
+	}
+
+	if err := req.Validate(ginCtx.Request.Context()); err != nil {
+		appErr.HandelError(ginCtx, appErr.CodeValidation.New(err))
+		return req, false
+	}
+
+	if req.Format == "" {
+		req.Format = transferFormatDefault
+	}
+
+	return req, true
+}
+
+// readCSV reads one record at a time, malformed records are reported and skipped, only a missing
+// header fails the whole import, a broken body ends it with a failed row
+func readCSV(r io.Reader, importRow func(row int, in dto.ImportTodoItemRow), failRow func(row int, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return errors.New("csv header is missing: " + err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	column := func(record []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			failRow(row, err)
+			continue
+		}
+		if err != nil {
+			failRow(row, err)
+			return nil
+		}
+
+		importRow(row, dto.ImportTodoItemRow{
+			Id: column(record, "id"),
+			CreateTodoItemRequest: dto.CreateTodoItemRequest{
+				Description: column(record, "description"),
+				DueDate:     column(record, "dueDate"),
+			},
+		})
+	}
+}
+
+func readNDJSON(r io.Reader, importRow func(row int, in dto.ImportTodoItemRow), failRow func(row int, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row++
+
+		var in dto.ImportTodoItemRow
+		if err := json.Unmarshal([]byte(line), &in); err != nil {
+			failRow(row, err)
+			continue
+		}
+
+		importRow(row, in)
+	}
+
+	if err := scanner.Err(); err != nil {
+		failRow(row+1, err)
+	}
+
+	return nil
+}
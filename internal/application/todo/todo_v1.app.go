@@ -0,0 +1,232 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/dto"
+	"github.com/thealiakbari/todoapp/internal/application/todo/domain/transform"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+// The handlers of the v1 API, the ones of the other files are shared by every version
+
+// MakeCreate
+// @Schemes
+// @Summary Create TodoItem
+// @Description This api for create poll
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param  body body dto.CreateTodoItemRequest true "Contains information to set data"
+// @Success 201  {object}  dto.TodoItem
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items [post]
+func (t TodoItemHttpApp) MakeCreate() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		create[dto.CreateTodoItemRequest](t, ginCtx, transform.CreateTodoItemRequestToEntity, func(item entity.TodoItem) any {
+			return transform.TodoItemEntityToTodoItemDto(item)
+		})
+	}
+}
+
+// MakeUpdate
+// @Schemes
+// @Summary Update TodoItem
+// @Description This api for update poll
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param id path string true "TodoItem Id"
+// @Param  body body dto.UpdateTodoItemRequest true "Contains information to set data"
+// @Success 200  {object}  dto.TodoItem
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/{id} [put]
+func (t TodoItemHttpApp) MakeUpdate() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		update[dto.UpdateTodoItemRequest](t, ginCtx, transform.UpdateTodoItemRequestToEntity, func(item entity.TodoItem) any {
+			return transform.TodoItemEntityToTodoItemDto(item)
+		})
+	}
+}
+
+// MakeGetById
+// @Schemes
+// @Summary Get TodoItem By Id
+// @Description This api for poll by id
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param id path string true "TodoItem Id"
+// @Success 200  {object} dto.TodoItem
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/{id} [get]
+func (t TodoItemHttpApp) MakeGetById() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		pollEntityResp, err := t.todoItemSvc.GetByIdOrEmpty(ginCtx.Request.Context(), ginCtx.Param("id"))
+		if err != nil {
+			appErr.HandelError(ginCtx, err)
+			return
+		}
+
+		appErr.OKResponse(ginCtx, transform.TodoItemEntityToTodoItemDto(pollEntityResp))
+	}
+}
+
+// MakeList
+// @Schemes
+// @Summary List TodoItems
+// @Description This api lists todo items, newest first. Without `page` the list is read with
+// @Description cursors: pass `nextCursor` or `prevCursor` of a response as `cursor` to get the
+// @Description page after or before it. Cursor lists only have `totalItems` when `count` asks for
+// @Description it, `estimated` may be off but does not count the rows.
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param ids query []string false "TodoItem Ids" collectionFormat(csv)
+// @Param page query int false "Page, starts from 1" default(1)
+// @Param pageSize query int false "Page size" default(12)
+// @Param paging query string false "cursor pages the list with cursors instead of page, a cursor implies it" Enums(page, cursor)
+// @Param cursor query string false "Cursor of the page to read, cannot be used with page"
+// @Param count query string false "Total count of a cursor list" Enums(exact, estimated)
+// @Success 200  {object}  appErr.ListResponse{items=[]dto.TodoItem}
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items [get]
+func (t TodoItemHttpApp) MakeList() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		t.list(ginCtx, func(items []entity.TodoItem) any {
+			return transform.TodoItemsEntityToTodoItemsDto(items)
+		})
+	}
+}
+
+// MakeStream
+// @Schemes
+// @Summary Stream TodoItem changes
+// @Description This api streams todo item changes over Server-Sent Events, or over WebSocket when the request is an upgrade
+// @Tags todo-items
+// @Produce text/event-stream
+// @Security Bearer
+// @Param types query []string false "Event types: created, updated, completed, deleted, purged" collectionFormat(csv)
+// @Param ids query []string false "TodoItem Ids" collectionFormat(csv)
+// @Param lastEventId query int false "Resume after this event id, same as the Last-Event-ID header"
+// @Param Last-Event-ID header int false "Resume after this event id"
+// @Success 200  {object}  dto.TodoItemEvent
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/stream [get]
+func (t TodoItemHttpApp) MakeStream() gin.HandlerFunc {
+	return t.stream(func(event entity.TodoItemEvent) any {
+		return transform.TodoItemEventEntityToTodoItemEventDto(event)
+	})
+}
+
+// MakeDone
+// @Schemes
+// @Summary Complete TodoItem
+// @Description This api marks a todo item done, an item that is done already keeps its completion time
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param id path string true "TodoItem Id"
+// @Success 200  {object}  dto.TodoItem
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/{id}/done [put]
+func (t TodoItemHttpApp) MakeDone() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		t.complete(ginCtx, true, func(item entity.TodoItem) any {
+			return transform.TodoItemEntityToTodoItemDto(item)
+		})
+	}
+}
+
+// MakeReopen
+// @Schemes
+// @Summary Reopen TodoItem
+// @Description This api marks a done todo item open again
+// @Tags todo-items
+// @Accept json
+// @Produce json
+// @Content-Type application/json
+// @Security Bearer
+// @Param id path string true "TodoItem Id"
+// @Success 200  {object}  dto.TodoItem
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 404  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/{id}/done [delete]
+func (t TodoItemHttpApp) MakeReopen() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		t.complete(ginCtx, false, func(item entity.TodoItem) any {
+			return transform.TodoItemEntityToTodoItemDto(item)
+		})
+	}
+}
+
+// MakeExport
+// @Schemes
+// @Summary Export TodoItems
+// @Description This api streams the todo items of the user as CSV or newline delimited JSON of v1 items
+// @Tags todo-items
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security Bearer
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Success 200  {string}  string
+// @Failure 400  {object}  appErr.ErrSwaggerResponse
+// @Failure 401  {object}  appErr.ErrSwaggerResponse
+// @Failure 403  {object}  appErr.ErrSwaggerResponse
+// @Failure 422  {object}  appErr.ErrValidationSwaggerResponse
+// @Failure 500  {object}  appErr.ErrSwaggerResponse
+// @x-api-v1 true
+// @Router /todo-items/export [get]
+func (t TodoItemHttpApp) MakeExport() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		t.export(ginCtx, func(item entity.TodoItem) any {
+			return transform.TodoItemEntityToTodoItemDto(item)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package todo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/transaction"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+)
+
+const (
+	feedTokenBytes = 32
+	feedPageSize   = 500
+)
+
+var feedOrder = []todo.Order{{Field: todo.FieldDueDate}, {Field: todo.FieldId}}
+
+// importUidNamespace derives stable item ids from foreign calendar UIDs, so importing
+// the same file twice updates the items instead of duplicating them. The ids are per owner, users
+// importing the same UID get items of their own.
+var importUidNamespace = uuid.MustParse("6f1c7a52-2f0e-4a55-9a0b-3d8f2b7c1e44")
+
+type TodoItemCalendarConfig struct {
+	Logger           logger.Logger
+	UnitOfWork       transaction.UnitOfWork
+	TodoItemRepo     todo.TodoItemRepository
+	TodoItemFeedRepo todo.TodoItemFeedRepository
+}
+
+type todoItemCalendarService struct {
+	TodoItemCalendarConfig
+}
+
+func NewTodoItemCalendarService(config TodoItemCalendarConfig) todoInterface.TodoItemCalendarService {
+	s := todoItemCalendarService{config}
+	s.Logger = config.Logger.ForService(s)
+	return s
+}
+
+func (s todoItemCalendarService) CreateFeed(ctx context.Context) (res entity.TodoItemFeed, token string, err error) {
+	raw := make([]byte, feedTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return entity.TodoItemFeed{}, "", CodeFeedTokenFailed.New(err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+
+	feed := entity.TodoItemFeed{TokenHash: hashFeedToken(token), UserReferenceId: ownerOf(ctx)}
+
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		res, err = s.TodoItemFeedRepo.Create(ctx, feed)
+		return err
+	})
+	if err != nil {
+		s.Logger.Errorf(ctx, "Cannot create todo item feed: %v", err)
+		return entity.TodoItemFeed{}, "", CodeFeedStorage.New(err)
+	}
+
+	return res, token, nil
+}
+
+func (s todoItemCalendarService) RevokeFeed(ctx context.Context, id string) (err error) {
+	if id == "" {
+		err := errors.New("id must not be empty")
+		return CodeFeedIdRequired.New(err)
+	}
+
+	feed, err := s.TodoItemFeedRepo.FindByIdOrEmpty(ctx, id)
+	if err != nil {
+		return CodeFeedStorage.New(err)
+	}
+
+	if feed.Id == uuid.Nil {
+		err := errors.New("feed not found")
+		return CodeFeedNotFound.New(err)
+	}
+
+	// a feed without a user is only revoked without one too
+	if !entity.SameOwner(feed.UserReferenceId, ownerOf(ctx)) {
+		err := errors.New("feed belongs to another user")
+		return CodeFeedForbidden.New(err)
+	}
+
+	err = s.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		return s.TodoItemFeedRepo.Delete(ctx, id)
+	})
+	if err != nil {
+		return CodeFeedStorage.New(err)
+	}
+
+	return nil
+}
+
+// GetFeedItems returns the items of the owner of the feed, a feed created without a user
+// lists the items created without one
+func (s todoItemCalendarService) GetFeedItems(ctx context.Context, token string) (res []entity.TodoItem, err error) {
+	feed, err := s.TodoItemFeedRepo.FindByTokenHashOrEmpty(ctx, hashFeedToken(token))
+	if err != nil {
+		return nil, CodeFeedStorage.New(err)
+	}
+
+	if feed.Id == uuid.Nil {
+		// Unknown and revoked tokens look the same, so tokens cannot be probed
+		err := errors.New("feed not found")
+		return nil, CodeFeedNotFound.New(err)
+	}
+
+	owner := ownedBy(feed.UserReferenceId)
+	for offset := 0; ; offset += feedPageSize {
+		page, err := s.TodoItemRepo.FilterFind(ctx, owner, feedOrder, feedPageSize, offset)
+		if err != nil {
+			return nil, CodeTodoItemStorage.New(err)
+		}
+
+		res = append(res, page...)
+		if len(page) < feedPageSize {
+			return res, nil
+		}
+	}
+}
+
+func (s todoItemCalendarService) Import(ctx context.Context, uid string, item entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	if uid == "" {
+		err := errors.New("uid must not be empty")
+		return entity.TodoItem{}, false, CodeImportUidRequired.New(err)
+	}
+
+	if err = item.Validate(ctx); err != nil {
+		return entity.TodoItem{}, false, CodeTodoItemInvalid.New(err)
+	}
+
+	id, err := uuid.Parse(uid)
+	if err != nil {
+		id = importUidToId(ctx, uid)
+	}
+
+	item.Id = id
+	return upsertTodoItem(ctx, s.Logger, s.UnitOfWork, s.TodoItemRepo, item)
+}
+
+// importUidToId is the item id of a foreign UID for the user of ctx, without one it is the id
+// derived from the UID alone
+func importUidToId(ctx context.Context, uid string) uuid.UUID {
+	name := uid
+	if owner := ownerOf(ctx); owner != nil {
+		name = *owner + "\x00" + uid
+	}
+	return uuid.NewSHA1(importUidNamespace, []byte(name))
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,182 @@
+package todo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+type mockFeedRepo struct {
+	mock.Mock
+}
+
+func (m *mockFeedRepo) Create(ctx context.Context, in entity.TodoItemFeed) (entity.TodoItemFeed, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(entity.TodoItemFeed), args.Error(1)
+}
+
+func (m *mockFeedRepo) FindByIdOrEmpty(ctx context.Context, id string) (entity.TodoItemFeed, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entity.TodoItemFeed), args.Error(1)
+}
+
+func (m *mockFeedRepo) FindByTokenHashOrEmpty(ctx context.Context, tokenHash string) (entity.TodoItemFeed, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(entity.TodoItemFeed), args.Error(1)
+}
+
+func (m *mockFeedRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestGetFeedItems_Owner(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	feedRepo := new(mockFeedRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemCalendarService(TodoItemCalendarConfig{
+		Logger:           log,
+		UnitOfWork:       mockUnitOfWork{},
+		TodoItemRepo:     repo,
+		TodoItemFeedRepo: feedRepo,
+	})
+
+	owner := "alice"
+	owned := entity.TodoItemFeed{UserReferenceId: &owner}
+	owned.Id = uuid.New()
+	anonymous := entity.TodoItemFeed{}
+	anonymous.Id = uuid.New()
+	feedRepo.On("FindByTokenHashOrEmpty", ctx, hashFeedToken("owned")).Return(owned, nil)
+	feedRepo.On("FindByTokenHashOrEmpty", ctx, hashFeedToken("anonymous")).Return(anonymous, nil)
+
+	items := []entity.TodoItem{{Description: "test", DueDate: "2025-01-01", UserReferenceId: &owner}}
+	repo.On("FilterFind", ctx, todo.Eq{Field: todo.FieldUserReferenceId, Value: owner}, feedOrder, feedPageSize, 0).Return(items, nil)
+	repo.On("FilterFind", ctx, todo.Null{Field: todo.FieldUserReferenceId}, feedOrder, feedPageSize, 0).Return([]entity.TodoItem{}, nil)
+
+	res, err := service.GetFeedItems(ctx, "owned")
+	assert.NoError(t, err)
+	assert.Equal(t, items, res)
+
+	res, err = service.GetFeedItems(ctx, "anonymous")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	repo.AssertExpectations(t)
+	feedRepo.AssertExpectations(t)
+}
+
+func TestRevokeFeed_Owner(t *testing.T) {
+	repo := new(mockRepo)
+	feedRepo := new(mockFeedRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemCalendarService(TodoItemCalendarConfig{
+		Logger:           log,
+		UnitOfWork:       mockUnitOfWork{},
+		TodoItemRepo:     repo,
+		TodoItemFeedRepo: feedRepo,
+	})
+
+	alice, bob := "alice", "bob"
+	feed := func(owner *string) string {
+		f := entity.TodoItemFeed{UserReferenceId: owner}
+		f.Id = uuid.New()
+		feedRepo.On("FindByIdOrEmpty", mock.Anything, f.Id.String()).Return(f, nil)
+		return f.Id.String()
+	}
+	as := func(user *string) context.Context {
+		if user == nil {
+			return context.Background()
+		}
+		return context.WithValue(context.Background(), middleware.UserReferenceIdKey, *user)
+	}
+
+	cases := []struct {
+		name   string
+		owner  *string
+		caller *string
+		ok     bool
+	}{
+		{"owner", &alice, &alice, true},
+		{"another user", &alice, &bob, false},
+		{"anonymous caller", &alice, nil, false},
+		{"anonymous feed", nil, &bob, false},
+		{"anonymous feed and caller", nil, nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			id := feed(c.owner)
+			if c.ok {
+				feedRepo.On("Delete", mock.Anything, id).Return(nil).Once()
+			}
+
+			err := service.RevokeFeed(as(c.caller), id)
+			if c.ok {
+				assert.NoError(t, err)
+				return
+			}
+			var e *appErr.Error
+			assert.ErrorAs(t, err, &e)
+			assert.Equal(t, appErr.EAccess, e.Class)
+		})
+	}
+
+	feedRepo.AssertExpectations(t)
+}
+
+func TestImport_Owner(t *testing.T) {
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemCalendarService(TodoItemCalendarConfig{
+		Logger:           log,
+		UnitOfWork:       mockUnitOfWork{},
+		TodoItemRepo:     repo,
+		TodoItemFeedRepo: new(mockFeedRepo),
+	})
+
+	alice := "alice"
+	ctx := context.WithValue(context.Background(), middleware.UserReferenceIdKey, alice)
+	item := entity.TodoItem{Description: "test", DueDate: "2025-01-01"}
+
+	owned := item
+	owned.Id = uuid.NewSHA1(importUidNamespace, []byte("alice\x0042@example.com"))
+	owned.UserReferenceId = &alice
+	repo.On("Upsert", ctx, owned).Return(owned, true, nil).Once()
+
+	res, created, err := service.Import(ctx, "42@example.com", item)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, uuid.NewSHA1(importUidNamespace, []byte("42@example.com")), res.Id, "the ids are per owner")
+
+	taken := item
+	taken.Id = uuid.New()
+	taken.UserReferenceId = &alice
+	repo.On("Upsert", ctx, taken).Return(entity.TodoItem{}, false, todo.ErrOwnedByAnother).Once()
+
+	_, _, err = service.Import(ctx, taken.Id.String(), item)
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.EAccess, e.Class)
+
+	repo.AssertExpectations(t)
+}
@@ -2,16 +2,33 @@ package entity
 
 import (
 	"context"
+	"time"
 
 	"github.com/thealiakbari/todoapp/pkg/common/db"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
 )
 
 type TodoItem struct {
 	db.UniversalModel
-	Description string `gorm:"column:description;type:text;not null" validate:"required"`
-	DueDate     string `gorm:"column:due_date;type:timestamp;not null" validate:"required"`
+	Description     string     `gorm:"column:description;type:text;not null" validate:"required"`
+	DueDate         string     `gorm:"column:due_date;type:timestamp;not null" validate:"required"`
+	CompletedAt     *time.Time `gorm:"column:completed_at"`
+	UserReferenceId *string    `gorm:"column:user_reference_id;type:text"`
+}
+
+// Done reports whether the item was completed
+func (u TodoItem) Done() bool {
+	return u.CompletedAt != nil
 }
 
 func (u TodoItem) Validate(ctx context.Context) error {
-	return nil
+	return validation.Validate(ctx, u)
+}
+
+// SameOwner reports whether two owners are the same user, or both no user
+func SameOwner(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
 }
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TodoItemEventType = string
+
+const (
+	TodoItemCreated TodoItemEventType = "created"
+	TodoItemUpdated TodoItemEventType = "updated"
+	// TodoItemCompleted is the update that marks an item done, opening it again is an update
+	TodoItemCompleted TodoItemEventType = "completed"
+	TodoItemDeleted   TodoItemEventType = "deleted"
+	TodoItemPurged    TodoItemEventType = "purged"
+	// TodoItemReset tells a subscriber that its cursor is no longer retained and
+	// changes may have been missed, so it should reload its state.
+	TodoItemReset TodoItemEventType = "reset"
+)
+
+type TodoItemEvent struct {
+	Id         uint64
+	Type       TodoItemEventType
+	Item       TodoItem
+	OccurredAt time.Time
+}
+
+// TodoItemEventFilter narrows a change stream, empty Types and Ids match everything. Only the
+// events of the items of Owner match, a nil Owner matches the items without one.
+type TodoItemEventFilter struct {
+	Types []TodoItemEventType
+	Ids   []uuid.UUID
+	Owner *string
+}
+
+func (f TodoItemEventFilter) Match(event TodoItemEvent) bool {
+	if event.Type == TodoItemReset {
+		return true
+	}
+
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+
+	if len(f.Ids) > 0 && !slices.Contains(f.Ids, event.Item.Id) {
+		return false
+	}
+
+	if !SameOwner(f.Owner, event.Item.UserReferenceId) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoItemEventFilter_MatchOwner(t *testing.T) {
+	alice, bob := "alice", "bob"
+	event := func(owner *string) TodoItemEvent {
+		return TodoItemEvent{Type: TodoItemUpdated, Item: TodoItem{UserReferenceId: owner}}
+	}
+
+	assert.True(t, TodoItemEventFilter{Owner: &alice}.Match(event(&alice)))
+	assert.False(t, TodoItemEventFilter{Owner: &alice}.Match(event(&bob)))
+	assert.False(t, TodoItemEventFilter{Owner: &alice}.Match(event(nil)))
+	assert.False(t, TodoItemEventFilter{}.Match(event(&alice)), "a subscriber without a user only sees items without an owner")
+	assert.True(t, TodoItemEventFilter{}.Match(event(nil)))
+	assert.True(t, TodoItemEventFilter{Owner: &bob}.Match(TodoItemEvent{Type: TodoItemReset}), "every subscriber is told to reset")
+}
@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+)
+
+// TodoItemFeed is a calendar subscription, only the hash of its token is stored
+type TodoItemFeed struct {
+	db.UniversalModel
+	UserReferenceId *string `gorm:"column:user_reference_id;type:text"`
+	TokenHash       string  `gorm:"column:token_hash;type:text;not null"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+)
+
+func TestTodoItem_Validate(t *testing.T) {
+	ctx := context.Background()
+
+	err := TodoItem{}.Validate(ctx)
+	var errValidation validation.ErrValidation
+	assert.True(t, errors.As(err, &errValidation))
+	assert.Len(t, errValidation, 2, "description and due date are required")
+
+	assert.NoError(t, TodoItem{Description: "a", DueDate: "2026-01-02"}.Validate(ctx))
+}
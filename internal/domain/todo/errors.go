@@ -0,0 +1,26 @@
+package todo
+
+import (
+	"net/http"
+
+	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+// The error codes of the todo items, 2000-2099, and of their calendar feeds, 2100-2199. Their
+// messages are in the `todo.` section of the locales.
+var (
+	CodeTodoItemInvalid           = appErr.NewCode(2001, appErr.EValidation, http.StatusUnprocessableEntity, "todo.item_invalid")
+	CodeTodoItemIdRequired        = appErr.NewCode(2002, appErr.EValidation, http.StatusUnprocessableEntity, "todo.id_required")
+	CodeTodoItemStorage           = appErr.NewCode(2003, appErr.EConflict, http.StatusConflict, "todo.storage")
+	CodeTodoItemCursorInvalid     = appErr.NewCode(2004, appErr.EValidation, http.StatusUnprocessableEntity, "todo.cursor_invalid")
+	CodeTodoItemStreamUnavailable = appErr.NewCode(2005, appErr.EConflict, http.StatusConflict, "todo.stream_unavailable")
+	CodeTodoItemNotFound          = appErr.NewCode(2006, appErr.ENotFound, http.StatusNotFound, "todo.not_found")
+	CodeTodoItemForbidden         = appErr.NewCode(2007, appErr.EAccess, http.StatusForbidden, "todo.forbidden")
+
+	CodeFeedTokenFailed   = appErr.NewCode(2101, appErr.EUnknown, http.StatusInternalServerError, "todo.feed.token_failed")
+	CodeFeedStorage       = appErr.NewCode(2102, appErr.EConflict, http.StatusConflict, "todo.feed.storage")
+	CodeFeedIdRequired    = appErr.NewCode(2103, appErr.EValidation, http.StatusUnprocessableEntity, "todo.feed.id_required")
+	CodeFeedNotFound      = appErr.NewCode(2104, appErr.ENotFound, http.StatusNotFound, "todo.feed.not_found")
+	CodeFeedForbidden     = appErr.NewCode(2105, appErr.EAccess, http.StatusForbidden, "todo.feed.forbidden")
+	CodeImportUidRequired = appErr.NewCode(2106, appErr.EValidation, http.StatusUnprocessableEntity, "todo.feed.uid_required")
+)
@@ -3,16 +3,23 @@ package todo
 import (
 	"context"
 	"errors"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
 	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
 	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/transaction"
 	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/request"
 	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
 )
 
 type TodoItemConfig struct {
 	Logger       logger.Logger
+	UnitOfWork   transaction.UnitOfWork
 	TodoItemRepo todo.TodoItemRepository
 }
 
@@ -29,22 +36,17 @@ func NewTodoItemService(config TodoItemConfig) todoInterface.TodoItemService {
 func (u todoItemService) Create(ctx context.Context, req entity.TodoItem) (res entity.TodoItem, err error) {
 	if err = req.Validate(ctx); err != nil {
 		u.Logger.Warnf(ctx, "validation error:%v", err)
-		return entity.TodoItem{}, &appErr.Error{
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EValidation,
-		}
+		return entity.TodoItem{}, CodeTodoItemInvalid.New(err)
 	}
 
-	todoItemEntity, err := u.TodoItemRepo.Create(ctx, req)
+	var todoItemEntity entity.TodoItem
+	err = u.UnitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		todoItemEntity, err = u.TodoItemRepo.Create(ctx, withOwner(ctx, req))
+		return err
+	})
 	if err != nil {
 		u.Logger.Errorf(ctx, "Cannot create todo item: %v", err)
-		return entity.TodoItem{}, &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EConflict,
-		}
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
 	}
 
 	return todoItemEntity, nil
@@ -53,92 +55,343 @@ func (u todoItemService) Create(ctx context.Context, req entity.TodoItem) (res e
 func (u todoItemService) Update(ctx context.Context, req entity.TodoItem) (res entity.TodoItem, err error) {
 	if err = req.Validate(ctx); err != nil {
 		u.Logger.Warnf(ctx, "validation error:%v", err)
-		return entity.TodoItem{}, &appErr.Error{
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EValidation,
+		return entity.TodoItem{}, CodeTodoItemInvalid.New(err)
+	}
+
+	err = u.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		existing, err := u.TodoItemRepo.FindByIdOrEmpty(ctx, req.Id.String())
+		if err != nil {
+			return err
 		}
+
+		// the completion only changes through Complete, the owner never
+		req.CompletedAt = existing.CompletedAt
+		req.UserReferenceId = existing.UserReferenceId
+		return u.TodoItemRepo.Update(ctx, req)
+	})
+	if err != nil {
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
+	}
+
+	return req, nil
+}
+
+func (u todoItemService) Complete(ctx context.Context, id string, done bool) (res entity.TodoItem, err error) {
+	if id == "" {
+		err = errors.New("id must not be empty")
+		return entity.TodoItem{}, CodeTodoItemIdRequired.New(err)
 	}
 
-	err = u.TodoItemRepo.Update(ctx, req)
+	err = u.UnitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		res, err = u.TodoItemRepo.FindByIdOrEmpty(ctx, id)
+		if err != nil {
+			return CodeTodoItemStorage.New(err)
+		}
+
+		if res.Id == uuid.Nil {
+			err := errors.New("todo item not found")
+			return CodeTodoItemNotFound.New(err)
+		}
+
+		// completing a done item keeps when it was done
+		if done == res.Done() {
+			return nil
+		}
+
+		res.CompletedAt = nil
+		if done {
+			now := time.Now().UTC()
+			res.CompletedAt = &now
+		}
+
+		if err = u.TodoItemRepo.Update(ctx, res); err != nil {
+			return CodeTodoItemStorage.New(err)
+		}
+		return nil
+	})
 	if err != nil {
-		return entity.TodoItem{}, &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EConflict,
+		var appError *appErr.Error
+		if errors.As(err, &appError) {
+			return entity.TodoItem{}, err
 		}
+
+		u.Logger.Errorf(ctx, "Cannot complete todo item %s: %v", id, err)
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
 	}
 
-	return req, nil
+	return res, nil
 }
 
 func (u todoItemService) GetByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error) {
 	if id == "" {
 		err = errors.New("id must not be empty")
-		return entity.TodoItem{}, &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: "Id(TodoItemId) cannot be empty",
-			Class:   appErr.EValidation,
-		}
+		return entity.TodoItem{}, CodeTodoItemIdRequired.New(err)
 	}
 
 	todoItemEntity, err := u.TodoItemRepo.FindByIdOrEmpty(ctx, id)
 	if err != nil {
-		return entity.TodoItem{}, &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EConflict,
-		}
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
 	}
 
 	return todoItemEntity, nil
 }
 
-func (u todoItemService) Purge(ctx context.Context, id string) (err error) {
-	if id == "" {
-		err := errors.New("id must not be empty")
-		return &appErr.Error{
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EValidation,
+// listOrder is newest first, the id keeps items created at the same time in place between pages
+var listOrder = []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}, {Field: todo.FieldId, Desc: true}}
+
+func listCriteria(ids []string) todo.Criteria {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return todo.InValues(todo.FieldId, ids)
+}
+
+func (u todoItemService) List(ctx context.Context, ids []string, portion request.Portion) (res []entity.TodoItem, count int64, err error) {
+	criteria := listCriteria(ids)
+
+	res, err = u.TodoItemRepo.FilterFind(ctx, criteria, listOrder, portion.Limit, portion.Offset)
+	if err != nil {
+		return nil, 0, CodeTodoItemStorage.New(err)
+	}
+
+	count, err = u.TodoItemRepo.FilterCount(ctx, criteria)
+	if err != nil {
+		return nil, 0, CodeTodoItemStorage.New(err)
+	}
+
+	return res, count, nil
+}
+
+// ListByCursor pages through the same order as List, newest first, with the keyset of
+// (created_at, id) so a page costs the same however deep it is
+func (u todoItemService) ListByCursor(ctx context.Context, ids []string, keyset request.Keyset) (res []entity.TodoItem, page request.KeysetPage, err error) {
+	criteria := listCriteria(ids)
+
+	seek := todo.Seek{Field: todo.FieldCreatedAt, Desc: true}
+	var backward bool
+	if keyset.Cursor != "" {
+		seek.Key, seek.Id, backward, err = decodeListCursor(keyset.Cursor)
+		if err != nil {
+			return nil, request.KeysetPage{}, CodeTodoItemCursorInvalid.New(err)
 		}
+		// the page before a position is the page after it in the reversed order
+		seek.Desc = !backward
 	}
 
-	err = u.TodoItemRepo.Purge(ctx, id)
+	// the extra row tells whether there is a page after this one
+	res, err = u.TodoItemRepo.FilterSeek(ctx, criteria, seek, keyset.Limit+1)
 	if err != nil {
-		return &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EConflict,
+		return nil, request.KeysetPage{}, CodeTodoItemStorage.New(err)
+	}
+
+	more := len(res) > keyset.Limit
+	if more {
+		res = res[:keyset.Limit]
+	}
+	if backward {
+		slices.Reverse(res)
+	}
+
+	if len(res) > 0 {
+		hasNext, hasPrev := more, keyset.Cursor != ""
+		if backward {
+			hasNext, hasPrev = true, more
+		}
+
+		if hasNext {
+			page.NextCursor = listCursor(res[len(res)-1], false)
+		}
+		if hasPrev {
+			page.PrevCursor = listCursor(res[0], true)
 		}
 	}
 
-	return nil
+	var count int64
+	switch keyset.Count {
+	case request.CountExact:
+		count, err = u.TodoItemRepo.FilterCount(ctx, criteria)
+	case request.CountEstimated:
+		count, err = u.TodoItemRepo.EstimateCount(ctx, criteria)
+		page.Estimated = true
+	default:
+		return res, page, nil
+	}
+	if err != nil {
+		return nil, request.KeysetPage{}, CodeTodoItemStorage.New(err)
+	}
+	page.Count = &count
+
+	return res, page, nil
+}
+
+func listCursor(item entity.TodoItem, backward bool) string {
+	return request.Cursor{
+		Key:      item.CreatedAt.Format(time.RFC3339Nano),
+		Id:       item.Id.String(),
+		Backward: backward,
+	}.Encode()
 }
 
-func (u todoItemService) Delete(ctx context.Context, id string) (err error) {
+func decodeListCursor(s string) (key time.Time, id string, backward bool, err error) {
+	cursor, err := request.DecodeCursor(s)
+	if err != nil {
+		return time.Time{}, "", false, err
+	}
+
+	// the offset is kept, SQLite compares the times as text
+	key, err = time.Parse(time.RFC3339Nano, cursor.Key)
+	if err != nil {
+		return time.Time{}, "", false, request.ErrInvalidCursor
+	}
+
+	if err = uuid.Validate(cursor.Id); err != nil {
+		return time.Time{}, "", false, request.ErrInvalidCursor
+	}
+
+	return key, cursor.Id, cursor.Backward, nil
+}
+
+func (u todoItemService) Purge(ctx context.Context, id string) (res entity.TodoItem, err error) {
 	if id == "" {
 		err := errors.New("id must not be empty")
-		return &appErr.Error{
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EValidation,
-		}
+		return entity.TodoItem{}, CodeTodoItemIdRequired.New(err)
+	}
+
+	err = u.UnitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		res, err = u.TodoItemRepo.Purge(ctx, id)
+		return err
+	})
+	if err != nil {
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
+	}
+
+	if res.Id == uuid.Nil {
+		err := errors.New("todo item not found")
+		return entity.TodoItem{}, CodeTodoItemNotFound.New(err)
 	}
 
-	err = u.TodoItemRepo.Delete(ctx, id)
+	return res, nil
+}
+
+func (u todoItemService) Delete(ctx context.Context, id string) (res entity.TodoItem, err error) {
+	if id == "" {
+		err := errors.New("id must not be empty")
+		return entity.TodoItem{}, CodeTodoItemIdRequired.New(err)
+	}
+
+	err = u.UnitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		res, err = u.TodoItemRepo.Delete(ctx, id)
+		return err
+	})
+	if err != nil {
+		return entity.TodoItem{}, CodeTodoItemStorage.New(err)
+	}
+
+	if res.Id == uuid.Nil {
+		err := errors.New("todo item not found")
+		return entity.TodoItem{}, CodeTodoItemNotFound.New(err)
+	}
+
+	return res, nil
+}
+
+func (u todoItemService) Upsert(ctx context.Context, req entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	if err = req.Validate(ctx); err != nil {
+		u.Logger.Warnf(ctx, "validation error:%v", err)
+		return entity.TodoItem{}, false, CodeTodoItemInvalid.New(err)
+	}
+
+	return upsertTodoItem(ctx, u.Logger, u.UnitOfWork, u.TodoItemRepo, req)
+}
+
+// Export walks the items of the user of ctx, without one the items created without one
+func (u todoItemService) Export(ctx context.Context, batchSize int, fc func(batch []entity.TodoItem) error) (err error) {
+	err = u.TodoItemRepo.FindInBatches(ctx, ownedBy(ownerOf(ctx)), batchSize, fc)
 	if err != nil {
-		return &appErr.Error{
-			ErrCode: 1024,
-			Cause:   err,
-			Message: err.Error(),
-			Class:   appErr.EConflict,
+		u.Logger.Errorf(ctx, "Cannot export todo items: %v", err)
+		return CodeTodoItemStorage.New(err)
+	}
+
+	return nil
+}
+
+func (u todoItemService) CountOverdue(ctx context.Context, now time.Time) (count int64, err error) {
+	count, err = u.TodoItemRepo.FilterCount(ctx, todo.And{
+		todo.Range{Field: todo.FieldDueDate, To: now},
+		todo.Null{Field: todo.FieldCompletedAt},
+	})
+	if err != nil {
+		return 0, CodeTodoItemStorage.New(err)
+	}
+
+	return count, nil
+}
+
+// upsertTodoItem reads and writes the item in one unit of work, errors are already *appErr.Error
+// unless the commit itself failed
+func upsertTodoItem(ctx context.Context, log logger.Logger, uow transaction.UnitOfWork, repo todo.TodoItemRepository, req entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	err = uow.Do(ctx, func(ctx context.Context) (err error) {
+		res, created, err = upsertTodoItemTx(ctx, log, repo, req)
+		return err
+	})
+	if err != nil {
+		var appError *appErr.Error
+		if errors.As(err, &appError) {
+			return entity.TodoItem{}, false, err
+		}
+
+		log.Errorf(ctx, "Cannot save todo item: %v", err)
+		return entity.TodoItem{}, false, CodeTodoItemStorage.New(err)
+	}
+
+	return res, created, nil
+}
+
+func upsertTodoItemTx(ctx context.Context, log logger.Logger, repo todo.TodoItemRepository, req entity.TodoItem) (res entity.TodoItem, created bool, err error) {
+	// only the items of the owner are updated, the id of another one's item is refused
+	req = withOwner(ctx, req)
+	if req.Id == uuid.Nil {
+		res, err = repo.Create(ctx, req)
+		if err != nil {
+			log.Errorf(ctx, "Cannot create todo item: %v", err)
+			return entity.TodoItem{}, false, CodeTodoItemStorage.New(err)
 		}
+
+		return res, true, nil
+	}
+
+	// a deleted item with the id is restored, the id stays unique
+	res, created, err = repo.Upsert(ctx, req)
+	if errors.Is(err, todo.ErrOwnedByAnother) {
+		return entity.TodoItem{}, false, CodeTodoItemForbidden.New(err)
+	}
+	if err != nil {
+		log.Errorf(ctx, "Cannot save todo item %s: %v", req.Id, err)
+		return entity.TodoItem{}, false, CodeTodoItemStorage.New(err)
 	}
 
+	return res, created, nil
+}
+
+// withOwner makes the user of ctx the owner of item, items created without a user have none
+func withOwner(ctx context.Context, item entity.TodoItem) entity.TodoItem {
+	item.UserReferenceId = ownerOf(ctx)
+	return item
+}
+
+// ownedBy selects the items of owner, a nil owner selects the items without one
+func ownedBy(owner *string) todo.Criteria {
+	if owner == nil {
+		return todo.Null{Field: todo.FieldUserReferenceId}
+	}
+	return todo.Eq{Field: todo.FieldUserReferenceId, Value: *owner}
+}
+
+// ownerOf is the user of ctx, nil without one
+func ownerOf(ctx context.Context) *string {
+	if userReferenceId, err := middleware.GetUserReferenceId(ctx); err == nil {
+		return &userReferenceId
+	}
 	return nil
 }
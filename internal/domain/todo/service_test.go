@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
 	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/request"
 	appErr "github.com/thealiakbari/todoapp/pkg/common/response"
 )
 
@@ -26,6 +31,11 @@ func (m *mockRepo) Update(ctx context.Context, in entity.TodoItem) error {
 	return args.Error(0)
 }
 
+func (m *mockRepo) Upsert(ctx context.Context, in entity.TodoItem) (entity.TodoItem, bool, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(entity.TodoItem), args.Bool(1), args.Error(2)
+}
+
 func (m *mockRepo) FindByIds(ctx context.Context, ids []string) ([]entity.TodoItem, error) {
 	args := m.Called(ctx, ids)
 	return args.Get(0).([]entity.TodoItem), args.Error(1)
@@ -36,26 +46,48 @@ func (m *mockRepo) FindByIdOrEmpty(ctx context.Context, id string) (entity.TodoI
 	return args.Get(0).(entity.TodoItem), args.Error(1)
 }
 
-func (m *mockRepo) Purge(ctx context.Context, id string) error {
+func (m *mockRepo) Purge(ctx context.Context, id string) (entity.TodoItem, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
+	return args.Get(0).(entity.TodoItem), args.Error(1)
 }
 
-func (m *mockRepo) Delete(ctx context.Context, id string) error {
+func (m *mockRepo) Delete(ctx context.Context, id string) (entity.TodoItem, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
+	return args.Get(0).(entity.TodoItem), args.Error(1)
+}
+
+func (m *mockRepo) FilterFind(ctx context.Context, criteria todo.Criteria, order []todo.Order, limit int, offset int) ([]entity.TodoItem, error) {
+	args := m.Called(ctx, criteria, order, limit, offset)
+	return args.Get(0).([]entity.TodoItem), args.Error(1)
 }
 
-func (m *mockRepo) FilterFind(ctx context.Context, query []any, order string, limit int, offset int) ([]entity.TodoItem, error) {
-	args := m.Called(ctx, query, order, limit, offset)
+func (m *mockRepo) FilterCount(ctx context.Context, criteria todo.Criteria) (int64, error) {
+	args := m.Called(ctx, criteria)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRepo) FilterSeek(ctx context.Context, criteria todo.Criteria, seek todo.Seek, limit int) ([]entity.TodoItem, error) {
+	args := m.Called(ctx, criteria, seek, limit)
 	return args.Get(0).([]entity.TodoItem), args.Error(1)
 }
 
-func (m *mockRepo) FilterCount(ctx context.Context, query []any) (int64, error) {
-	args := m.Called(ctx, query)
+func (m *mockRepo) EstimateCount(ctx context.Context, criteria todo.Criteria) (int64, error) {
+	args := m.Called(ctx, criteria)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *mockRepo) FindInBatches(ctx context.Context, criteria todo.Criteria, batchSize int, fc func(batch []entity.TodoItem) error) error {
+	args := m.Called(ctx, criteria, batchSize, fc)
+	return args.Error(0)
+}
+
+// mockUnitOfWork hands fn the caller's ctx, so expectations on it still match
+type mockUnitOfWork struct{}
+
+func (mockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestCreate_Success(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepo)
@@ -66,6 +98,7 @@ func TestCreate_Success(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
@@ -78,6 +111,33 @@ func TestCreate_Success(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestCreate_Owner(t *testing.T) {
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	owner := "alice"
+	ctx := context.WithValue(context.Background(), middleware.UserReferenceIdKey, owner)
+	other := "mallory"
+	item := entity.TodoItem{Description: "test", DueDate: "2025-01-01", UserReferenceId: &other}
+	owned := item
+	owned.UserReferenceId = &owner
+	repo.On("Create", ctx, owned).Return(owned, nil)
+
+	res, err := service.Create(ctx, item)
+	assert.NoError(t, err)
+	assert.Equal(t, owner, *res.UserReferenceId)
+	repo.AssertExpectations(t)
+}
+
 func TestCreate_ValidationError(t *testing.T) {
 	ctx := context.Background()
 	repo := new(mockRepo)
@@ -88,6 +148,7 @@ func TestCreate_ValidationError(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
@@ -109,6 +170,7 @@ func TestCreate_RepoError(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
@@ -131,6 +193,7 @@ func TestGetByIdOrEmpty_EmptyId(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
@@ -150,6 +213,7 @@ func TestGetByIdOrEmpty_Success(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
@@ -171,10 +235,11 @@ func TestDelete_EmptyId(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
-	err = service.Delete(ctx, "")
+	_, err = service.Delete(ctx, "")
 	assert.Error(t, err)
 	assert.IsType(t, &appErr.Error{}, err)
 }
@@ -189,11 +254,243 @@ func TestDelete_Success(t *testing.T) {
 	)
 	service := NewTodoItemService(TodoItemConfig{
 		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	item := entity.TodoItem{Description: "test"}
+	item.Id = uuid.New()
+	repo.On("Delete", ctx, item.Id.String()).Return(item, nil)
+
+	res, err := service.Delete(ctx, item.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, item, res)
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	// unknown and deleted items are not removed again
+	repo.On("Delete", ctx, "123").Return(entity.TodoItem{}, nil)
+	repo.On("Purge", ctx, "123").Return(entity.TodoItem{}, nil)
+
+	_, err := service.Delete(ctx, "123")
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.ENotFound, e.Class)
+
+	_, err = service.Purge(ctx, "123")
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.ENotFound, e.Class)
+}
+
+func TestList_Success(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	log, err := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	expected := []entity.TodoItem{{Description: "test", DueDate: "2025-01-01"}}
+	criteria := todo.In{Field: todo.FieldId, Values: []any{"123"}}
+	order := []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}, {Field: todo.FieldId, Desc: true}}
+	repo.On("FilterFind", ctx, criteria, order, 12, 12).Return(expected, nil)
+	repo.On("FilterCount", ctx, criteria).Return(int64(13), nil)
+
+	res, count, err := service.List(ctx, []string{"123"}, request.Portion{Limit: 12, Offset: 12})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res)
+	assert.Equal(t, int64(13), count)
+}
+
+func TestListByCursor(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	log, err := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]entity.TodoItem, 3)
+	for i := range items {
+		items[i].Id = uuid.New()
+		items[i].CreatedAt = base.Add(-time.Duration(i) * time.Hour)
+	}
+
+	// the first page has a row more than asked for, so a next page follows
+	repo.On("FilterSeek", ctx, nil, todo.Seek{Field: todo.FieldCreatedAt, Desc: true}, 3).Return(items, nil).Once()
+	res, page, err := service.ListByCursor(ctx, nil, request.Keyset{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, items[:2], res)
+	assert.Empty(t, page.PrevCursor)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Nil(t, page.Count)
+
+	// the next page starts after the last item
+	next := todo.Seek{Field: todo.FieldCreatedAt, Desc: true, Key: items[1].CreatedAt, Id: items[1].Id.String()}
+	repo.On("FilterSeek", ctx, nil, next, 3).Return(items[2:], nil).Once()
+	repo.On("FilterCount", ctx, nil).Return(int64(3), nil).Once()
+	res, page, err = service.ListByCursor(ctx, nil, request.Keyset{Cursor: page.NextCursor, Limit: 2, Count: request.CountExact})
+	assert.NoError(t, err)
+	assert.Equal(t, items[2:], res)
+	assert.Empty(t, page.NextCursor)
+	assert.NotEmpty(t, page.PrevCursor)
+	assert.Equal(t, int64(3), *page.Count)
+	assert.False(t, page.Estimated)
+
+	// going back reads ascending from the first item and reverses
+	prev := todo.Seek{Field: todo.FieldCreatedAt, Key: items[2].CreatedAt, Id: items[2].Id.String()}
+	repo.On("FilterSeek", ctx, nil, prev, 3).Return([]entity.TodoItem{items[1], items[0]}, nil).Once()
+	res, page, err = service.ListByCursor(ctx, nil, request.Keyset{Cursor: page.PrevCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, items[:2], res)
+	assert.Empty(t, page.PrevCursor)
+	assert.NotEmpty(t, page.NextCursor)
+
+	_, _, err = service.ListByCursor(ctx, nil, request.Keyset{Cursor: "not a cursor", Limit: 2})
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.EValidation, e.Class)
+
+	repo.AssertExpectations(t)
+}
+
+func TestCountOverdue(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
 		TodoItemRepo: repo,
 	})
 
-	repo.On("Delete", ctx, "123").Return(nil)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	overdue := todo.And{todo.Range{Field: todo.FieldDueDate, To: now}, todo.Null{Field: todo.FieldCompletedAt}}
+	repo.On("FilterCount", ctx, overdue).Return(int64(4), nil).Once()
+	repo.On("FilterCount", ctx, overdue).Return(int64(0), errors.New("db down")).Once()
 
-	err = service.Delete(ctx, "123")
+	count, err := service.CountOverdue(ctx, now)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	_, err = service.CountOverdue(ctx, now)
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.EConflict, e.Class)
+
+	repo.AssertExpectations(t)
+}
+
+func TestComplete(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	item := entity.TodoItem{Description: "Task", DueDate: "2025-03-01"}
+	item.Id = uuid.New()
+	repo.On("FindByIdOrEmpty", ctx, item.Id.String()).Return(item, nil).Once()
+	repo.On("Update", ctx, mock.MatchedBy(func(in entity.TodoItem) bool { return in.Done() })).Return(nil).Once()
+
+	res, err := service.Complete(ctx, item.Id.String(), true)
+	assert.NoError(t, err)
+	assert.True(t, res.Done())
+
+	// reopening an open item changes nothing
+	repo.On("FindByIdOrEmpty", ctx, item.Id.String()).Return(item, nil).Once()
+	res, err = service.Complete(ctx, item.Id.String(), false)
+	assert.NoError(t, err)
+	assert.False(t, res.Done())
+
+	repo.On("FindByIdOrEmpty", ctx, "missing").Return(entity.TodoItem{}, nil).Once()
+	_, err = service.Complete(ctx, "missing", true)
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.ENotFound, e.Class)
+
+	repo.AssertExpectations(t)
+}
+
+func TestExport_Owner(t *testing.T) {
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	ctx := context.WithValue(context.Background(), middleware.UserReferenceIdKey, "alice")
+	repo.On("FindInBatches", ctx, todo.Eq{Field: todo.FieldUserReferenceId, Value: "alice"}, 10, mock.Anything).Return(nil)
+	repo.On("FindInBatches", context.Background(), todo.Null{Field: todo.FieldUserReferenceId}, 10, mock.Anything).Return(nil)
+
+	assert.NoError(t, service.Export(ctx, 10, func(batch []entity.TodoItem) error { return nil }))
+	assert.NoError(t, service.Export(context.Background(), 10, func(batch []entity.TodoItem) error { return nil }))
+	repo.AssertExpectations(t)
+}
+
+func TestUpsert_OwnedByAnother(t *testing.T) {
+	repo := new(mockRepo)
+	log, _ := logger.New(
+		"local",
+		"todoapp",
+		"todoapp",
+	)
+	service := NewTodoItemService(TodoItemConfig{
+		Logger:       log,
+		UnitOfWork:   mockUnitOfWork{},
+		TodoItemRepo: repo,
+	})
+
+	item := entity.TodoItem{Description: "test", DueDate: "2025-01-01"}
+	item.Id = uuid.New()
+	repo.On("Upsert", context.Background(), item).Return(entity.TodoItem{}, false, todo.ErrOwnedByAnother)
+
+	_, _, err := service.Upsert(context.Background(), item)
+	var e *appErr.Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, appErr.EAccess, e.Class)
+	repo.AssertExpectations(t)
 }
@@ -0,0 +1,75 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	todoInterface "github.com/thealiakbari/todoapp/internal/ports/inbound/todo"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+)
+
+type TodoItemStreamConfig struct {
+	Logger          logger.Logger
+	TodoItemEvent   todo.TodoItemEventBroker
+	TodoItemMetrics todo.TodoItemMetrics
+}
+
+type todoItemStreamService struct {
+	TodoItemStreamConfig
+}
+
+func NewTodoItemStreamService(config TodoItemStreamConfig) todoInterface.TodoItemStreamService {
+	s := todoItemStreamService{config}
+	s.Logger = config.Logger.ForService(s)
+	return s
+}
+
+// Notify must be called once the change is committed, it counts the change and publishes it.
+// A failed publish is only logged because the change itself has already been persisted.
+func (s todoItemStreamService) Notify(ctx context.Context, eventType entity.TodoItemEventType, item entity.TodoItem) {
+	s.TodoItemMetrics.Changed(eventType)
+
+	_, err := s.TodoItemEvent.Publish(ctx, entity.TodoItemEvent{
+		Type: eventType,
+		Item: item,
+	})
+	if err != nil {
+		s.Logger.Warnf(ctx, "Cannot publish todo item %s event: %v", eventType, err)
+	}
+}
+
+// Subscribe streams the changes of the items of the user of ctx that match filter, a subscriber
+// without a user gets the changes of the items created without one
+func (s todoItemStreamService) Subscribe(ctx context.Context, filter entity.TodoItemEventFilter, lastEventId uint64) (res <-chan entity.TodoItemEvent, err error) {
+	filter.Owner = nil
+	if userReferenceId, err := middleware.GetUserReferenceId(ctx); err == nil {
+		filter.Owner = &userReferenceId
+	}
+
+	events, err := s.TodoItemEvent.Subscribe(ctx, lastEventId)
+	if err != nil {
+		streamErr := CodeTodoItemStreamUnavailable.New(err)
+		streamErr.IsTemp = true
+		return nil, streamErr
+	}
+
+	filtered := make(chan entity.TodoItemEvent)
+	go func() {
+		defer close(filtered)
+		for event := range events {
+			if !filter.Match(event) {
+				continue
+			}
+
+			select {
+			case filtered <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return filtered, nil
+}
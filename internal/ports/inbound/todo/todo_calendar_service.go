@@ -0,0 +1,14 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+type TodoItemCalendarService interface {
+	CreateFeed(ctx context.Context) (res entity.TodoItemFeed, token string, err error)
+	RevokeFeed(ctx context.Context, id string) (err error)
+	GetFeedItems(ctx context.Context, token string) (res []entity.TodoItem, err error)
+	Import(ctx context.Context, uid string, item entity.TodoItem) (res entity.TodoItem, created bool, err error)
+}
@@ -2,14 +2,27 @@ package todo
 
 import (
 	"context"
+	"time"
 
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/pkg/common/request"
 )
 
 type TodoItemService interface {
 	Create(ctx context.Context, entity entity.TodoItem) (res entity.TodoItem, err error)
 	Update(ctx context.Context, entity entity.TodoItem) (res entity.TodoItem, err error)
+	// Complete marks the item done, or open again when done is false
+	Complete(ctx context.Context, id string, done bool) (res entity.TodoItem, err error)
 	GetByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error)
-	Delete(ctx context.Context, id string) (err error)
-	Purge(ctx context.Context, id string) (err error)
+	List(ctx context.Context, ids []string, portion request.Portion) (res []entity.TodoItem, count int64, err error)
+	ListByCursor(ctx context.Context, ids []string, keyset request.Keyset) (res []entity.TodoItem, page request.KeysetPage, err error)
+	// Delete soft deletes the item and returns it, an unknown or deleted item is not found
+	Delete(ctx context.Context, id string) (res entity.TodoItem, err error)
+	// Purge removes the item, deleted or not, and returns it
+	Purge(ctx context.Context, id string) (res entity.TodoItem, err error)
+	// Upsert updates the item when its id exists and creates it, keeping a preset id, otherwise
+	Upsert(ctx context.Context, entity entity.TodoItem) (res entity.TodoItem, created bool, err error)
+	Export(ctx context.Context, batchSize int, fc func(batch []entity.TodoItem) error) (err error)
+	// CountOverdue counts the open items due at or before now
+	CountOverdue(ctx context.Context, now time.Time) (count int64, err error)
 }
@@ -0,0 +1,12 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+type TodoItemStreamService interface {
+	Notify(ctx context.Context, eventType entity.TodoItemEventType, item entity.TodoItem)
+	Subscribe(ctx context.Context, filter entity.TodoItemEventFilter, lastEventId uint64) (res <-chan entity.TodoItemEvent, err error)
+}
@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache keeps encoded values under string keys, an expired or evicted key reads as missing
+type Cache interface {
+	// GetMany returns the values found for keys, missing keys are left out of res
+	GetMany(ctx context.Context, keys []string) (res map[string][]byte, err error)
+	SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) (err error)
+	Delete(ctx context.Context, keys ...string) (err error)
+}
@@ -0,0 +1,188 @@
+package todo
+
+import (
+	"fmt"
+
+	"github.com/thealiakbari/todoapp/pkg/common/request"
+)
+
+// Field is a todo item column that criteria, orders and seeks refer to
+type Field string
+
+const (
+	FieldId          Field = "id"
+	FieldDescription Field = "description"
+	FieldDueDate     Field = "due_date"
+	FieldCreatedAt   Field = "created_at"
+	FieldUpdatedAt   Field = "updated_at"
+	FieldCompletedAt Field = "completed_at"
+	// FieldUserReferenceId is the owner of an item, items created without a user have none
+	FieldUserReferenceId Field = "user_reference_id"
+)
+
+// Valid reports whether f is a todo item field, adapters reject criteria on anything else
+func (f Field) Valid() bool {
+	switch f {
+	case FieldId, FieldDescription, FieldDueDate, FieldCreatedAt, FieldUpdatedAt, FieldCompletedAt, FieldUserReferenceId:
+		return true
+	default:
+		return false
+	}
+}
+
+// Criteria selects todo items, every adapter translates it to its own storage. A nil Criteria
+// selects every item. Soft deleted items are never selected.
+type Criteria interface {
+	criteria()
+}
+
+// Eq matches items whose Field equals Value
+type Eq struct {
+	Field Field
+	Value any
+}
+
+// In matches items whose Field equals one of Values, no values match nothing
+type In struct {
+	Field  Field
+	Values []any
+}
+
+// Range matches items whose Field is between From and To, both inclusive. A nil bound is open.
+type Range struct {
+	Field Field
+	From  any
+	To    any
+}
+
+// Contains matches items whose Field contains Text, ignoring case
+type Contains struct {
+	Field Field
+	Text  string
+}
+
+// Null matches items without a Field value
+type Null struct {
+	Field Field
+}
+
+// And matches items every criteria matches, an empty And matches everything
+type And []Criteria
+
+// Or matches items any criteria matches, an empty Or matches nothing
+type Or []Criteria
+
+// Not matches items Criteria does not match
+type Not struct {
+	Criteria Criteria
+}
+
+func (Eq) criteria()       {}
+func (In) criteria()       {}
+func (Range) criteria()    {}
+func (Contains) criteria() {}
+func (Null) criteria()     {}
+func (And) criteria()      {}
+func (Or) criteria()       {}
+func (Not) criteria()      {}
+
+// InValues builds an In from a typed slice
+func InValues[T any](field Field, values []T) In {
+	res := In{Field: field, Values: make([]any, len(values))}
+	for i, v := range values {
+		res.Values[i] = v
+	}
+
+	return res
+}
+
+// DateRange builds a Range from the bounds of a request filter
+func DateRange(field Field, r request.DateRange) Range {
+	res := Range{Field: field}
+	if r.From != nil {
+		res.From = *r.From
+	}
+	if r.To != nil {
+		res.To = *r.To
+	}
+
+	return res
+}
+
+// NumberRange builds a Range from the bounds of a request filter
+func NumberRange(field Field, r request.NumberRange) Range {
+	res := Range{Field: field}
+	if r.From != nil {
+		res.From = *r.From
+	}
+	if r.To != nil {
+		res.To = *r.To
+	}
+
+	return res
+}
+
+// Order sorts by Field, items are ordered by id when no order is given
+type Order struct {
+	Field Field
+	Desc  bool
+}
+
+// Seek positions a keyset page: items ordered by (Field, id), descending when Desc, starting
+// strictly after (Key, Id) when Key is set
+type Seek struct {
+	Field Field
+	Desc  bool
+	Key   any
+	Id    string
+}
+
+// CheckCriteria rejects unknown fields before an adapter translates c
+func CheckCriteria(c Criteria) error {
+	switch c := c.(type) {
+	case nil:
+		return nil
+	case Eq:
+		return checkField(c.Field)
+	case In:
+		return checkField(c.Field)
+	case Range:
+		return checkField(c.Field)
+	case Contains:
+		return checkField(c.Field)
+	case Null:
+		return checkField(c.Field)
+	case And:
+		return checkAll(c)
+	case Or:
+		return checkAll(c)
+	case Not:
+		if c.Criteria == nil {
+			return fmt.Errorf("not needs a criteria")
+		}
+		return CheckCriteria(c.Criteria)
+	default:
+		return fmt.Errorf("unsupported criteria %T", c)
+	}
+}
+
+func checkAll(all []Criteria) error {
+	for _, c := range all {
+		if c == nil {
+			return fmt.Errorf("nil criteria in a composition")
+		}
+		if err := CheckCriteria(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkField(f Field) error {
+	if !f.Valid() {
+		return fmt.Errorf("unknown field %q", f)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+type TodoItemEventBroker interface {
+	// Publish assigns the event its sequence id and fans it out to subscribers
+	Publish(ctx context.Context, event entity.TodoItemEvent) (res entity.TodoItemEvent, err error)
+	// Subscribe replays the retained events after `lastEventId` and then streams new ones,
+	// the channel is closed when ctx is done, the subscriber falls behind or the broker is closed
+	Subscribe(ctx context.Context, lastEventId uint64) (res <-chan entity.TodoItemEvent, err error)
+	Close() (err error)
+}
@@ -0,0 +1,14 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+type TodoItemFeedRepository interface {
+	Create(ctx context.Context, in entity.TodoItemFeed) (res entity.TodoItemFeed, err error)
+	FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItemFeed, err error)
+	FindByTokenHashOrEmpty(ctx context.Context, tokenHash string) (res entity.TodoItemFeed, err error)
+	Delete(ctx context.Context, id string) (err error)
+}
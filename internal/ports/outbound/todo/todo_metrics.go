@@ -0,0 +1,15 @@
+package todo
+
+import (
+	"context"
+
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+)
+
+type TodoItemMetrics interface {
+	// Changed counts a committed change of an item
+	Changed(eventType entity.TodoItemEventType)
+	// WatchOverdue reports what count returns as the number of overdue items, it is called
+	// whenever the metrics are read
+	WatchOverdue(count func(ctx context.Context) (int64, error))
+}
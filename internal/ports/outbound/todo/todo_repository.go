@@ -2,17 +2,35 @@ package todo
 
 import (
 	"context"
+	"errors"
 
 	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
 )
 
+// ErrOwnedByAnother is returned by Upsert when the item with the id belongs to another owner
+var ErrOwnedByAnother = errors.New("the todo item belongs to another user")
+
 type TodoItemRepository interface {
 	Create(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, err error)
 	Update(ctx context.Context, in entity.TodoItem) (err error)
+	// Upsert creates the item or updates the one with its id, a soft deleted one is restored.
+	// created tells which of the two happened. An item of another owner is left as it is and
+	// ErrOwnedByAnother returned.
+	Upsert(ctx context.Context, in entity.TodoItem) (res entity.TodoItem, created bool, err error)
 	FindByIds(ctx context.Context, ids []string) (res []entity.TodoItem, err error)
 	FindByIdOrEmpty(ctx context.Context, id string) (res entity.TodoItem, err error)
-	Purge(ctx context.Context, id string) (err error)
-	Delete(ctx context.Context, id string) (err error)
-	FilterFind(ctx context.Context, query []any, order string, limit int, offset int) (res []entity.TodoItem, err error)
-	FilterCount(ctx context.Context, query []any) (res int64, err error)
+	// Purge removes the item, deleted or not, and returns it. res is empty when there was none.
+	Purge(ctx context.Context, id string) (res entity.TodoItem, err error)
+	// Delete soft deletes the item and returns it. res is empty when there was none or it is
+	// deleted already.
+	Delete(ctx context.Context, id string) (res entity.TodoItem, err error)
+	FilterFind(ctx context.Context, criteria Criteria, order []Order, limit int, offset int) (res []entity.TodoItem, err error)
+	FilterCount(ctx context.Context, criteria Criteria) (res int64, err error)
+	// FilterSeek reads a keyset page, the rows after the seek position in its order
+	FilterSeek(ctx context.Context, criteria Criteria, seek Seek, limit int) (res []entity.TodoItem, err error)
+	// EstimateCount is FilterCount where the storage can answer it cheaper, it may be off
+	EstimateCount(ctx context.Context, criteria Criteria) (res int64, err error)
+	// FindInBatches walks the items matching criteria in primary key order, `fc` must not keep the
+	// batch slice
+	FindInBatches(ctx context.Context, criteria Criteria, batchSize int, fc func(batch []entity.TodoItem) error) (err error)
 }
@@ -0,0 +1,270 @@
+// Package todotest holds the conformance suite every todo.TodoItemRepository adapter runs, so the
+// adapters agree on what the criteria model means
+package todotest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/internal/domain/todo/entity"
+	"github.com/thealiakbari/todoapp/internal/ports/outbound/todo"
+	"github.com/thealiakbari/todoapp/pkg/common/request"
+)
+
+// TestTodoItemRepository runs the suite on the repository of newRepo. Its items get a random tag
+// in their description and every query is scoped to it, so the storage may hold other items.
+func TestTodoItemRepository(t *testing.T, newRepo func(t *testing.T) todo.TodoItemRepository) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	tag := "conformance-" + uuid.NewString()[:8]
+	scope := todo.Contains{Field: todo.FieldDescription, Text: tag}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	owner := tag + "-owner"
+	seed := []struct {
+		name        string
+		description string
+		dueDate     string
+		createdAt   time.Time
+		owner       *string
+	}{
+		{"milk", "Buy milk", "2025-01-05T00:00:00Z", base, nil},
+		{"bread", "buy BREAD", "2025-02-10T12:00:00Z", base.Add(time.Hour), &owner},
+		{"support", "Call 100% support_desk", "2025-03-01T00:00:00Z", base.Add(time.Hour), nil},
+		{"report", "Write report", "2025-03-15T08:30:00Z", base.Add(2 * time.Hour), &owner},
+		{"deleted", "Buy nothing", "2025-01-01T00:00:00Z", base, &owner},
+	}
+
+	ids := map[string]string{}
+	names := map[string]string{}
+	for _, s := range seed {
+		item := entity.TodoItem{Description: tag + " " + s.description, DueDate: s.dueDate, UserReferenceId: s.owner}
+		item.CreatedAt = s.createdAt
+		item.UpdatedAt = s.createdAt
+		created, err := repo.Create(ctx, item)
+		require.NoError(t, err)
+		ids[s.name] = created.Id.String()
+		names[created.Id.String()] = s.name
+	}
+	_, err := repo.Delete(ctx, ids["deleted"])
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for _, id := range ids {
+			_, _ = repo.Purge(ctx, id)
+		}
+	})
+
+	itemNames := func(items []entity.TodoItem) []string {
+		res := make([]string, len(items))
+		for i, item := range items {
+			res[i] = names[item.Id.String()]
+		}
+		return res
+	}
+	date := func(s string) *time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return &d
+	}
+	description := func(s string) todo.Eq {
+		return todo.Eq{Field: todo.FieldDescription, Value: tag + " " + s}
+	}
+
+	cases := []struct {
+		name     string
+		criteria todo.Criteria
+		want     []string
+	}{
+		{"everything", nil, []string{"milk", "bread", "support", "report"}},
+		{"eq", description("Write report"), []string{"report"}},
+		{"eq is case sensitive", description("write report"), nil},
+		{"in", todo.InValues(todo.FieldId, []string{ids["milk"], ids["support"], ids["deleted"]}), []string{"milk", "support"}},
+		{"in nothing", todo.InValues(todo.FieldId, []string{}), nil},
+		{"date range", todo.DateRange(todo.FieldDueDate, request.DateRange{From: date("2025-02-01T00:00:00Z"), To: date("2025-03-01T00:00:00Z")}), []string{"bread", "support"}},
+		{"date range from", todo.DateRange(todo.FieldDueDate, request.DateRange{From: date("2025-03-01T00:00:00Z")}), []string{"support", "report"}},
+		{"date range to", todo.DateRange(todo.FieldCreatedAt, request.DateRange{To: date("2025-01-01T01:00:00Z")}), []string{"milk", "bread", "support"}},
+		{"open range", todo.DateRange(todo.FieldDueDate, request.DateRange{}), []string{"milk", "bread", "support", "report"}},
+		{"eq owner", todo.Eq{Field: todo.FieldUserReferenceId, Value: owner}, []string{"bread", "report"}},
+		{"null owner", todo.Null{Field: todo.FieldUserReferenceId}, []string{"milk", "support"}},
+		{"contains ignores case", todo.Contains{Field: todo.FieldDescription, Text: "BUY"}, []string{"milk", "bread"}},
+		{"contains takes % literally", todo.Contains{Field: todo.FieldDescription, Text: "100%"}, []string{"support"}},
+		{"contains takes _ literally", todo.Contains{Field: todo.FieldDescription, Text: "_"}, []string{"support"}},
+		{"or", todo.Or{todo.Contains{Field: todo.FieldDescription, Text: "milk"}, description("Write report")}, []string{"milk", "report"}},
+		{"not", todo.Not{Criteria: todo.Contains{Field: todo.FieldDescription, Text: "buy"}}, []string{"support", "report"}},
+		{"and", todo.And{
+			todo.DateRange(todo.FieldDueDate, request.DateRange{From: date("2025-02-01T00:00:00Z")}),
+			todo.Not{Criteria: todo.InValues(todo.FieldId, []string{ids["report"]})},
+		}, []string{"bread", "support"}},
+		{"nested", todo.Or{
+			todo.And{
+				todo.Contains{Field: todo.FieldDescription, Text: "buy"},
+				todo.DateRange(todo.FieldDueDate, request.DateRange{To: date("2025-01-31T00:00:00Z")}),
+			},
+			todo.Contains{Field: todo.FieldDescription, Text: "report"},
+		}, []string{"milk", "report"}},
+		{"empty and", todo.And{}, []string{"milk", "bread", "support", "report"}},
+		{"empty or", todo.Or{}, nil},
+		{"not empty or", todo.Not{Criteria: todo.Or{}}, []string{"milk", "bread", "support", "report"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			criteria := todo.And{scope}
+			if c.criteria != nil {
+				criteria = append(criteria, c.criteria)
+			}
+
+			res, err := repo.FilterFind(ctx, criteria, nil, 10, 0)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, c.want, itemNames(res))
+
+			count, err := repo.FilterCount(ctx, criteria)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(c.want)), count)
+
+			count, err = repo.EstimateCount(ctx, criteria)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(c.want)), count, "filtered estimates are exact")
+		})
+	}
+
+	t.Run("order and portion", func(t *testing.T) {
+		res, err := repo.FilterFind(ctx, scope, []todo.Order{{Field: todo.FieldDueDate, Desc: true}}, 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"support", "bread"}, itemNames(res))
+
+		// bread and support were created at the same time, the id orders them
+		res, err = repo.FilterFind(ctx, scope, []todo.Order{{Field: todo.FieldCreatedAt}, {Field: todo.FieldId}}, 10, 0)
+		require.NoError(t, err)
+		tied := []string{ids["bread"], ids["support"]}
+		slices.Sort(tied)
+		assert.Equal(t, []string{"milk", names[tied[0]], names[tied[1]], "report"}, itemNames(res))
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		all, err := repo.FilterFind(ctx, scope, []todo.Order{{Field: todo.FieldCreatedAt, Desc: true}, {Field: todo.FieldId, Desc: true}}, 10, 0)
+		require.NoError(t, err)
+
+		var walked []entity.TodoItem
+		seek := todo.Seek{Field: todo.FieldCreatedAt, Desc: true}
+		for range len(all) + 1 {
+			res, err := repo.FilterSeek(ctx, scope, seek, 3)
+			require.NoError(t, err)
+			if len(res) == 0 {
+				break
+			}
+			walked = append(walked, res...)
+			last := res[len(res)-1]
+			seek.Key, seek.Id = last.CreatedAt, last.Id.String()
+		}
+		assert.Equal(t, itemNames(all), itemNames(walked))
+
+		// ascending from the last item reads back towards the first
+		seek = todo.Seek{Field: todo.FieldCreatedAt, Key: all[3].CreatedAt, Id: all[3].Id.String()}
+		res, err := repo.FilterSeek(ctx, scope, seek, 10)
+		require.NoError(t, err)
+		assert.Equal(t, itemNames([]entity.TodoItem{all[2], all[1], all[0]}), itemNames(res))
+	})
+
+	t.Run("find in batches", func(t *testing.T) {
+		var batches [][]string
+		err := repo.FindInBatches(ctx, todo.And{scope, todo.Eq{Field: todo.FieldUserReferenceId, Value: owner}}, 1, func(batch []entity.TodoItem) error {
+			batches = append(batches, itemNames(batch))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, [][]string{{"bread"}, {"report"}}, batches)
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		id := uuid.New()
+		t.Cleanup(func() { _, _ = repo.Purge(ctx, id.String()) })
+
+		item := entity.TodoItem{Description: tag + " Upserted", DueDate: "2025-04-01T00:00:00Z"}
+		item.Id = id
+		res, created, err := repo.Upsert(ctx, item)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, id, res.Id)
+
+		_, err = repo.Delete(ctx, id.String())
+		require.NoError(t, err)
+
+		// the deleted item is restored instead of inserted a second time
+		item.Description = tag + " Restored"
+		res, created, err = repo.Upsert(ctx, item)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, tag+" Restored", res.Description)
+
+		count, err := repo.FilterCount(ctx, todo.And{scope, todo.Eq{Field: todo.FieldId, Value: id.String()}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// the item of another owner, deleted or not, is neither updated nor restored
+		_, err = repo.Delete(ctx, id.String())
+		require.NoError(t, err)
+		other := item
+		other.Description = tag + " Taken over"
+		other.UserReferenceId = &owner
+		_, _, err = repo.Upsert(ctx, other)
+		assert.ErrorIs(t, err, todo.ErrOwnedByAnother)
+
+		count, err = repo.FilterCount(ctx, todo.And{scope, todo.Eq{Field: todo.FieldId, Value: id.String()}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("delete and purge", func(t *testing.T) {
+		item := entity.TodoItem{Description: tag + " Removed", DueDate: "2025-04-01T00:00:00Z", UserReferenceId: &owner}
+		created, err := repo.Create(ctx, item)
+		require.NoError(t, err)
+		id := created.Id.String()
+		t.Cleanup(func() { _, _ = repo.Purge(ctx, id) })
+
+		// the removed item is returned, removing it again returns none
+		res, err := repo.Delete(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, created.Id, res.Id)
+		assert.Equal(t, &owner, res.UserReferenceId)
+
+		res, err = repo.Delete(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, res.Id)
+
+		// a deleted item is purged as well
+		res, err = repo.Purge(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, created.Id, res.Id)
+		assert.Equal(t, &owner, res.UserReferenceId)
+
+		res, err = repo.Purge(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, res.Id)
+
+		res, err = repo.Delete(ctx, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, res.Id)
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		_, err := repo.FilterFind(ctx, todo.Eq{Field: "owner", Value: "a"}, nil, 10, 0)
+		assert.Error(t, err)
+
+		_, err = repo.FilterFind(ctx, todo.Not{Criteria: todo.Or{todo.Contains{Field: "owner"}}}, nil, 10, 0)
+		assert.Error(t, err)
+
+		_, err = repo.FilterFind(ctx, scope, []todo.Order{{Field: "owner"}}, 10, 0)
+		assert.Error(t, err)
+
+		_, err = repo.FilterSeek(ctx, scope, todo.Seek{Field: "owner"}, 10)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,12 @@
+package transaction
+
+import (
+	"context"
+)
+
+type UnitOfWork interface {
+	// Do runs fn as one unit, the repositories called with the ctx it is given share a transaction
+	// that is committed when fn returns nil and rolled back otherwise. A Do inside fn joins the same
+	// transaction and only rolls back its own changes when it fails.
+	Do(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
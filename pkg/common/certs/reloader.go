@@ -0,0 +1,188 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+const (
+	ClientAuthRequire       = "require"
+	ClientAuthVerifyIfGiven = "verify_if_given"
+)
+
+// defaultReloadInterval is how often the files are checked without a configured interval
+const defaultReloadInterval = time.Minute
+
+// Reloader serves the certificate of the TLS config, and verifies the client certificates with
+// its CAs, read again from their files when they change, so they are renewed without a restart
+type Reloader struct {
+	conf       config.TLS
+	minVersion uint16
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTime   time.Time
+}
+
+// New reads the files of conf, it is nil when conf has no certificate
+func New(conf config.TLS) (*Reloader, error) {
+	if conf.CertFile == "" && conf.KeyFile == "" {
+		return nil, nil
+	}
+
+	r := &Reloader{conf: conf}
+	switch conf.MinVersion {
+	case "", "1.2":
+		r.minVersion = tls.VersionTLS12
+	case "1.3":
+		r.minVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported tls min version %q", conf.MinVersion)
+	}
+	switch conf.ClientAuth {
+	case "", ClientAuthRequire:
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	case ClientAuthVerifyIfGiven:
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unknown tls client auth %q", conf.ClientAuth)
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config serves the certificate, and asks the clients for theirs when there are client CAs
+func (r *Reloader) Config() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: r.minVersion,
+		// the configs of the clients are not the one http.Server adds h2 to
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.conf.ClientCAFile == "" {
+		return cfg
+	}
+
+	cfg.ClientAuth = r.clientAuth
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		client := cfg.Clone()
+		client.GetConfigForClient = nil
+
+		r.mu.RLock()
+		client.ClientCAs = r.clientCAs
+		r.mu.RUnlock()
+		return client, nil
+	}
+
+	return cfg
+}
+
+// Reload reads the files again when one changed since they were read, it is true when they were.
+// The certificate in use is kept when they cannot be read, e.g. halfway through their renewal.
+func (r *Reloader) Reload() (bool, error) {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	return true, r.load()
+}
+
+// Watch reloads the files every reload interval until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	interval := defaultReloadInterval
+	if r.conf.ReloadInterval != "" {
+		interval = r.conf.ReloadInterval.Duration()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				log.Printf("Cannot reload the TLS certificate, the current one is kept: %v", err)
+			} else if reloaded {
+				log.Println("TLS certificate reloaded.")
+			}
+		}
+	}
+}
+
+func (r *Reloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load the tls certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.conf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read the tls client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("there is no certificate in %s", r.conf.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTime = modTime
+	return nil
+}
+
+// latestModTime is when the last of the files changed
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.conf.CertFile, r.conf.KeyFile, r.conf.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,112 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+// writeCert writes a self-signed certificate for name, with its files changed at modTime
+func writeCert(t *testing.T, certFile, keyFile, name string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func servedName(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+
+	cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.TLS{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+	}
+	modTime := time.Now().Add(-time.Minute)
+	writeCert(t, conf.CertFile, conf.KeyFile, "first", modTime)
+
+	r, err := New(conf)
+	require.NoError(t, err)
+	cfg := r.Config()
+	assert.Equal(t, "first", servedName(t, cfg))
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth, "without client CAs")
+
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "the files did not change")
+
+	writeCert(t, conf.CertFile, conf.KeyFile, "second", modTime.Add(time.Second))
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "second", servedName(t, cfg), "the config serves the reloaded certificate")
+
+	require.NoError(t, os.WriteFile(conf.KeyFile, []byte("halfway"), 0o600))
+	_, err = r.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "second", servedName(t, cfg), "the certificate in use is kept")
+}
+
+func TestNew(t *testing.T) {
+	r, err := New(config.TLS{})
+	require.NoError(t, err)
+	assert.Nil(t, r, "no certificate, no TLS")
+
+	dir := t.TempDir()
+	conf := config.TLS{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "tls.crt"),
+		MinVersion:   "1.3",
+	}
+	writeCert(t, conf.CertFile, conf.KeyFile, "ca", time.Now())
+
+	r, err = New(conf)
+	require.NoError(t, err)
+	cfg := r.Config()
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	client, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.NotNil(t, client.ClientCAs)
+
+	conf.MinVersion = "1.0"
+	_, err = New(conf)
+	assert.Error(t, err)
+}
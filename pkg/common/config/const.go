@@ -6,3 +6,19 @@ const (
 	ModeStage = "stage"
 	ModeProd  = "prod"
 )
+
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+const (
+	CacheRedis  = "redis"
+	CacheMemory = "memory"
+)
+
+const (
+	RateLimitRedis  = "redis"
+	RateLimitMemory = "memory"
+)
@@ -7,27 +7,161 @@ import (
 )
 
 type AppConfig struct {
-	ServiceName string   `yaml:"service_name"`
-	Language    string   `yaml:"language"`
-	Mode        string   `yaml:"mode"`
-	DB          DB       `mapstructure:"db"`
-	Services    Services `yaml:"services"`
-	Core        Core     `yaml:"core"`
+	ServiceName string    `yaml:"service_name"`
+	Language    string    `yaml:"language"`
+	Mode        string    `yaml:"mode"`
+	DB          DB        `mapstructure:"db"`
+	Services    Services  `yaml:"services"`
+	Core        Core      `yaml:"core"`
+	Tracing     Tracing   `mapstructure:"tracing"`
+	Redaction   Redaction `mapstructure:"redaction"`
+	Log         Log       `mapstructure:"log"`
 }
 
 type Core struct {
-	Http Http `mapstructure:"http"`
+	Http   Http   `mapstructure:"http"`
+	Admin  Admin  `mapstructure:"admin"`
+	Stream Stream `mapstructure:"stream"`
+	Health Health `mapstructure:"health"`
 }
 
 type Http struct {
 	Address string `yaml:"address"`
 	Port    uint16 `yaml:"port"`
 	Url     string `yaml:"url"`
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout of the connections, empty is no
+	// timeout. The streams, exports and imports are not cut by the read and write timeouts.
+	ReadTimeout       TimeDuration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout TimeDuration `mapstructure:"read_header_timeout"`
+	WriteTimeout      TimeDuration `mapstructure:"write_timeout"`
+	IdleTimeout       TimeDuration `mapstructure:"idle_timeout"`
+	// MaxHeaderBytes of a request, 1 MB when empty
+	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
+	// ShutdownTimeout is how long the requests in flight are waited for on shutdown, 5s when empty
+	ShutdownTimeout TimeDuration `mapstructure:"shutdown_timeout"`
+	TLS             TLS          `mapstructure:"tls"`
+	// RequestLog is what the request log keeps of each request
+	RequestLog RequestLog `mapstructure:"request_log"`
+	// Versions are the deprecations of the API versions, by version, e.g. `v1`
+	Versions map[string]ApiVersion `mapstructure:"versions"`
+	// TrustedProxies are the addresses or CIDRs whose `X-Forwarded-For` and `X-Real-IP` give the
+	// client IP, empty trusts no proxy
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// RateLimit limits the requests of the API
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+	// Idempotency replays the responses of the requests retried with their `Idempotency-Key`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+}
+
+// Idempotency keeps the responses of the POST, PUT, PATCH and DELETE requests with an
+// `Idempotency-Key` in the database, the memory storage keeps them in the process
+type Idempotency struct {
+	// TTL is how long a key and its response are kept, empty turns the keys off
+	TTL TimeDuration `mapstructure:"ttl"`
+	// LockTimeout is how long a request in progress holds its key, so the key of a request whose
+	// replica died is not held until it expires. Empty holds it until then.
+	LockTimeout TimeDuration `mapstructure:"lock_timeout"`
+}
+
+// RateLimit limits the requests of the API with token buckets, by route and identity
+type RateLimit struct {
+	// Store keeps the buckets, `memory` (each replica on its own) or `redis` (shared by the
+	// replicas), empty turns the limits off
+	Store string `mapstructure:"store"`
+	// Prefix of the Redis keys of the buckets
+	Prefix string `mapstructure:"prefix"`
+	// Rules are matched in order, the first one matching the route and method limits the request
+	Rules []RateLimitRule `mapstructure:"rules"`
+}
+
+type RateLimitRule struct {
+	// Name tells the buckets of the rules apart, the rules of a name share them
+	Name string `mapstructure:"name"`
+	// Route is the prefix of the matched routes, e.g. `/api/v1/todo-items/import`, empty matches all
+	Route string `mapstructure:"route"`
+	// Methods matched, empty matches all
+	Methods []string `mapstructure:"methods"`
+	// Limits by identity, `user` (user reference id), `api_key` (API key id) or `ip`. The request
+	// is limited by the first of its identities with a limit, in this order. Users and API keys are
+	// only known once the authentication verified them.
+	Limits map[string]RateLimitBucket `mapstructure:"limits"`
+}
+
+// RateLimitBucket gains Requests tokens per Period and holds up to Burst of them
+type RateLimitBucket struct {
+	Requests int          `mapstructure:"requests"`
+	Period   TimeDuration `mapstructure:"period"`
+	// Burst is how many requests can be made at once, Requests when empty
+	Burst int `mapstructure:"burst"`
+}
+
+// ApiVersion tells the clients of a version it is going away, the dates are RFC 3339
+type ApiVersion struct {
+	// Deprecation is when the version is, or was, deprecated, empty when it is not
+	Deprecation string `mapstructure:"deprecation"`
+	// Sunset is when the version stops answering
+	Sunset string `mapstructure:"sunset"`
+	// Link is the page documenting the migration to the next version
+	Link string `mapstructure:"link"`
+}
+
+// TLS serves the API over HTTPS, the files are read again when they change
+type TLS struct {
+	// CertFile and KeyFile are the PEM certificate chain and key, empty serves plain HTTP
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile are the PEM CAs of the client certificates, mTLS is off without them
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// ClientAuth is `require` (default), clients without a certificate of the CAs are refused, or
+	// `verify_if_given`
+	ClientAuth string `mapstructure:"client_auth"`
+	// MinVersion is `1.2` (default) or `1.3`
+	MinVersion string `mapstructure:"min_version"`
+	// ReloadInterval is how often the files are checked for changes, 1m when empty
+	ReloadInterval TimeDuration `mapstructure:"reload_interval"`
+}
+
+type RequestLog struct {
+	// BodiesOnError logs the request and response bodies only for the responses of status 400 and up
+	BodiesOnError bool `mapstructure:"bodies_on_error"`
+}
+
+// Admin is the listener of the operational endpoints, e.g. `/metrics`, kept apart from the API
+type Admin struct {
+	// Address to listen on, apart from the one of the API, empty turns the listener off
+	Address string `yaml:"address"`
+	// Token is the bearer token of every admin endpoint but `/metrics`, empty turns them off
+	Token string `mask:"filled" yaml:"token"`
+}
+
+// Health is how the `/healthz` probes check the dependencies and how the service drains
+type Health struct {
+	// Timeout of each dependency check, 2s when empty
+	Timeout TimeDuration `mapstructure:"timeout"`
+	// DrainDelay is how long the readiness fails before the server shuts down, for the load
+	// balancers to stop sending requests
+	DrainDelay TimeDuration `mapstructure:"drain_delay"`
+	// CacheTTL is how long the results of the checks answer the probes, 1s when empty
+	CacheTTL TimeDuration `mapstructure:"cache_ttl"`
+}
+
+type Stream struct {
+	HeartbeatInterval TimeDuration `mapstructure:"heartbeat_interval"`
+	HistorySize       int          `mapstructure:"history_size"`
+	BufferSize        int          `mapstructure:"buffer_size"`
+	// AllowedOrigins are the origins, e.g. `https://app.example.com`, whose pages may open a
+	// WebSocket stream besides the ones of the API host, `*` allows every origin
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
 }
 
 type DB struct {
+	// Driver selects the storage, `postgres` (default), `sqlite` or `memory`
+	Driver    string   `mapstructure:"driver"`
 	Postgres  Postgres `mapstructure:"postgres"`
+	Sqlite    Sqlite   `mapstructure:"sqlite"`
 	Redis     Redis    `yaml:"redis"`
+	Cache     Cache    `mapstructure:"cache"`
+	QueryLog  QueryLog `mapstructure:"query_log"`
 	RunSeeder bool     `mapstructure:"run_seeder"`
 }
 
@@ -47,6 +181,23 @@ type Postgres struct {
 	MaxOpenConnection  int           `yaml:"max_open_connection" mapstructure:"max_open_connection"`
 	ConnMaxLifetime    time.Duration `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`
 	TraceStacks        bool          `yaml:"trace_stacks" mapstructure:"trace_stacks"`
+	// Replicas get the reads, they share the credentials and settings of the primary
+	Replicas             []PostgresReplica `mapstructure:"replicas"`
+	ReplicaMaxLag        time.Duration     `yaml:"replica_max_lag" mapstructure:"replica_max_lag"`
+	ReplicaCheckInterval time.Duration     `yaml:"replica_check_interval" mapstructure:"replica_check_interval"`
+}
+
+type PostgresReplica struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type Sqlite struct {
+	// Path of the database file, `:memory:` keeps it in the process
+	Path               string        `yaml:"path"`
+	MigrationsURL      string        `mapstructure:"migrations_url"`
+	BusyTimeout        time.Duration `yaml:"busy_timeout" mapstructure:"busy_timeout"`
+	TransactionTimeout time.Duration `yaml:"transaction_timeout" mapstructure:"transaction_timeout"`
 }
 
 type Redis struct {
@@ -55,11 +206,90 @@ type Redis struct {
 	DB       int    `yaml:"db"`
 }
 
+type Cache struct {
+	// Driver selects where todo items are cached, `redis`, `memory` (in-process LRU) or empty for none
+	Driver string       `mapstructure:"driver"`
+	TTL    TimeDuration `mapstructure:"ttl"`
+	// Size is how many items the `memory` cache keeps
+	Size int `mapstructure:"size"`
+}
+
+type Tracing struct {
+	// Endpoint of the OTLP/HTTP collector, e.g. `otel-collector:4318`, empty exports nothing
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+	// Sampler decides for the traces the service starts, `always_on` (default), `always_off` or
+	// `ratio` of SampleRatio. A trace started upstream keeps the decision of its caller.
+	Sampler     string  `yaml:"sampler"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	// ResourceAttributes are added to service.name and deployment.environment, written like
+	// OTEL_RESOURCE_ATTRIBUTES: `key1=value1,key2=value2`
+	ResourceAttributes string `mapstructure:"resource_attributes"`
+}
+
+// QueryLog is how the SQL statements are logged, as structured records or, in the `local` mode,
+// as highlighted SQL on the console
+type QueryLog struct {
+	// Level is `silent`, `error` (failed statements), `warn` (and the slow ones) or `info` (and a
+	// sample of the others, the default)
+	Level         string       `yaml:"level"`
+	SlowThreshold TimeDuration `mapstructure:"slow_threshold"`
+	// SampleRate is the share of the statements logged at info, between 0 and 1; 0 is all of them
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// RedactParams logs the statements with their placeholders rather than the parameters
+	RedactParams bool `mapstructure:"redact_params"`
+}
+
+// Log is where and from which level the logs are written
+type Log struct {
+	// Backend writes the records, `slog_json` (default), `slog_text` or `zap`
+	Backend string `yaml:"backend"`
+	// Levels are the minimum level of each mode, `debug`, `info`, `warn` or `error`, a mode left
+	// out logs from debug. The level can be changed at runtime on the admin listener.
+	Levels map[string]string `yaml:"levels"`
+	// Output is `stdout` (default) or `file`
+	Output string  `yaml:"output"`
+	File   LogFile `mapstructure:"file"`
+}
+
+// LogFile is rotated once it reaches MaxSizeMb, keeping MaxBackups files as `<path>.1`, `<path>.2`...
+type LogFile struct {
+	Path       string `yaml:"path"`
+	MaxSizeMb  int    `mapstructure:"max_size_mb"`
+	MaxBackups int    `mapstructure:"max_backups"`
+}
+
+// Redaction hides the secrets of the request log and of the fields of the other logs
+type Redaction struct {
+	// Headers are logged as `[REDACTED]`, matched case-insensitively
+	Headers []string `yaml:"headers"`
+	// Fields are the body and log fields masked, as dot separated key paths matched at any depth,
+	// e.g. `password` or `user.token`; `*` matches any key and arrays are looked through
+	Fields []string `yaml:"fields"`
+	// MaxBodySize caps a logged body in bytes, longer ones are truncated; 0 keeps them whole
+	MaxBodySize int `mapstructure:"max_body_size"`
+	// Routes add rules to the global ones for the requests of a route
+	Routes []RouteRedaction `mapstructure:"routes"`
+}
+
+type RouteRedaction struct {
+	// Method of the route, empty for all of them
+	Method string `yaml:"method"`
+	// Route is the pattern the route is registered with, e.g. `/api/v1/todo-items/:id`
+	Route   string   `yaml:"route"`
+	Headers []string `yaml:"headers"`
+	Fields  []string `yaml:"fields"`
+	// Params are the path params masked in the logged path
+	Params []string `yaml:"params"`
+	// MaxBodySize overrides the global cap when it is not 0
+	MaxBodySize int `mapstructure:"max_body_size"`
+}
+
 type Services struct{}
 
 func LoadConfig(configPath string) *AppConfig {
 	conf := NewConfig(configPath, &AppConfig{})
-	configJson, err := json.Marshal(conf.Internal.(*AppConfig))
+	configJson, err := json.Marshal(Masked(conf.Internal))
 	if err != nil {
 		panic(fmt.Sprintf("Can't make the json the config file:%v", err))
 	}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The modes of the `mask` struct tag
+const (
+	// MaskFilled only tells whether the value is set
+	MaskFilled = "filled"
+	// MaskPartial keeps the first and last two characters of the values long enough to hide the rest
+	MaskPartial = "partial"
+	// MaskHash replaces the value with the start of its SHA-256, to compare it without showing it
+	MaskHash = "hash"
+)
+
+const (
+	maskFilled         = "[FILLED]"
+	maskHidden         = "****"
+	partialMaskMinSize = 8
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Masked returns v as maps, slices and values to dump, with the fields tagged with `mask` masked.
+// The fields are named like encoding/json names them, an unknown mode is taken as filled.
+func Masked(v any) any {
+	return masked(reflect.ValueOf(v))
+}
+
+func masked(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return masked(v.Elem())
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface()
+		}
+
+		res := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+
+			if mode, ok := field.Tag.Lookup("mask"); ok {
+				res[name] = maskValue(mode, v.Field(i))
+				continue
+			}
+			res[name] = masked(v.Field(i))
+		}
+		return res
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		res := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			res[fmt.Sprint(iter.Key().Interface())] = masked(iter.Value())
+		}
+		return res
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		res := make([]any, v.Len())
+		for i := range res {
+			res[i] = masked(v.Index(i))
+		}
+		return res
+	default:
+		return v.Interface()
+	}
+}
+
+// maskValue masks a leaf as its text, a zero value stays empty so an unset secret shows as such
+func maskValue(mode string, v reflect.Value) string {
+	if !v.IsValid() || v.IsZero() {
+		return ""
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	s := fmt.Sprint(v.Interface())
+
+	switch mode {
+	case MaskPartial:
+		// by runes, a multi-byte character must not be cut into invalid UTF-8
+		r := []rune(s)
+		if len(r) < partialMaskMinSize {
+			return maskHidden
+		}
+		return string(r[:2]) + maskHidden + string(r[len(r)-2:])
+	case MaskHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	default:
+		return maskFilled
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMasked(t *testing.T) {
+	type credential struct {
+		User   string
+		Secret string `mask:"partial"`
+	}
+	type section struct {
+		Password  string            `mask:"filled"`
+		Empty     string            `mask:"filled"`
+		Key       string            `mask:"hash"`
+		Port      int               `mask:"unknown"`
+		Name      string            `json:"name"`
+		Short     *credential       `json:"short"`
+		Creds     []credential      `json:"creds"`
+		ByName    map[string]string `json:"by_name"`
+		Nested    map[string]credential
+		Skipped   string `json:"-"`
+		unexposed string
+	}
+
+	masked := Masked(&section{
+		Password:  "postgres",
+		Key:       "secret",
+		Port:      5432,
+		Name:      "todoapp",
+		Short:     &credential{User: "ali", Secret: "abc"},
+		Creds:     []credential{{User: "ali", Secret: "s3cr3t-value"}, {User: "ümit", Secret: "ğüşöçı-ğüş"}},
+		ByName:    map[string]string{"a": "b"},
+		Nested:    map[string]credential{"replica": {Secret: "another-secret"}},
+		Skipped:   "skipped",
+		unexposed: "unexposed",
+	})
+
+	assert.Equal(t, map[string]any{
+		"Password": "[FILLED]",
+		"Empty":    "",
+		"Key":      "sha256:2bb80d537b1d",
+		"Port":     "[FILLED]",
+		"name":     "todoapp",
+		"short":    map[string]any{"User": "ali", "Secret": "****"},
+		"creds":    []any{map[string]any{"User": "ali", "Secret": "s3****ue"}, map[string]any{"User": "ümit", "Secret": "ğü****üş"}},
+		"by_name":  map[string]any{"a": "b"},
+		"Nested":   map[string]any{"replica": map[string]any{"User": "", "Secret": "an****et"}},
+	}, masked)
+
+	t.Run("app config", func(t *testing.T) {
+		conf := AppConfig{DB: DB{
+			Postgres: Postgres{Password: "postgres"},
+			Redis:    Redis{Password: "redis"},
+		}}
+
+		b, err := json.Marshal(Masked(&conf))
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), "postgres")
+		assert.NotContains(t, string(b), "redis")
+	})
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SchemaDrift compares the gorm models with the live schema and describes every difference: missing
+// tables, columns missing from either side, and columns whose type or nullability disagree. Types are
+// compared by family (text, time, ...) since drivers name them differently, SQLite stores uuid as text.
+func SchemaDrift(db *gorm.DB, models ...any) ([]string, error) {
+	var res []string
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+
+		if !db.Migrator().HasTable(table) {
+			res = append(res, fmt.Sprintf("table %s is missing", table))
+			continue
+		}
+
+		columnTypes, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, err
+		}
+
+		columns := make(map[string]gorm.ColumnType, len(columnTypes))
+		for _, ct := range columnTypes {
+			columns[ct.Name()] = ct
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+
+			ct, ok := columns[field.DBName]
+			if !ok {
+				res = append(res, fmt.Sprintf("column %s.%s is missing", table, field.DBName))
+				continue
+			}
+			delete(columns, field.DBName)
+
+			if want, got := typeFamily(string(field.DataType)), typeFamily(ct.DatabaseTypeName()); want != got {
+				res = append(res, fmt.Sprintf("column %s.%s is %s, the model expects %s", table, field.DBName, got, want))
+			}
+
+			notNull := field.NotNull || field.PrimaryKey
+			if nullable, ok := ct.Nullable(); ok && nullable == notNull {
+				res = append(res, fmt.Sprintf("column %s.%s nullable is %t, the model expects %t", table, field.DBName, nullable, !notNull))
+			}
+		}
+
+		for _, ct := range columnTypes {
+			if _, ok := columns[ct.Name()]; ok {
+				res = append(res, fmt.Sprintf("column %s.%s is not in the model", table, ct.Name()))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func typeFamily(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case name == string(schema.String), strings.Contains(name, "uuid"), strings.Contains(name, "text"), strings.Contains(name, "char"):
+		return "text"
+	case name == string(schema.Time), strings.Contains(name, "time"), strings.Contains(name, "date"):
+		return "time"
+	case strings.Contains(name, "bool"):
+		return "bool"
+	case name == string(schema.Bytes), strings.Contains(name, "bytea"), strings.Contains(name, "blob"):
+		return "bytes"
+	case name == string(schema.Int), name == string(schema.Uint), strings.Contains(name, "int"), strings.Contains(name, "serial"):
+		return "int"
+	case name == string(schema.Float), strings.Contains(name, "float"), strings.Contains(name, "double"),
+		strings.Contains(name, "real"), strings.Contains(name, "numeric"), strings.Contains(name, "decimal"):
+		return "float"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	glog "gorm.io/gorm/logger"
+)
+
+type driftModel struct {
+	UniversalModel
+	Title string `gorm:"column:title;type:text;not null"`
+	Done  bool   `gorm:"column:done;not null"`
+}
+
+func TestSchemaDrift(t *testing.T) {
+	gormDB, err := NewSqliteConn(context.Background(), config.Sqlite{
+		Path:               SqliteInMemory,
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}, glog.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+
+	drift, err := SchemaDrift(gormDB, &driftModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"table drift_models is missing"}, drift)
+
+	require.NoError(t, gormDB.Exec(`CREATE TABLE drift_models (
+		id TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		deleted_at DATETIME,
+		title TEXT,
+		done DATETIME NOT NULL,
+		extra TEXT
+	)`).Error)
+
+	drift, err = SchemaDrift(gormDB, &driftModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"column drift_models.title nullable is true, the model expects false",
+		"column drift_models.done is time, the model expects bool",
+		"column drift_models.extra is not in the model",
+	}, drift)
+}
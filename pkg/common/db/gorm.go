@@ -4,19 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"io"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrTransactionStarted = errors.New("transaction has already been started")
-	ErrDBType             = errors.New("wrong type of DB interface")
-	ErrDBTimeOut          = errors.New("can't set transaction timeout")
+	ErrDBType    = errors.New("wrong type of DB interface")
+	ErrDBTimeOut = errors.New("can't set transaction timeout")
 )
 
 const (
-	ApmDBConnsStat           = "db.connections_status"
 	ApmQueryIndexUsagePrefix = "db.query_index_usage."
 )
 
@@ -52,16 +51,20 @@ type DB interface {
 	SavePoint(name string) *gorm.DB
 	RollbackTo(name string) *gorm.DB
 	Exec(sql string, values ...interface{}) (tx *gorm.DB)
+	Raw(sql string, values ...interface{}) (tx *gorm.DB)
 	WithContext(ctx context.Context) *gorm.DB
 	Model(value interface{}) (tx *gorm.DB)
 	Table(name string, args ...interface{}) (tx *gorm.DB)
 	Unscoped() (tx *gorm.DB)
+	Clauses(conds ...clause.Expression) (tx *gorm.DB)
 }
 
+// GormConnection returns the transaction of ctx or db, either one runs its statements with ctx so
+// their spans belong to the span of ctx
 func GormConnection(ctx context.Context, db *gorm.DB) DB {
 	tx, ok := gormTxFromContext(ctx)
 	if ok {
-		return tx
+		return tx.WithContext(ctx)
 	}
 	return db.WithContext(ctx)
 }
@@ -71,28 +74,17 @@ func gormTxFromContext(ctx context.Context) (*gorm.DB, bool) {
 	return tx, ok
 }
 
-func BeginTx(ctx context.Context, db DB) (*gorm.DB, context.Context, error) {
-	tx, ok := gormTxFromContext(ctx)
-	if ok {
-		return tx, ctx, ErrTransactionStarted
+// Close closes the pool of db and those of its replicas, a connection without a database has none
+func Close(db *gorm.DB) error {
+	var errs []error
+	for _, plugin := range db.Config.Plugins {
+		if closer, ok := plugin.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
 	}
-
-	dbt, ok := db.(*gorm.DB)
-	if !ok {
-		return nil, ctx, ErrDBType
+	if sqlDB, err := db.DB(); err == nil {
+		errs = append(errs, sqlDB.Close())
 	}
-	ctx, _ = context.WithTimeout(ctx, transactionTimeOut)
-
-	tx = dbt.Begin().WithContext(ctx)
-	go func() {
-		<-ctx.Done()
-		tx.Rollback()
-		fmt.Println("The context has been canceled and transaction timeout")
-	}()
-
-	return tx, withTx(ctx, tx), nil
-}
 
-func withTx(ctx context.Context, tx *gorm.DB) context.Context {
-	return context.WithValue(ctx, txKey{}, tx)
+	return errors.Join(errs...)
 }
@@ -3,38 +3,218 @@ package db
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"os"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/chroma/v2/quick"
 	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
 	"github.com/thealiakbari/todoapp/pkg/common/logger"
 	"github.com/thealiakbari/todoapp/pkg/common/middleware"
 	"github.com/thealiakbari/todoapp/pkg/common/utiles"
+	"gorm.io/gorm"
 	glog "gorm.io/gorm/logger"
 )
 
 const (
-	highlightSql = true
-	reset        = "\033[0m"
-	red          = "\033[31m"
-	magenta      = "\033[35m"
-	green        = "\033[32m"
-	blueBold     = "\033[34;1m"
-	infoStr      = green + reset + green + "[info] " + reset
-	warnStr      = blueBold + reset + magenta + "[warn] " + reset
-	errStr       = magenta + reset + red + "[error] " + reset
+	reset    = "\033[0m"
+	red      = "\033[31m"
+	magenta  = "\033[35m"
+	green    = "\033[32m"
+	blueBold = "\033[34;1m"
+	infoStr  = green + reset + green + "[info] " + reset
+	warnStr  = blueBold + reset + magenta + "[warn] " + reset
+	errStr   = magenta + reset + red + "[error] " + reset
 )
 
+const (
+	QueryLogSilent = "silent"
+	QueryLogError  = "error"
+	QueryLogWarn   = "warn"
+	QueryLogInfo   = "info"
+)
+
+const defaultSlowThreshold = 200 * time.Millisecond
+
 type gormLogger struct {
+	log           logger.Logger
+	level         glog.LogLevel
+	slowThreshold time.Duration
+	sampleRate    float64
+	redactParams  bool
+	// pretty prints colourised SQL on the console instead of the structured records, for ModeLocal
+	pretty      bool
 	traceStacks bool
 }
 
-var _ glog.Interface = gormLogger{}
+var (
+	_ glog.Interface    = gormLogger{}
+	_ gorm.ParamsFilter = gormLogger{}
+)
+
+// NewGormLogger logs the statements of gorm through log: failures at error, the ones slower than
+// the threshold at warn and a sample of the others at info, each with its duration, rows, caller
+// and the trace of its context. In ModeLocal they are printed as highlighted SQL on the console
+// instead, with the stack when traceStacks is set.
+func NewGormLogger(conf config.QueryLog, mode string, log logger.Logger, traceStacks bool) (glog.Interface, error) {
+	var level glog.LogLevel
+	switch conf.Level {
+	case "", QueryLogInfo:
+		level = glog.Info
+	case QueryLogWarn:
+		level = glog.Warn
+	case QueryLogError:
+		level = glog.Error
+	case QueryLogSilent:
+		level = glog.Silent
+	default:
+		return nil, fmt.Errorf("unknown query log level %q", conf.Level)
+	}
+
+	if conf.SampleRate < 0 || conf.SampleRate > 1 {
+		return nil, fmt.Errorf("query log sample rate %v is not between 0 and 1", conf.SampleRate)
+	}
+	sampleRate := conf.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	slowThreshold := defaultSlowThreshold
+	if conf.SlowThreshold != "" {
+		slowThreshold = conf.SlowThreshold.Duration()
+	}
+
+	return gormLogger{
+		log:           log.ForService(gormLogger{}),
+		level:         level,
+		slowThreshold: slowThreshold,
+		sampleRate:    sampleRate,
+		redactParams:  conf.RedactParams,
+		pretty:        mode == config.ModeLocal,
+		traceStacks:   traceStacks,
+	}, nil
+}
+
+func (g gormLogger) LogMode(level glog.LogLevel) glog.Interface {
+	g.level = level
+	return g
+}
+
+// ParamsFilter leaves the parameters out of the logged SQL when they are redacted, the statement
+// keeps its placeholders
+func (g gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if g.redactParams {
+		return sql, nil
+	}
+
+	return sql, params
+}
+
+func (g gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.level <= glog.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	// a missing record is an answer, not a failure
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+
+	var level glog.LogLevel
+	switch {
+	case failed:
+		level = glog.Error
+	case elapsed > g.slowThreshold:
+		level = glog.Warn
+	default:
+		if g.sampleRate < 1 && rand.Float64() >= g.sampleRate {
+			return
+		}
+		level = glog.Info
+	}
+	if level > g.level {
+		return
+	}
+
+	sql, rows := fc()
+	if g.pretty {
+		printQuery(ctx, sql, rows, level, elapsed, g.traceStacks, err)
+		return
+	}
 
-func newGormLogger(traceStacks bool) glog.Interface {
-	return gormLogger{traceStacks: traceStacks}
+	fields := []logger.Field{
+		logger.String("sql", sql),
+		logger.Any("duration_ms", float64(elapsed.Microseconds())/1000),
+		logger.Any("rows", rows),
+		logger.String("caller", queryCaller()),
+	}
+	switch level {
+	case glog.Error:
+		g.log.Error(ctx, "query failed", append(fields, logger.Error(err))...)
+	case glog.Warn:
+		g.log.Warn(ctx, "slow query", append(fields, logger.Any("slow_threshold_ms", g.slowThreshold.Milliseconds()))...)
+	default:
+		g.log.Info(ctx, "query", fields...)
+	}
+}
+
+func (g gormLogger) Info(ctx context.Context, template string, args ...interface{}) {
+	if g.level < glog.Info {
+		return
+	}
+
+	if g.pretty {
+		fmt.Printf(infoStr+" "+template+"\n%s\n", append(args, getStack())...)
+		return
+	}
+	g.log.Infof(ctx, template, args...)
+}
+
+func (g gormLogger) Warn(ctx context.Context, template string, args ...interface{}) {
+	if g.level < glog.Warn {
+		return
+	}
+
+	if g.pretty {
+		fmt.Printf(warnStr+" "+template+"\n%s\n", append(args, getStack())...)
+		return
+	}
+	g.log.Warnf(ctx, template, args...)
+}
+
+func (g gormLogger) Error(ctx context.Context, template string, args ...interface{}) {
+	if g.level < glog.Error {
+		return
+	}
+
+	if g.pretty {
+		fmt.Printf(errStr+" "+template+"\n%s\n", append(args, getStack())...)
+		return
+	}
+	g.log.Errorf(ctx, template, args...)
+}
+
+// queryCaller is the first frame outside gorm and this logger, the code that ran the statement
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	wd, _ := os.Getwd()
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.Contains(frame.Function, "/pkg/common/db.gormLogger") {
+			file := strings.TrimPrefix(frame.File, wd+"/")
+			return fmt.Sprintf("%s:%d", file, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
 }
 
 func nowStr() string {
@@ -45,28 +225,32 @@ func nowStr() string {
 	)
 }
 
-func log(sql string, rows int64, level string, beginTime time.Time, elapsed time.Duration, traceId *string, traceStacks bool, err error) {
+// printQuery is the console output of ModeLocal
+func printQuery(ctx context.Context, sql string, rows int64, level glog.LogLevel, elapsed time.Duration, traceStacks bool, err error) {
+	levelStr := infoStr
+	switch level {
+	case glog.Error:
+		levelStr = errStr
+	case glog.Warn:
+		levelStr = warnStr
+	}
+
 	x := bytes.NewBufferString(
 		fmt.Sprintf(
 			"[%s] %s elapsed: %v -- rows: %d",
 			nowStr(),
-			level,
+			levelStr,
 			elapsed.String(),
 			rows,
 		),
 	)
-	if traceId != nil {
+	if traceId := getTraceId(ctx); traceId != nil {
 		x.WriteString(" -- traceId: ")
 		x.WriteString(*traceId)
 	}
 
 	x.WriteString("\n")
-
-	if highlightSql {
-		quick.Highlight(x, sql, "postgresql", "terminal16m", "monokai")
-	} else {
-		x.WriteString(sql)
-	}
+	quick.Highlight(x, sql, "postgresql", "terminal16m", "monokai")
 
 	if traceStacks || err != nil {
 		stack := logger.Stacks(7, 4)
@@ -85,45 +269,20 @@ func log(sql string, rows int64, level string, beginTime time.Time, elapsed time
 	println(x.String())
 }
 
-func getStack() string {
-	stack := logger.Stacks(7, 4)
-	return strings.Join(stack, "\n")
-}
-
-func (g gormLogger) LogMode(glog.LogLevel) glog.Interface {
-	return g
-}
-
-func (g gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
-	elapsed := time.Since(begin)
-	// slowThreshold from gorm source code, in default logger this is a constant initiation
-	slowThreshold := 200 * time.Millisecond
-	sql, rows := fc()
-
-	var traceId *string
-	traceIdUuid, ok := ctx.Value(middleware.TraceIdKey).(uuid.UUID)
-	if ok {
-		traceId = utiles.Ptr(traceIdUuid.String())
+func getTraceId(ctx context.Context) *string {
+	if ctx == nil {
+		return nil
 	}
 
-	level := infoStr
-	if err != nil {
-		level = errStr
-	} else if elapsed > slowThreshold {
-		level = warnStr
+	traceIdUuid, ok := ctx.Value(middleware.TraceIdKey).(uuid.UUID)
+	if !ok {
+		return nil
 	}
 
-	log(sql, rows, level, begin, elapsed, traceId, g.traceStacks, err)
+	return utiles.Ptr(traceIdUuid.String())
 }
 
-func (g gormLogger) Warn(ctx context.Context, template string, args ...interface{}) {
-	fmt.Printf(warnStr+" "+template+"\n%s", args, getStack())
-}
-
-func (g gormLogger) Error(ctx context.Context, template string, args ...interface{}) {
-	fmt.Printf(errStr+" "+template+"\n%s", args, getStack())
-}
-
-func (g gormLogger) Info(ctx context.Context, template string, args ...interface{}) {
-	fmt.Printf(infoStr+" "+template+"\n%s", args, getStack())
+func getStack() string {
+	stack := logger.Stacks(7, 4)
+	return strings.Join(stack, "\n")
 }
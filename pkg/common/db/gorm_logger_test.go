@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+)
+
+type logRecord struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// recordingLogger keeps what is logged, only the methods the query logger calls record
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) record(level, msg string, fields []logger.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record := logRecord{level: level, msg: msg, fields: map[string]any{}}
+	for _, f := range fields {
+		record.fields[f.Key] = f.Value
+	}
+	l.records = append(l.records, record)
+}
+
+func (l *recordingLogger) take() []logRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := l.records
+	l.records = nil
+	return records
+}
+
+func (l *recordingLogger) ForService(service interface{}) logger.Logger { return l }
+func (l *recordingLogger) Info(ctx context.Context, msg string, attrs ...logger.Field) {
+	l.record("info", msg, attrs)
+}
+func (l *recordingLogger) Error(ctx context.Context, msg string, attrs ...logger.Field) {
+	l.record("error", msg, attrs)
+}
+func (l *recordingLogger) Warn(ctx context.Context, msg string, attrs ...logger.Field) {
+	l.record("warn", msg, attrs)
+}
+func (l *recordingLogger) Debug(ctx context.Context, msg string, attrs ...logger.Field) {
+	l.record("debug", msg, attrs)
+}
+func (l *recordingLogger) MethodError(ctx context.Context, input interface{}, msg string, attrs ...logger.Field) {
+}
+func (l *recordingLogger) Infof(ctx context.Context, template string, args ...interface{}) {
+	l.record("info", fmt.Sprintf(template, args...), nil)
+}
+func (l *recordingLogger) Errorf(ctx context.Context, template string, args ...interface{}) {
+	l.record("error", fmt.Sprintf(template, args...), nil)
+}
+func (l *recordingLogger) MethodErrorf(ctx context.Context, input interface{}, template string, args ...interface{}) {
+}
+func (l *recordingLogger) Debugf(ctx context.Context, template string, args ...interface{}) {}
+func (l *recordingLogger) Warnf(ctx context.Context, template string, args ...interface{}) {
+	l.record("warn", fmt.Sprintf(template, args...), nil)
+}
+func (l *recordingLogger) Panicf(ctx context.Context, template string, args ...interface{}) {}
+func (l *recordingLogger) CloneAsInfra() logger.InfraLogger                                 { return nil }
+
+func TestGormLogger(t *testing.T) {
+	log := &recordingLogger{}
+	open := func(t *testing.T, conf config.QueryLog) *gorm.DB {
+		queryLogger, err := NewGormLogger(conf, config.ModeProd, log, false)
+		require.NoError(t, err)
+
+		gormDB, err := NewSqliteConn(context.Background(), config.Sqlite{
+			Path:               SqliteInMemory,
+			BusyTimeout:        5000,
+			TransactionTimeout: 120000,
+		}, queryLogger)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			sdb, _ := gormDB.DB()
+			_ = sdb.Close()
+		})
+		require.NoError(t, gormDB.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL)").Error)
+		log.take()
+
+		return gormDB
+	}
+
+	t.Run("structured", func(t *testing.T) {
+		gormDB := open(t, config.QueryLog{})
+
+		require.NoError(t, gormDB.Exec("INSERT INTO notes (body) VALUES (?)", "secret").Error)
+		require.Error(t, gormDB.Exec("INSERT INTO missing VALUES (1)").Error)
+
+		records := log.take()
+		require.Len(t, records, 2)
+
+		assert.Equal(t, "info", records[0].level)
+		assert.Equal(t, "query", records[0].msg)
+		assert.Equal(t, "INSERT INTO notes (body) VALUES (\"secret\")", records[0].fields["sql"])
+		assert.Equal(t, int64(1), records[0].fields["rows"])
+		assert.Contains(t, records[0].fields, "duration_ms")
+		assert.True(t, strings.HasPrefix(records[0].fields["caller"].(string), "gorm_logger_test.go:"), records[0].fields["caller"])
+
+		assert.Equal(t, "error", records[1].level)
+		assert.Equal(t, "query failed", records[1].msg)
+		assert.ErrorContains(t, records[1].fields["error"].(error), "no such table")
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		gormDB := open(t, config.QueryLog{RedactParams: true})
+
+		require.NoError(t, gormDB.Exec("INSERT INTO notes (body) VALUES (?)", "secret").Error)
+
+		records := log.take()
+		require.Len(t, records, 1)
+		assert.Equal(t, "INSERT INTO notes (body) VALUES (?)", records[0].fields["sql"])
+	})
+
+	t.Run("slow", func(t *testing.T) {
+		gormDB := open(t, config.QueryLog{Level: QueryLogWarn, SlowThreshold: "1ns"})
+
+		var count int64
+		require.NoError(t, gormDB.Table("notes").Count(&count).Error)
+
+		records := log.take()
+		require.Len(t, records, 1)
+		assert.Equal(t, "warn", records[0].level)
+		assert.Equal(t, "slow query", records[0].msg)
+	})
+
+	t.Run("level", func(t *testing.T) {
+		gormDB := open(t, config.QueryLog{Level: QueryLogWarn})
+
+		var count int64
+		require.NoError(t, gormDB.Table("notes").Count(&count).Error)
+		require.NoError(t, gormDB.Session(&gorm.Session{Logger: gormDB.Logger.LogMode(glog.Info)}).Table("notes").Count(&count).Error)
+
+		records := log.take()
+		require.Len(t, records, 1, "LogMode raises the level of its session only")
+		assert.Equal(t, "info", records[0].level)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewGormLogger(config.QueryLog{Level: "verbose"}, config.ModeProd, log, false)
+		assert.Error(t, err)
+
+		_, err = NewGormLogger(config.QueryLog{SampleRate: 2}, config.ModeProd, log, false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"gorm.io/gorm"
+)
+
+// migrationsTable is where golang-migrate records the version, of Postgres and SQLite alike
+const migrationsTable = "schema_migrations"
+
+// Migrator applies the migration scripts of one database
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// NewPostgresMigrator reads the scripts from `migrations_url` when it is set (eg.
+// `file:./cmd/migration/scripts` while writing a migration), otherwise from scripts
+func NewPostgresMigrator(cfg config.Postgres, scripts fs.FS) (*Migrator, error) {
+	src, err := newMigrationSource(cfg.MigrationsURL, scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, postgresDSN(cfg, cfg.Host, cfg.Port))
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// NewSqliteMigrator migrates the connection of NewSqliteConn rather than opening its own, so an
+// in-memory database is migrated too. Closing the migrator leaves the connection open.
+func NewSqliteMigrator(db *gorm.DB, cfg config.Sqlite, scripts fs.FS) (*Migrator, error) {
+	sdb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := newMigrationSource(cfg.MigrationsURL, scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, config.DriverSqlite, &sqliteMigrateDriver{db: sdb})
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+func newMigrationSource(url string, scripts fs.FS) (source.Driver, error) {
+	if url != "" {
+		return source.Open(url)
+	}
+
+	return iofs.New(scripts, ".")
+}
+
+// Up applies every pending migration, having none is not an error
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Down rolls back the last n migrations
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return errors.New("the number of migrations to roll back must be positive")
+	}
+
+	return m.m.Steps(-n)
+}
+
+// Goto migrates up or down to version
+func (m *Migrator) Goto(version uint) error {
+	if err := m.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Force sets the version without running anything and clears the dirty flag, after a failed
+// migration was fixed by hand. -1 means no migration is applied.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Status lists every known migration, version is 0 when none is applied
+func (m *Migrator) Status() (version uint, dirty bool, res []MigrationStatus, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		version, err = 0, nil
+	}
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	v, err := m.source.First()
+	for err == nil {
+		status := MigrationStatus{Version: v, Applied: v <= version}
+
+		r, name, readErr := m.source.ReadUp(v)
+		if readErr != nil {
+			return 0, false, nil, readErr
+		}
+		_ = r.Close()
+		status.Name = name
+
+		res = append(res, status)
+		v, err = m.source.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil, err
+	}
+
+	return version, dirty, res, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// LatestMigration is the version of the last script of the source, the one Up migrates to
+func LatestMigration(url string, scripts fs.FS) (uint, error) {
+	src, err := newMigrationSource(url, scripts)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for err == nil {
+		var next uint
+		next, err = src.Next(version)
+		if err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// MigrationVersion reads the version recorded in the database, 0 when none is applied. It queries
+// sqlDB directly, so it is neither logged nor traced like the statements of gorm.
+func MigrationVersion(ctx context.Context, sqlDB *sql.DB) (version uint, dirty bool, err error) {
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", migrationsTable)
+	err = sqlDB.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNoopConn(t *testing.T) {
+	gormDB, err := NewNoopConn()
+	require.NoError(t, err)
+	gormDB = gormDB.WithContext(context.Background())
+
+	var n int
+	assert.ErrorIs(t, gormDB.Raw("SELECT 1").Row().Scan(&n), ErrNoDatabase)
+	assert.ErrorIs(t, gormDB.Raw("SELECT 1").Scan(&n).Error, ErrNoDatabase)
+	assert.ErrorIs(t, gormDB.Exec("DELETE FROM todo_items").Error, ErrNoDatabase)
+	assert.ErrorIs(t, gormDB.Table("todo_items").Where("id = ?", 1).Count(new(int64)).Error, ErrNoDatabase)
+
+	assert.NoError(t, gormDB.Transaction(func(tx *gorm.DB) error { return nil }))
+}
@@ -2,18 +2,16 @@ package db
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	"github.com/thealiakbari/todoapp/pkg/common/config"
-	infraLogger "github.com/thealiakbari/todoapp/pkg/common/logger"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
 	apmpostgres "go.elastic.co/apm/module/apmgormv2/v2/driver/postgres"
 	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
 	"gorm.io/plugin/opentelemetry/tracing"
 )
 
@@ -21,17 +19,12 @@ const UUIDExtension = "uuid-ossp"
 
 var transactionTimeOut time.Duration = 60000
 
-func NewPostgresConn(ctx context.Context, cfg config.Postgres) (*gorm.DB, error) {
-	db, err := gorm.Open(apmpostgres.Open(fmt.Sprintf(`postgresql://%s:%s@%s:%d/%s?sslmode=%s&application_name=%s`,
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-		cfg.Ssl,
-		cfg.AppName)), &gorm.Config{
+// NewPostgresConn connects the primary and the replicas of cfg, log reports the replicas
+// entering and leaving the rotation
+func NewPostgresConn(ctx context.Context, cfg config.Postgres, queryLogger glog.Interface, log logger.InfraLogger) (*gorm.DB, error) {
+	db, err := gorm.Open(apmpostgres.Open(postgresDSN(cfg, cfg.Host, cfg.Port)), &gorm.Config{
 		SkipDefaultTransaction: true,
-		Logger:                 newGormLogger(cfg.TraceStacks),
+		Logger:                 queryLogger,
 	})
 	if err != nil {
 		return nil, err
@@ -39,6 +32,22 @@ func NewPostgresConn(ctx context.Context, cfg config.Postgres) (*gorm.DB, error)
 	if err := db.Use(tracing.NewPlugin()); err != nil {
 		panic(err)
 	}
+
+	if len(cfg.Replicas) > 0 {
+		replicas, err := newPostgresReplicas(cfg, queryLogger, log)
+		if err != nil {
+			return nil, err
+		}
+		if err = db.Use(replicas); err != nil {
+			return nil, err
+		}
+
+		replicas.check(ctx)
+		if cfg.ReplicaCheckInterval > 0 {
+			go replicas.watch(ctx, cfg.ReplicaCheckInterval*time.Millisecond)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -62,6 +71,47 @@ func NewPostgresConn(ctx context.Context, cfg config.Postgres) (*gorm.DB, error)
 	return db, nil
 }
 
+// newPostgresReplicas opens the replicas of cfg with the pool settings of the primary
+func newPostgresReplicas(cfg config.Postgres, queryLogger glog.Interface, log logger.InfraLogger) (*replicaSet, error) {
+	replicas := make([]*replica, 0, len(cfg.Replicas))
+	for _, replicaCfg := range cfg.Replicas {
+		db, err := gorm.Open(apmpostgres.Open(postgresDSN(cfg, replicaCfg.Host, replicaCfg.Port)), &gorm.Config{
+			SkipDefaultTransaction: true,
+			Logger:                 queryLogger,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		pdb, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		pdb.SetConnMaxLifetime(time.Millisecond * cfg.ConnMaxLifetime)
+		pdb.SetMaxIdleConns(cfg.MaxIdleConnection)
+		pdb.SetMaxOpenConns(cfg.MaxOpenConnection)
+
+		replicas = append(replicas, &replica{
+			name: fmt.Sprintf("%s:%d", replicaCfg.Host, replicaCfg.Port),
+			db:   pdb,
+		})
+	}
+
+	return newReplicaSet(replicas, cfg.ReplicaMaxLag*time.Millisecond, postgresReplicaLag, log.ForService(replicaSet{})), nil
+}
+
+func postgresDSN(cfg config.Postgres, host string, port int) string {
+	return fmt.Sprintf(`postgresql://%s:%s@%s:%d/%s?sslmode=%s&application_name=%s`,
+		cfg.Username,
+		cfg.Password,
+		host,
+		port,
+		cfg.Name,
+		cfg.Ssl,
+		cfg.AppName,
+	)
+}
+
 func (db *DBWrapper) addExtension(names []string) error {
 	for _, name := range names {
 		if err := db.DB.Exec(fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS \"%v\";", name)).Error; err != nil {
@@ -104,48 +154,3 @@ func (db *DBWrapper) GenerateFunction(fnCode ...string) (err error) {
 
 	return nil
 }
-
-// Migrate need `migrations_url` to be set in config file with a relation path, eg. `file:./cmd/migration/scripts`
-func Migrate(pgConf config.Postgres, log infraLogger.InfraLogger) (err error) {
-	databaseURL := fmt.Sprintf(`postgresql://%s:%s@%s:%d/%s?sslmode=%s&application_name=%s`,
-		pgConf.Username,
-		pgConf.Password,
-		pgConf.Host,
-		pgConf.Port,
-		pgConf.Name,
-		pgConf.Ssl,
-		pgConf.AppName,
-	)
-
-	if pgConf.MigrationsURL == "" {
-		log.Panicf("migration_url cannot be empty, set it with a relation path, eg. `file:./cmd/migration/scripts`\n")
-	}
-
-	m, err := migrate.New(pgConf.MigrationsURL, databaseURL)
-	if err != nil {
-		log.Error(err.Error())
-		return err
-	}
-	defer func() {
-		srcErr, dbErr := m.Close()
-		if srcErr != nil {
-			err = srcErr
-			return
-		}
-
-		if dbErr != nil {
-			err = dbErr
-			return
-		}
-	}()
-
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			return nil
-		}
-		log.Error(err.Error())
-		return err
-	}
-
-	return nil
-}
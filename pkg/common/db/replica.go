@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"gorm.io/gorm"
+)
+
+const replicaCheckTimeout = 2 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so reads made with it go to the primary, for reads that must see the
+// caller's own writes which a replica may not have replayed yet
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary || InTx(ctx)
+}
+
+// replicaLag reports how far a replica is behind its primary
+type replicaLag func(ctx context.Context, db *sql.DB) (time.Duration, error)
+
+// postgresReplicaLag is zero when every received WAL record is replayed, otherwise the age of the
+// last replayed transaction. A server that is not in recovery has no lag.
+func postgresReplicaLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var seconds float64
+	err := db.QueryRowContext(ctx, `SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`).Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet sends the reads of a gorm connection to its healthy replicas in turn. A replica is out
+// of rotation while it fails its check or lags more than maxLag, with none left reads go to the
+// primary. Writes, reads in a transaction and reads with WithPrimary always go to the primary.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	lag      replicaLag
+	log      logger.InfraLogger
+}
+
+func newReplicaSet(replicas []*replica, maxLag time.Duration, lag replicaLag, log logger.InfraLogger) *replicaSet {
+	return &replicaSet{
+		replicas: replicas,
+		maxLag:   maxLag,
+		lag:      lag,
+		log:      log,
+	}
+}
+
+// Name and Initialize make the set a gorm plugin, so Close finds the replicas of a connection
+func (r *replicaSet) Name() string {
+	return "db:replica"
+}
+
+func (r *replicaSet) Initialize(db *gorm.DB) error {
+	return r.register(db)
+}
+
+// Close closes the pools of the replicas
+func (r *replicaSet) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *replicaSet) register(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("db:replica", r.route); err != nil {
+		return err
+	}
+
+	return db.Callback().Row().Before("gorm:row").Register("db:replica", r.route)
+}
+
+func (r *replicaSet) route(db *gorm.DB) {
+	if db.Error != nil || usesPrimary(db.Statement.Context) {
+		return
+	}
+
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+
+	// SELECT ... FOR UPDATE locks rows on the primary
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return
+	}
+
+	if pool := r.pick(); pool != nil {
+		db.Statement.ConnPool = pool
+	}
+}
+
+func (r *replicaSet) pick() *sql.DB {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+
+	return nil
+}
+
+// check pings every replica and measures its lag, replicas changing state are logged
+func (r *replicaSet) check(ctx context.Context) {
+	for _, rep := range r.replicas {
+		ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+		lag, err := r.lag(ctx, rep.db)
+		cancel()
+
+		healthy := err == nil && (r.maxLag <= 0 || lag <= r.maxLag)
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+
+		replica := logger.String("replica", rep.name)
+		switch {
+		case healthy:
+			r.log.Info("db replica is in rotation", replica, logger.Any("lag_ms", lag.Milliseconds()))
+		case err != nil:
+			r.log.Warn("db replica is out of rotation", replica, logger.Error(err))
+		default:
+			r.log.Warn("db replica is out of rotation, it lags", replica,
+				logger.Any("lag_ms", lag.Milliseconds()),
+				logger.Any("max_lag_ms", r.maxLag.Milliseconds()),
+			)
+		}
+	}
+}
+
+// watch checks the replicas every interval until ctx is done
+func (r *replicaSet) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/logger"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+)
+
+// openNamed opens an in-memory database whose only row names it, so reads show where they went
+func openNamed(t *testing.T, name string) *gorm.DB {
+	gormDB, err := NewSqliteConn(context.Background(), config.Sqlite{
+		Path:               SqliteInMemory,
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}, glog.Discard)
+	require.NoError(t, err)
+	require.NoError(t, gormDB.Exec("CREATE TABLE names (name TEXT NOT NULL)").Error)
+	require.NoError(t, gormDB.Exec("INSERT INTO names (name) VALUES (?)", name).Error)
+
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+
+	return gormDB
+}
+
+func readName(t *testing.T, ctx context.Context, gormDB *gorm.DB) string {
+	var names []string
+	require.NoError(t, GormConnection(ctx, gormDB).Table("names").Pluck("name", &names).Error)
+	require.Len(t, names, 1)
+	return names[0]
+}
+
+func TestReplicaSet_Routing(t *testing.T) {
+	ctx := context.Background()
+	primary := openNamed(t, "primary")
+
+	lags := map[string]time.Duration{}
+	var replicas []*replica
+	for _, name := range []string{"r1", "r2"} {
+		sdb, err := openNamed(t, name).DB()
+		require.NoError(t, err)
+		replicas = append(replicas, &replica{name: name, db: sdb})
+	}
+	pools := map[*sql.DB]string{replicas[0].db: "r1", replicas[1].db: "r2"}
+
+	var logs bytes.Buffer
+	log, err := logger.NewInfra("local", "todoapp", "todoapp", logger.WithHandler(slog.NewJSONHandler(&logs, nil)))
+	require.NoError(t, err)
+
+	set := newReplicaSet(replicas, time.Second, func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+		lag, ok := lags[pools[db]]
+		if !ok {
+			return 0, errors.New("down")
+		}
+		return lag, nil
+	}, log)
+	require.NoError(t, primary.Use(set))
+
+	// nothing checked yet, every replica is out of rotation
+	assert.Equal(t, "primary", readName(t, ctx, primary))
+
+	lags["r1"], lags["r2"] = 0, 0
+	set.check(ctx)
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[readName(t, ctx, primary)]++
+	}
+	assert.Equal(t, map[string]int{"r1": 2, "r2": 2}, seen, "round robin")
+
+	assert.Equal(t, "primary", readName(t, WithPrimary(ctx), primary))
+
+	err = NewGormUnitOfWork(primary).Do(ctx, func(ctx context.Context) error {
+		assert.Equal(t, "primary", readName(t, ctx, primary))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// writes stay on the primary
+	require.NoError(t, GormConnection(ctx, primary).Exec("UPDATE names SET name = ?", "written").Error)
+	assert.Equal(t, "written", readName(t, WithPrimary(ctx), primary))
+
+	// r2 lags and r1 is down
+	lags["r2"] = time.Minute
+	delete(lags, "r1")
+	logs.Reset()
+	set.check(ctx)
+	assert.Equal(t, "written", readName(t, ctx, primary))
+	assert.Contains(t, logs.String(), `"replica":"r1"`)
+	assert.Contains(t, logs.String(), `"error":"down"`)
+	assert.Contains(t, logs.String(), `"replica":"r2"`)
+	assert.Contains(t, logs.String(), `"lag_ms":60000`)
+
+	lags["r2"] = 0
+	set.check(ctx)
+	assert.Equal(t, "r2", readName(t, ctx, primary))
+	assert.Equal(t, "r2", readName(t, ctx, primary))
+
+	require.NoError(t, Close(primary))
+	assert.Error(t, replicas[0].db.Ping(), "the replicas are closed with the primary")
+}
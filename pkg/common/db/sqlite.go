@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/glebarez/go-sqlite"
+	gormSqlite "github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+const SqliteInMemory = ":memory:"
+
+var registerSqliteFunctions sync.Once
+
+// NewSqliteConn opens the database file of cfg.Path with a single connection, SQLite allows one
+// writer at a time and a single connection keeps transactions from failing with SQLITE_BUSY.
+// uuid_generate_v4() is registered as a function, it is what CREATE EXTENSION "uuid-ossp"
+// provides on Postgres and the column defaults rely on it.
+func NewSqliteConn(ctx context.Context, cfg config.Sqlite, queryLogger glog.Interface) (*gorm.DB, error) {
+	var err error
+	registerSqliteFunctions.Do(func() {
+		err = sqlite.RegisterScalarFunction("uuid_generate_v4", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+			return uuid.NewString(), nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == "" {
+		return nil, errors.New("sqlite path cannot be empty, set it to a file or `:memory:`")
+	}
+
+	pragmas := url.Values{}
+	pragmas.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", (cfg.BusyTimeout*time.Millisecond).Milliseconds()))
+	pragmas.Add("_pragma", "foreign_keys(1)")
+	if cfg.Path != SqliteInMemory {
+		pragmas.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	db, err := gorm.Open(gormSqlite.Open(cfg.Path+"?"+pragmas.Encode()), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 queryLogger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracing.NewPlugin()); err != nil {
+		panic(err)
+	}
+
+	sdb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// `:memory:` is a new empty database for every connection, it has to stay open
+	sdb.SetMaxOpenConns(1)
+	sdb.SetMaxIdleConns(1)
+	sdb.SetConnMaxLifetime(0)
+	db.WithContext(ctx)
+
+	transactionTimeOut = cfg.TransactionTimeout * time.Millisecond
+
+	return db, nil
+}
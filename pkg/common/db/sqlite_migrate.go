@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+const sqliteMigrationsTable = "schema_migrations"
+
+// sqliteMigrateDriver is a golang-migrate driver over an open *sql.DB. migrate's own sqlite driver
+// registers modernc.org/sqlite under the same name as the driver gorm uses, so it can't be imported.
+// Every migration runs in a transaction and the connection is left open for the caller.
+type sqliteMigrateDriver struct {
+	db       *sql.DB
+	isLocked atomic.Bool
+}
+
+func (d *sqliteMigrateDriver) Open(url string) (database.Driver, error) {
+	return nil, errors.New("the sqlite migrate driver only works on an open connection")
+}
+
+func (d *sqliteMigrateDriver) Close() error {
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Lock() error {
+	if !d.isLocked.CompareAndSwap(false, true) {
+		return database.ErrLocked
+	}
+
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Unlock() error {
+	if !d.isLocked.CompareAndSwap(true, false) {
+		return database.ErrNotLocked
+	}
+
+	return nil
+}
+
+func (d *sqliteMigrateDriver) Run(migration io.Reader) error {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	return d.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(string(query)); err != nil {
+			return database.Error{OrigErr: err, Err: "migration failed", Query: query}
+		}
+
+		return nil
+	})
+}
+
+func (d *sqliteMigrateDriver) SetVersion(version int, dirty bool) error {
+	if err := d.ensureVersionTable(); err != nil {
+		return err
+	}
+
+	return d.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", sqliteMigrationsTable)); err != nil {
+			return err
+		}
+
+		// Also re-write the schema version for nil dirty versions to prevent
+		// empty schema version for failed down migration on the first migration
+		if version >= 0 || (version == database.NilVersion && dirty) {
+			query := fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (?, ?)", sqliteMigrationsTable)
+			if _, err := tx.Exec(query, version, dirty); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *sqliteMigrateDriver) Version() (version int, dirty bool, err error) {
+	if err = d.ensureVersionTable(); err != nil {
+		return database.NilVersion, false, err
+	}
+
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", sqliteMigrationsTable)
+	err = d.db.QueryRow(query).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return database.NilVersion, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func (d *sqliteMigrateDriver) Drop() error {
+	rows, err := d.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return err
+	}
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if _, err := d.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %q", table)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *sqliteMigrateDriver) ensureVersionTable() error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version INTEGER NOT NULL, dirty BOOLEAN NOT NULL)", sqliteMigrationsTable)
+	_, err := d.db.Exec(query)
+	return err
+}
+
+func (d *sqliteMigrateDriver) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := d.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
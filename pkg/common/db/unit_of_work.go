@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txScopeKey struct{}
+
+// txScope is one level of a unit of work, the transaction itself or a savepoint in it. It collects
+// the AfterCommit callbacks, a savepoint hands them to its parent when it is released.
+type txScope struct {
+	mu          sync.Mutex
+	depth       int
+	afterCommit []func()
+}
+
+func (s *txScope) add(fns ...func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.afterCommit = append(s.afterCommit, fns...)
+}
+
+func (s *txScope) take() []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fns := s.afterCommit
+	s.afterCommit = nil
+	return fns
+}
+
+func scopeFromContext(ctx context.Context) (*txScope, bool) {
+	scope, ok := ctx.Value(txScopeKey{}).(*txScope)
+	return scope, ok
+}
+
+// AfterCommit runs fn once the unit of work of ctx is committed, it is dropped when the work or the
+// savepoint it was added in is rolled back. Outside a unit of work fn runs right away.
+func AfterCommit(ctx context.Context, fn func()) {
+	scope, ok := scopeFromContext(ctx)
+	if !ok {
+		fn()
+		return
+	}
+
+	scope.add(fn)
+}
+
+// InTx reports whether ctx belongs to a unit of work
+func InTx(ctx context.Context) bool {
+	_, ok := scopeFromContext(ctx)
+	return ok
+}
+
+type GormUnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewGormUnitOfWork runs units of work in gorm transactions, repositories pick the transaction up
+// from the context through GormConnection. A nested Do runs in a savepoint, so its failure only
+// rolls back its own changes.
+func NewGormUnitOfWork(db *gorm.DB) GormUnitOfWork {
+	return GormUnitOfWork{
+		db: db,
+	}
+}
+
+func (u GormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if tx, ok := gormTxFromContext(ctx); ok {
+		return u.savepoint(ctx, tx, fn)
+	}
+
+	// database/sql rolls the transaction back once ctx is done
+	ctx, cancel := context.WithTimeout(ctx, transactionTimeOut)
+	defer cancel()
+
+	tx := u.db.WithContext(ctx).Begin()
+	if err = tx.Error; err != nil {
+		return err
+	}
+
+	scope := &txScope{}
+	txCtx := context.WithValue(context.WithValue(ctx, txKey{}, tx), txScopeKey{}, scope)
+
+	panicked := true
+	defer func() {
+		if panicked {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(txCtx)
+	panicked = false
+	if err != nil {
+		if rbErr := tx.Rollback().Error; rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+
+	for _, fn := range scope.take() {
+		fn()
+	}
+
+	return nil
+}
+
+func (u GormUnitOfWork) savepoint(ctx context.Context, tx *gorm.DB, fn func(ctx context.Context) error) (err error) {
+	parent, ok := scopeFromContext(ctx)
+	if !ok {
+		parent = &txScope{}
+	}
+
+	scope := &txScope{depth: parent.depth + 1}
+	name := fmt.Sprintf("sp%d", scope.depth)
+	if err = tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	if err = fn(context.WithValue(ctx, txScopeKey{}, scope)); err != nil {
+		if rbErr := tx.RollbackTo(name).Error; rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	parent.add(scope.take()...)
+	return nil
+}
+
+type NoopUnitOfWork struct{}
+
+// NewNoopUnitOfWork is the unit of work of storages without transactions (e.g. `memory`), changes
+// are applied as they are made and nothing is rolled back. AfterCommit callbacks still wait for the
+// outermost Do to succeed.
+func NewNoopUnitOfWork() NoopUnitOfWork {
+	return NoopUnitOfWork{}
+}
+
+func (u NoopUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	parent, nested := scopeFromContext(ctx)
+
+	scope := &txScope{}
+	if nested {
+		scope.depth = parent.depth + 1
+	}
+
+	if err = fn(context.WithValue(ctx, txScopeKey{}, scope)); err != nil {
+		return err
+	}
+
+	if nested {
+		parent.add(scope.take()...)
+		return nil
+	}
+
+	for _, fn := range scope.take() {
+		fn()
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+)
+
+func setupUnitOfWork(t *testing.T) (GormUnitOfWork, *gorm.DB) {
+	gormDB, err := NewSqliteConn(context.Background(), config.Sqlite{
+		Path:               SqliteInMemory,
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}, glog.Discard)
+	require.NoError(t, err)
+	require.NoError(t, gormDB.Exec("CREATE TABLE names (name TEXT NOT NULL)").Error)
+
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+
+	return NewGormUnitOfWork(gormDB), gormDB
+}
+
+func insertName(ctx context.Context, gormDB *gorm.DB, name string) error {
+	return GormConnection(ctx, gormDB).Exec("INSERT INTO names (name) VALUES (?)", name).Error
+}
+
+func names(t *testing.T, gormDB *gorm.DB) (res []string) {
+	require.NoError(t, gormDB.Raw("SELECT name FROM names ORDER BY name").Scan(&res).Error)
+	return res
+}
+
+func TestGormUnitOfWork_CommitAndRollback(t *testing.T) {
+	ctx := context.Background()
+	uow, gormDB := setupUnitOfWork(t)
+
+	var committed bool
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		assert.True(t, InTx(ctx))
+		AfterCommit(ctx, func() { committed = true })
+		assert.False(t, committed)
+		return insertName(ctx, gormDB, "a")
+	})
+	assert.NoError(t, err)
+	assert.True(t, committed)
+
+	errRollback := errors.New("rollback")
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { t.Error("ran after a rollback") })
+		assert.NoError(t, insertName(ctx, gormDB, "b"))
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	assert.Panics(t, func() {
+		_ = uow.Do(ctx, func(ctx context.Context) error {
+			assert.NoError(t, insertName(ctx, gormDB, "c"))
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, []string{"a"}, names(t, gormDB))
+	assert.False(t, InTx(ctx))
+}
+
+func TestGormUnitOfWork_Savepoints(t *testing.T) {
+	ctx := context.Background()
+	uow, gormDB := setupUnitOfWork(t)
+
+	var hooks []string
+	err := uow.Do(ctx, func(ctx context.Context) error {
+		assert.NoError(t, insertName(ctx, gormDB, "outer"))
+
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { hooks = append(hooks, "failed") })
+			assert.NoError(t, insertName(ctx, gormDB, "failed"))
+			return errors.New("only this savepoint")
+		})
+		assert.Error(t, err)
+
+		return uow.Do(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { hooks = append(hooks, "nested") })
+			return uow.Do(ctx, func(ctx context.Context) error {
+				return insertName(ctx, gormDB, "nested")
+			})
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"nested", "outer"}, names(t, gormDB))
+	assert.Equal(t, []string{"nested"}, hooks)
+}
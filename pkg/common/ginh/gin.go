@@ -3,138 +3,215 @@ package ginh
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/i18next"
 	"github.com/thealiakbari/todoapp/pkg/common/logger"
 	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/redact"
 	"github.com/thealiakbari/todoapp/pkg/common/response"
+	"github.com/thealiakbari/todoapp/pkg/common/tracing"
 	"github.com/thealiakbari/todoapp/pkg/common/utiles"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	slog "log/slog"
 )
 
-var slogger *slog.Logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+// defaultSlogger writes the request log when the engine is given none
+var defaultSlogger *slog.Logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
-func traceIdMiddlewarefunc(c *gin.Context) {
-	traceIdStr := c.Request.Header.Get("X-Trace-Id")
-	traceId, err := uuid.Parse(traceIdStr)
-	if err != nil {
-		traceId = uuid.New()
+// traceMiddleware starts the server span of the request, continuing the trace of a `traceparent`
+// or, as a fallback, the trace id of a legacy `X-Trace-Id`. The trace context is sent back in
+// both forms and the trace id stays in the context under middleware.TraceIdKey.
+func traceMiddleware(c *gin.Context) {
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if legacy, err := uuid.Parse(c.Request.Header.Get(middleware.XTraceIdKey)); err == nil {
+			ctx = tracing.WithTraceId(ctx, trace.TraceID(legacy))
+		}
+	}
+
+	route := c.FullPath()
+	name := c.Request.Method
+	if route != "" {
+		name += " " + route
 	}
+	ctx, span := tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+			semconv.UserAgentOriginal(c.Request.UserAgent()),
+		),
+	)
+	defer span.End()
 
+	traceId := uuid.UUID(span.SpanContext().TraceID())
 	c.Set(middleware.XTraceIdKey, traceId)
-	ctx := context.WithValue(c.Request.Context(), middleware.TraceIdKey, traceId)
+	ctx = context.WithValue(ctx, middleware.TraceIdKey, traceId)
 	c.Request = c.Request.WithContext(ctx)
 	c.Header(middleware.XTraceIdKey, traceId.String())
+	propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+
 	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
 }
 
-func responseLoggerMiddleware(c *gin.Context) {
-	var body any
-	if c.Request.Body != nil {
-		// Read the request body and reset it to its original state.
-		requestBody, _ := io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
-		var bodyMap map[string]any
-		err := json.Unmarshal(requestBody, &bodyMap)
-		if err != nil {
-			body = string(requestBody)
-		} else {
-			body = bodyMap
-		}
-	} else {
-		body = nil
+// languageMiddleware translates the messages of the request to the loaded language closest to
+// its `Accept-Language`, the default language without one
+func languageMiddleware(c *gin.Context) {
+	if header := c.GetHeader("Accept-Language"); header != "" {
+		c.Request = c.Request.WithContext(i18next.WithLang(c.Request.Context(), i18next.MatchHeader(header)))
 	}
 
-	// Capture the original response writer.
-	originalWriter := c.Writer
-	// Create a custom writer to capture the response body.
-	bodyCapture := &responseBodyCapture{ResponseWriter: originalWriter, body: bytes.NewBufferString("")}
-	c.Writer = bodyCapture
-
-	logResult := func() {
-		var response any
-		var resultMap map[string]any
-		context := make(map[string]any)
-		context["method"] = c.Request.Method
-		context["path"] = c.Request.URL.Path
-		context["params"] = utiles.SimplifyMap(c.Request.URL.Query())
-		context["req-headers"] = utiles.SimplifyMap(c.Request.Header)
-		context["res-headers"] = utiles.SimplifyMap(c.Writer.Header())
-		context["status"] = c.Writer.Status()
-
-		err := json.Unmarshal(bodyCapture.body.Bytes(), &resultMap)
-		if err != nil {
-			response = bodyCapture.body.String()
-		} else {
-			response = resultMap
+	c.Next()
+}
+
+// streamedContentTypes are bodies that can be endless or huge, they are passed through
+// without being buffered for the request log
+var streamedContentTypes = []string{
+	"text/event-stream",
+	"text/csv",
+	"text/calendar",
+	"application/x-ndjson",
+	"multipart/form-data",
+}
+
+func isStreamed(contentType string) bool {
+	for _, streamed := range streamedContentTypes {
+		if strings.HasPrefix(contentType, streamed) {
+			return true
 		}
+	}
+	return false
+}
 
-		status := "request "
-		level := slog.LevelInfo
-		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
-			status += "success - "
-		} else if c.Writer.Status() >= 400 && c.Writer.Status() < 500 {
-			status += "client error - "
-			level = slog.LevelError
-		} else if c.Writer.Status() >= 500 {
-			status += "server error - "
-			level = slog.LevelError
+// newResponseLoggerMiddleware logs each request with its response, the headers, params, fields
+// and path params of the redactor masked and the bodies capped. With conf.BodiesOnError the bodies
+// are only logged for the responses of status 400 and up.
+func newResponseLoggerMiddleware(slogger *slog.Logger, conf config.RequestLog, redactor *redact.Redactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody []byte
+		streamedRequest := isStreamed(c.ContentType())
+		if !streamedRequest && c.Request.Body != nil {
+			// Read the request body and reset it to its original state.
+			requestBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 
-		traceId, _ := c.Get(middleware.XTraceIdKey)
-		status += strconv.FormatInt(int64(c.Writer.Status()), 10)
+		// Capture the original response writer.
+		originalWriter := c.Writer
+		// Create a custom writer to capture the response body.
+		bodyCapture := &responseBodyCapture{ResponseWriter: originalWriter, body: bytes.NewBufferString("")}
+		c.Writer = bodyCapture
 
-		// Log the response information after the request is processed.
-		slogger.LogAttrs(nil, level, status,
-			slog.Any(middleware.TraceIdKey, traceId),
-			slog.Any(middleware.Body, body),
-			slog.Any(middleware.Response, response),
-			slog.Any(middleware.Context, context),
-		)
-	}
+		logResult := func() {
+			rules := redactor.ForRoute(c.Request.Method, c.FullPath())
+
+			context := make(map[string]any)
+			context["method"] = c.Request.Method
+			context["path"] = rules.Path(c.FullPath(), c.Request.URL.Path)
+			context["params"] = rules.Value(utiles.SimplifyMap(c.Request.URL.Query()))
+			context["req-headers"] = rules.Headers(c.Request.Header)
+			context["res-headers"] = rules.Headers(c.Writer.Header())
+			context["status"] = c.Writer.Status()
+
+			var body, response any
+			if !conf.BodiesOnError || c.Writer.Status() >= http.StatusBadRequest {
+				if streamedRequest {
+					body = "<streamed body not logged>"
+				} else {
+					body = rules.Body(requestBody)
+				}
+
+				if isStreamed(c.Writer.Header().Get("Content-Type")) {
+					response = "<streamed body not logged>"
+				} else {
+					response = rules.Body(bodyCapture.body.Bytes())
+				}
+			}
+
+			status := "request "
+			level := slog.LevelInfo
+			if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
+				status += "success - "
+			} else if c.Writer.Status() >= 400 && c.Writer.Status() < 500 {
+				status += "client error - "
+				level = slog.LevelError
+			} else if c.Writer.Status() >= 500 {
+				status += "server error - "
+				level = slog.LevelError
+			}
 
-	panicked := true
-	defer func() {
-		if r := recover(); r != nil || panicked {
 			traceId, _ := c.Get(middleware.XTraceIdKey)
+			status += strconv.FormatInt(int64(c.Writer.Status()), 10)
 
-			slogger.Debug(
-				"PANIC ",
+			attrs := []slog.Attr{
 				slog.Any(middleware.TraceIdKey, traceId),
-				slog.String(middleware.Error, fmt.Sprintf("%v", r)),
-				// The skip with 8 frames, come from the recovery functions from APM, Gin and Go
-				slog.Any(middleware.Stack, logger.Stacks(8)),
-			)
-			c.AbortWithStatusJSON(
-				http.StatusInternalServerError,
-				response.BaseResponse{
-					Payload: nil,
-					Meta: response.ErrResponse{
-						Message: "Internal Server Error",
-						Causes:  nil,
-						Code:    500,
-					},
-				},
-			)
-			logResult()
-		} else {
-			logResult()
+				slog.Any(middleware.Body, body),
+				slog.Any(middleware.Response, response),
+				slog.Any(middleware.Context, context),
+			}
+			// the causes of the errors, the clients only get their codes and details
+			if len(c.Errors) > 0 {
+				attrs = append(attrs, slog.Any(middleware.Error, c.Errors.Errors()))
+			}
+			if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+				attrs = append(attrs,
+					slog.String(middleware.OtelTraceIdKey, spanContext.TraceID().String()),
+					slog.String(middleware.OtelSpanIdKey, spanContext.SpanID().String()),
+				)
+			}
+
+			// Log the response information after the request is processed.
+			slogger.LogAttrs(nil, level, status, attrs...)
 		}
-	}()
-	c.Next()
-	panicked = false
 
-	// Restore the original writer.
-	c.Writer = originalWriter
+		panicked := true
+		defer func() {
+			if r := recover(); r != nil || panicked {
+				traceId, _ := c.Get(middleware.XTraceIdKey)
+
+				slogger.Debug(
+					"PANIC ",
+					slog.Any(middleware.TraceIdKey, traceId),
+					slog.String(middleware.Error, fmt.Sprintf("%v", r)),
+					// The skip with 8 frames, come from the recovery functions from APM, Gin and Go
+					slog.Any(middleware.Stack, logger.Stacks(8)),
+				)
+				response.HandelError(c, response.CodeUnknown.New(fmt.Errorf("panic: %v", r)))
+				logResult()
+			} else {
+				logResult()
+			}
+		}()
+		c.Next()
+		panicked = false
+
+		// Restore the original writer.
+		c.Writer = originalWriter
+	}
 }
 
 // responseBodyCapture is a custom ResponseWriter that captures the response body.
@@ -143,26 +220,56 @@ type responseBodyCapture struct {
 	body *bytes.Buffer
 }
 
+// Unwrap lets http.ResponseController reach the connection, e.g. for LiftDeadlines
+func (w *responseBodyCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LiftDeadlines lifts the read and write timeouts of the server for the requests which take as
+// long as they take, the streams and the exports and imports
+func LiftDeadlines(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		_ = c.Error(fmt.Errorf("cannot lift the read deadline: %w", err))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		_ = c.Error(fmt.Errorf("cannot lift the write deadline: %w", err))
+	}
+
+	c.Next()
+}
+
 // Write captures the response body and writes to the original writer.
 func (w *responseBodyCapture) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if !isStreamed(w.Header().Get("Content-Type")) {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
-func NewGinEngine(mode string) *gin.Engine {
+// NewGinEngine builds the engine with the common middlewares, `handlers` run around them, so they
+// see the response of a recovered panic too. The request log is written with slogger, JSON on
+// stdout when it is nil, and masks what redactor redacts, a nil one masks nothing.
+func NewGinEngine(mode string, slogger *slog.Logger, requestLog config.RequestLog, redactor *redact.Redactor, handlers ...gin.HandlerFunc) *gin.Engine {
+	if slogger == nil {
+		slogger = defaultSlogger
+	}
+
 	// Usingh New to drop the gin.Logger
 	r := gin.New()
 	// r.Use(recovery)
 	r.Use(cors.New(cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "traceparent", "tracestate", middleware.XTraceIdKey, IdempotencyKeyHeader},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 		AllowAllOrigins:  true,
 	}))
 
-	r.Use(traceIdMiddlewarefunc)
-	r.Use(responseLoggerMiddleware)
+	r.Use(handlers...)
+	r.Use(traceMiddleware)
+	r.Use(languageMiddleware)
+	r.Use(newResponseLoggerMiddleware(slogger, requestLog, redactor))
 
 	return r
 }
@@ -0,0 +1,324 @@
+package ginh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/idempotency"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/ratelimit"
+	"github.com/thealiakbari/todoapp/pkg/common/redact"
+	"github.com/thealiakbari/todoapp/pkg/common/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provider, err := tracing.NewTracerProvider(context.Background(), config.Tracing{}, "todoapp", config.ModeLocal)
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	var handlerSpan trace.SpanContext
+	var handlerTraceId any
+	r := NewGinEngine(config.ModeLocal, nil, config.RequestLog{}, nil)
+	r.GET("/items/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		handlerTraceId = c.Request.Context().Value(middleware.TraceIdKey)
+		c.Status(http.StatusInternalServerError)
+	})
+
+	t.Run("traceparent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerSpan.TraceID().String())
+		assert.Regexp(t, "^00-4bf92f3577b34da6a3ce929d0e0e4736-"+handlerSpan.SpanID().String()+"-01$", res.Header().Get("traceparent"))
+		assert.Equal(t, "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", res.Header().Get(middleware.XTraceIdKey))
+		assert.Equal(t, uuid.MustParse("4bf92f35-77b3-4da6-a3ce-929d0e0e4736"), handlerTraceId)
+
+		spans := recorder.Ended()
+		require.NotEmpty(t, spans)
+		span := spans[len(spans)-1]
+		assert.Equal(t, "GET /items/:id", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.Equal(t, "Error", span.Status().Code.String())
+	})
+
+	t.Run("legacy trace id", func(t *testing.T) {
+		legacy := uuid.New()
+		req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		req.Header.Set(middleware.XTraceIdKey, legacy.String())
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, trace.TraceID(legacy), handlerSpan.TraceID())
+		assert.Equal(t, legacy.String(), res.Header().Get(middleware.XTraceIdKey))
+		assert.False(t, recorder.Ended()[len(recorder.Ended())-1].Parent().IsValid())
+	})
+
+	t.Run("new trace", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		assert.True(t, handlerSpan.IsValid())
+		assert.Equal(t, uuid.UUID(handlerSpan.TraceID()).String(), res.Header().Get(middleware.XTraceIdKey))
+		assert.NotEmpty(t, res.Header().Get("traceparent"))
+	})
+}
+
+func TestResponseLoggerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	slogger := slog.New(slog.NewJSONHandler(&out, nil))
+
+	redactor := redact.New(config.Redaction{
+		Headers: []string{"Authorization"},
+		Fields:  []string{"password"},
+		Routes:  []config.RouteRedaction{{Method: http.MethodPost, Route: "/feeds/:token", Params: []string{"token"}}},
+	})
+	serve := func(t *testing.T, conf config.RequestLog, status int) map[string]any {
+		r := NewGinEngine(config.ModeLocal, slogger, conf, redactor)
+		r.POST("/feeds/:token", func(c *gin.Context) {
+			c.JSON(status, map[string]any{"password": "secret", "title": "kept"})
+		})
+
+		out.Reset()
+		req := httptest.NewRequest(http.MethodPost, "/feeds/abc?password=secret", bytes.NewBufferString(`{"password":"secret"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+		return record
+	}
+
+	t.Run("masked", func(t *testing.T) {
+		record := serve(t, config.RequestLog{}, http.StatusOK)
+
+		assert.NotContains(t, out.String(), "secret")
+		assert.Equal(t, map[string]any{"password": redact.Mask}, record[middleware.Body])
+		assert.Equal(t, map[string]any{"password": redact.Mask, "title": "kept"}, record[middleware.Response])
+
+		context := record[middleware.Context].(map[string]any)
+		assert.Equal(t, "/feeds/"+redact.Mask, context["path"])
+		assert.Equal(t, map[string]any{"password": redact.Mask}, context["params"])
+		assert.Equal(t, redact.Mask, context["req-headers"].(map[string]any)["Authorization"])
+	})
+
+	t.Run("bodies on error", func(t *testing.T) {
+		record := serve(t, config.RequestLog{BodiesOnError: true}, http.StatusOK)
+		assert.Nil(t, record[middleware.Body])
+		assert.Nil(t, record[middleware.Response])
+
+		record = serve(t, config.RequestLog{BodiesOnError: true}, http.StatusBadRequest)
+		assert.NotNil(t, record[middleware.Body])
+		assert.NotNil(t, record[middleware.Response])
+	})
+}
+
+func TestDeprecationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deprecation, err := NewDeprecationMiddleware(config.ApiVersion{})
+	require.NoError(t, err)
+	assert.Nil(t, deprecation, "not deprecated")
+
+	deprecation, err = NewDeprecationMiddleware(config.ApiVersion{
+		Deprecation: "2026-01-01T00:00:00Z",
+		Sunset:      "2026-07-01T00:00:00+02:00",
+		Link:        "https://example.com/migrate-to-v2",
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Group(APIV1.Path(), deprecation).GET("/todo-items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/todo-items", nil))
+
+	assert.Equal(t, "@1767225600", res.Header().Get("Deprecation"))
+	assert.Equal(t, "Tue, 30 Jun 2026 22:00:00 GMT", res.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/migrate-to-v2>; rel="deprecation"; type="text/html"`, res.Header().Get("Link"))
+
+	_, err = NewDeprecationMiddleware(config.ApiVersion{Sunset: "next summer"})
+	assert.Error(t, err)
+}
+
+// authenticate stands in for the authentication, it puts the id of a known X-Api-Key in the context
+func authenticate(keys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id, ok := keys[c.GetHeader("X-Api-Key")]; ok {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), middleware.ApiKeyIdKey, id))
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := config.RateLimit{Rules: []config.RateLimitRule{
+		{Name: "import", Route: "/todo-items/import", Methods: []string{http.MethodPost}, Limits: map[string]config.RateLimitBucket{
+			IdentityIP: {Requests: 1, Period: "1m"},
+		}},
+		{Name: "api", Limits: map[string]config.RateLimitBucket{
+			IdentityApiKey: {Requests: 10, Period: "1m", Burst: 2},
+			IdentityIP:     {Requests: 10, Period: "1m", Burst: 3},
+		}},
+	}}
+
+	rateLimit, err := NewRateLimitMiddleware(conf, nil, APIV1.Path())
+	require.NoError(t, err)
+	assert.Nil(t, rateLimit, "no store")
+
+	store := ratelimit.NewMemoryStore()
+	r := gin.New()
+	r.Use(authenticate(map[string]string{"secret-a": "key-a", "secret-b": "key-b"}))
+	for _, version := range APIVersions {
+		rateLimit, err := NewRateLimitMiddleware(conf, store, version.Path())
+		require.NoError(t, err)
+		group := r.Group(version.Path(), rateLimit)
+		group.POST("/todo-items/import", func(c *gin.Context) { c.Status(http.StatusOK) })
+		group.GET("/todo-items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	serve := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodPost, "/api/v1/todo-items/import", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "1", res.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", res.Header().Get("RateLimit-Reset"))
+
+	res = serve(http.MethodPost, "/api/v2/todo-items/import", "")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code, "the versions share the buckets")
+	assert.Equal(t, "60", res.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/todo-items", "secret-a").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/todo-items", "secret-a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/api/v1/todo-items", "secret-a").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/todo-items", "secret-b").Code, "the buckets are by identity")
+
+	// keys which are not verified are no identity, rotating them still drains the bucket of the IP
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/todo-items", uuid.NewString()).Code)
+	}
+	res = serve(http.MethodGet, "/api/v1/todo-items", uuid.NewString())
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "3", res.Header().Get("RateLimit-Limit"))
+
+	conf.Rules[1].Limits["tenant"] = config.RateLimitBucket{Requests: 1, Period: "1s"}
+	_, err = NewRateLimitMiddleware(conf, store, APIV1.Path())
+	assert.Error(t, err)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	idempotent, err := NewIdempotencyMiddleware(config.Idempotency{}, idempotency.NewMemoryStore())
+	require.NoError(t, err)
+	assert.Nil(t, idempotent, "no ttl")
+
+	idempotent, err = NewIdempotencyMiddleware(config.Idempotency{TTL: "1h", LockTimeout: "1m"}, idempotency.NewMemoryStore())
+	require.NoError(t, err)
+
+	created := 0
+	started, release := make(chan struct{}), make(chan struct{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("RateLimit-Remaining", strconv.Itoa(created))
+	}, idempotent)
+	r.POST("/todo-items", func(c *gin.Context) {
+		created++
+		c.Header("Location", "/todo-items/"+strconv.Itoa(created))
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+	r.POST("/slow", func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/failing", func(c *gin.Context) {
+		created++
+		c.Status(http.StatusInternalServerError)
+	})
+	serveFrom := func(remoteAddr, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		return res
+	}
+	serve := func(path, key, body string) *httptest.ResponseRecorder {
+		return serveFrom("192.0.2.1:1234", path, key, body)
+	}
+
+	first := serve("/todo-items", "a", `{"description":"milk"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	retry := serve("/todo-items", "a", `{"description":"milk"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/todo-items/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "1", retry.Header().Get("RateLimit-Remaining"), "the headers of the other middlewares are not replayed")
+	assert.Equal(t, 1, created)
+
+	assert.Equal(t, http.StatusConflict, serve("/todo-items", "a", `{"description":"bread"}`).Code, "another payload")
+	assert.Equal(t, http.StatusCreated, serve("/todo-items", "b", `{"description":"bread"}`).Code)
+	assert.Equal(t, http.StatusCreated, serve("/todo-items", "", `{"description":"bread"}`).Code)
+	assert.Equal(t, 3, created)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve("/slow", "c", "") }()
+	<-started
+	inProgress := serve("/slow", "c", "")
+	assert.Equal(t, http.StatusConflict, inProgress.Code)
+	assert.Equal(t, "1", inProgress.Header().Get("Retry-After"))
+	close(release)
+	assert.Equal(t, http.StatusNoContent, (<-done).Code)
+	assert.Equal(t, http.StatusNoContent, serve("/slow", "c", "").Code)
+
+	serve("/failing", "d", "")
+	serve("/failing", "d", "")
+	assert.Equal(t, 5, created, "server errors are not kept")
+
+	other := serveFrom("192.0.2.2:1234", "/todo-items", "a", `{"description":"milk"}`)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Empty(t, other.Header().Get(IdempotentReplayedHeader), "the keys of another client are not shared")
+	assert.NotEqual(t, first.Body.String(), other.Body.String())
+	assert.Equal(t, 6, created)
+}
@@ -0,0 +1,179 @@
+package ginh
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/idempotency"
+	"github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+var idempotentMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// NewIdempotencyMiddleware replays the response of a POST, PUT, PATCH or DELETE retried with the
+// same `Idempotency-Key` of the same user, or API key, for the ttl of conf. A key sent with another
+// request, or whose request is still in progress, answers 409. Responses of status 500 and up are
+// not kept, the request can be retried. Streamed bodies, e.g. the imports, are not fingerprinted
+// and go through. It is nil when conf has no ttl or there is no store.
+func NewIdempotencyMiddleware(conf config.Idempotency, store idempotency.Store) (gin.HandlerFunc, error) {
+	if conf.TTL == "" || store == nil {
+		return nil, nil
+	}
+
+	ttl, err := time.ParseDuration(string(conf.TTL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid idempotency ttl: %w", err)
+	}
+	var lockTimeout time.Duration
+	if conf.LockTimeout != "" {
+		if lockTimeout, err = time.ParseDuration(string(conf.LockTimeout)); err != nil {
+			return nil, fmt.Errorf("invalid idempotency lock timeout: %w", err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !slices.Contains(idempotentMethods, c.Request.Method) || isStreamed(c.ContentType()) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			err := fmt.Errorf("the %s header is longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+			response.HandelError(c, response.CodeBadRequest.NewWithDetail(err, err.Error()))
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			read, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				response.HandelError(c, response.CodeBadRequest.NewWithDetail(err, err.Error()))
+				return
+			}
+			body = read
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		now := time.Now().UTC()
+		claim := idempotency.Record{
+			Scope:       idempotencyScope(c),
+			Key:         key,
+			Fingerprint: fingerprint(c.Request, body),
+			Header:      "{}",
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		record, claimed, err := store.Claim(c.Request.Context(), claim, lockTimeout)
+		if err != nil {
+			response.HandelError(c, response.CodeUnknown.New(fmt.Errorf("idempotency key: %w", err)))
+			return
+		}
+		if !claimed {
+			replay(c, claim, record)
+			return
+		}
+
+		// the response is kept even when the client is gone, it is the one retrying
+		ctx := context.WithoutCancel(c.Request.Context())
+		before := c.Writer.Header().Clone()
+		capture := &responseBodyCapture{ResponseWriter: c.Writer, body: bytes.NewBufferString("")}
+		c.Writer = capture
+
+		done := false
+		defer func() {
+			c.Writer = capture.ResponseWriter
+			if !done {
+				// panicked, the request can be made again
+				_ = store.Release(ctx, claim.Scope, claim.Key)
+			}
+		}()
+		c.Next()
+		done = true
+
+		if err := complete(ctx, store, claim, capture, before); err != nil {
+			_ = c.Error(fmt.Errorf("idempotency key: %w", err))
+		}
+	}, nil
+}
+
+// complete keeps the response of the claimed key, the headers set before the request ran, e.g.
+// the rate limits, are left out since they are set again on the replay
+func complete(ctx context.Context, store idempotency.Store, claim idempotency.Record, capture *responseBodyCapture, before http.Header) error {
+	if capture.Status() >= http.StatusInternalServerError || isStreamed(capture.Header().Get("Content-Type")) {
+		return store.Release(ctx, claim.Scope, claim.Key)
+	}
+
+	header := make(http.Header)
+	for name, values := range capture.Header() {
+		if !slices.Equal(before[name], values) {
+			header[name] = values
+		}
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	claim.Status = capture.Status()
+	claim.Header = string(encoded)
+	claim.Body = capture.body.Bytes()
+	return store.Complete(ctx, claim)
+}
+
+func replay(c *gin.Context, claim, record idempotency.Record) {
+	switch {
+	case record.Fingerprint != claim.Fingerprint:
+		response.HandelError(c, response.CodeIdempotencyKeyReused.New(fmt.Errorf("idempotency key %q was used for another request", claim.Key)))
+	case record.InProgress():
+		c.Header("Retry-After", "1")
+		response.HandelError(c, response.CodeIdempotencyKeyInProgress.New(fmt.Errorf("idempotency key %q is in progress", claim.Key)))
+	default:
+		var header http.Header
+		if err := json.Unmarshal([]byte(record.Header), &header); err != nil {
+			response.HandelError(c, response.CodeUnknown.New(fmt.Errorf("idempotency key %q: %w", claim.Key, err)))
+			return
+		}
+		for name, values := range header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Status(record.Status)
+		_, _ = c.Writer.Write(record.Body)
+		c.Abort()
+	}
+}
+
+// idempotencyScope is the client the keys belong to, the verified user or API key of the request
+// and else its IP, so clients never see the responses of each other
+func idempotencyScope(c *gin.Context) string {
+	for _, kind := range identities {
+		if id := identityOf(c, kind); id != "" {
+			return kind + ":" + id
+		}
+	}
+	return ""
+}
+
+// fingerprint tells the requests of a key apart, by method, URL and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
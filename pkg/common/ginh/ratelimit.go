@@ -0,0 +1,178 @@
+package ginh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/ratelimit"
+	"github.com/thealiakbari/todoapp/pkg/common/response"
+)
+
+// The identities a request is limited by, in the order they are looked for
+const (
+	IdentityUser   = "user"
+	IdentityApiKey = "api_key"
+	IdentityIP     = "ip"
+)
+
+var identities = []string{IdentityUser, IdentityApiKey, IdentityIP}
+
+type rateLimitRule struct {
+	name    string
+	route   string
+	methods []string
+	limits  map[string]ratelimit.Limit
+}
+
+func newRateLimitRule(conf config.RateLimitRule) (rateLimitRule, error) {
+	if conf.Name == "" {
+		return rateLimitRule{}, errors.New("the rule has no name")
+	}
+
+	rule := rateLimitRule{
+		name:    conf.Name,
+		route:   conf.Route,
+		methods: conf.Methods,
+		limits:  make(map[string]ratelimit.Limit, len(conf.Limits)),
+	}
+	for identity, bucket := range conf.Limits {
+		if identity != IdentityUser && identity != IdentityApiKey && identity != IdentityIP {
+			return rateLimitRule{}, fmt.Errorf("unknown identity %q", identity)
+		}
+
+		period, err := time.ParseDuration(string(bucket.Period))
+		if err != nil {
+			return rateLimitRule{}, fmt.Errorf("invalid period of %s: %w", identity, err)
+		}
+		if bucket.Requests <= 0 || period <= 0 || bucket.Burst < 0 {
+			return rateLimitRule{}, fmt.Errorf("the limit of %s needs positive requests and period", identity)
+		}
+
+		burst := bucket.Burst
+		if burst == 0 {
+			burst = bucket.Requests
+		}
+		rule.limits[identity] = ratelimit.Limit{Rate: float64(bucket.Requests) / period.Seconds(), Burst: burst}
+	}
+
+	return rule, nil
+}
+
+func (r rateLimitRule) matches(method, route string) bool {
+	if !strings.HasPrefix(route, r.route) {
+		return false
+	}
+	if len(r.methods) == 0 {
+		return true
+	}
+	for _, m := range r.methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// identity is the first identity of the request the rule has a limit for
+func (r rateLimitRule) identity(c *gin.Context) (string, string, ratelimit.Limit, bool) {
+	for _, kind := range identities {
+		limit, ok := r.limits[kind]
+		if !ok {
+			continue
+		}
+		if id := identityOf(c, kind); id != "" {
+			return kind, id, limit, true
+		}
+	}
+
+	return "", "", ratelimit.Limit{}, false
+}
+
+// identityOf is the id of the request of the kind, empty when it has none. Users and API keys
+// are only taken from the context, where the authentication puts them once verified, so a client
+// cannot pick its bucket with a header and falls back to its IP.
+func identityOf(c *gin.Context, kind string) string {
+	switch kind {
+	case IdentityUser:
+		id, _ := middleware.GetUserReferenceId(c.Request.Context())
+		return id
+	case IdentityApiKey:
+		id, _ := middleware.GetApiKeyId(c.Request.Context())
+		return id
+	default:
+		return c.ClientIP()
+	}
+}
+
+// NewRateLimitMiddleware limits the requests with the token buckets of store, by the first rule of
+// conf matching the method and the route below basePath, e.g. `/todo-items` of `/api/v1/todo-items`.
+// The rules are the same for every version and so are their buckets. A request answers 429 once
+// its bucket is empty, a store which fails lets it through. It is nil without rules or store.
+func NewRateLimitMiddleware(conf config.RateLimit, store ratelimit.Store, basePath string) (gin.HandlerFunc, error) {
+	if len(conf.Rules) == 0 || store == nil {
+		return nil, nil
+	}
+
+	rules := make([]rateLimitRule, 0, len(conf.Rules))
+	for i, ruleConf := range conf.Rules {
+		rule, err := newRateLimitRule(ruleConf)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %d: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return func(c *gin.Context) {
+		route := strings.TrimPrefix(c.FullPath(), basePath)
+		for _, rule := range rules {
+			if rule.matches(c.Request.Method, route) {
+				if !rule.take(c, store) {
+					return
+				}
+				break
+			}
+		}
+
+		c.Next()
+	}, nil
+}
+
+// take takes a token of the bucket of the request, it is false when the request is answered 429.
+// The headers tell the client how much is left of its bucket.
+func (r rateLimitRule) take(c *gin.Context, store ratelimit.Store) bool {
+	kind, id, limit, ok := r.identity(c)
+	if !ok {
+		return true
+	}
+
+	res, err := store.Take(c.Request.Context(), r.name+":"+kind+":"+id, limit)
+	if err != nil {
+		// logged with the request, the API stays up without its store
+		_ = c.Error(fmt.Errorf("rate limit %s: %w", r.name, err))
+		return true
+	}
+
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	header.Set("RateLimit-Reset", seconds(res.Reset))
+	if !res.Allowed {
+		header.Set("Retry-After", seconds(res.RetryAfter))
+		response.HandelError(c, response.CodeRateLimited.New(fmt.Errorf("rate limit %s of %s %s exceeded", r.name, kind, id)))
+		return false
+	}
+
+	return true
+}
+
+// seconds are the whole seconds of the header values, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,62 @@
+package ginh
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+// APIVersion is the path segment of a version of the API, e.g. `/api/v2`
+type APIVersion string
+
+const (
+	APIV1 APIVersion = "v1"
+	APIV2 APIVersion = "v2"
+)
+
+// APIVersions are the served versions, oldest first
+var APIVersions = []APIVersion{APIV1, APIV2}
+
+// Path is the prefix of the routes of the version
+func (v APIVersion) Path() string {
+	return "/api/" + string(v)
+}
+
+// NewDeprecationMiddleware signals clients the version is going away, with the `Deprecation`
+// (RFC 9745), `Sunset` (RFC 8594) and `Link` headers of conf. It is nil when conf has none.
+func NewDeprecationMiddleware(conf config.ApiVersion) (gin.HandlerFunc, error) {
+	if conf.Deprecation == "" && conf.Sunset == "" && conf.Link == "" {
+		return nil, nil
+	}
+
+	headers := make(http.Header)
+	if conf.Deprecation != "" {
+		deprecation, err := time.Parse(time.RFC3339, conf.Deprecation)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deprecation %q: %w", conf.Deprecation, err)
+		}
+		headers.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+	}
+	if conf.Sunset != "" {
+		sunset, err := time.Parse(time.RFC3339, conf.Sunset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunset %q: %w", conf.Sunset, err)
+		}
+		headers.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	if conf.Link != "" {
+		headers.Set("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, conf.Link))
+	}
+
+	return func(c *gin.Context) {
+		for key, values := range headers {
+			c.Writer.Header()[key] = values
+		}
+
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,214 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOk       = "ok"
+	StatusFailing  = "failing"
+	StatusStarting = "starting"
+	StatusDraining = "draining"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Checker checks a dependency the service cannot serve without
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc turns a function into a Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Registry answers the liveness, readiness and startup probes from its named checkers and the
+// lifecycle of the process: it starts once Started is called and drains from Drain on.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	checkers []namedChecker
+	started  atomic.Bool
+	draining atomic.Bool
+
+	// the last check, the probes in the cache TTL share it
+	checkMu   sync.Mutex
+	checkedAt time.Time
+	lastOk    bool
+	last      map[string]CheckResult
+}
+
+// NewRegistry gives each check timeout to answer, 0 is 2s. The results are reused for cacheTTL,
+// so frequent probes do not load the dependencies, 0 checks on every probe.
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkers = append(r.checkers, namedChecker{name: name, checker: checker})
+}
+
+// Started marks the end of the startup, e.g. the migrations applied and the listeners open
+func (r *Registry) Started() {
+	r.started.Store(true)
+}
+
+// Drain fails the readiness from now on, so load balancers stop sending requests before the
+// server shuts down
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// CheckResult is the outcome of one checker
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Report is the body of the probes, the errors of the checks are only in the one of Details
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Check runs the checkers concurrently, each with the timeout of the registry, or answers the
+// results of the last run within the cache TTL. Concurrent calls wait for one run. The results
+// are shared and must not be changed.
+func (r *Registry) Check(ctx context.Context) (ok bool, results map[string]CheckResult) {
+	r.checkMu.Lock()
+	defer r.checkMu.Unlock()
+
+	if r.last != nil && time.Since(r.checkedAt) < r.cacheTTL {
+		return r.lastOk, r.last
+	}
+
+	// the results are shared, a probe that gives up must not fail them for the others
+	ok, results = r.check(context.WithoutCancel(ctx))
+	r.checkedAt, r.lastOk, r.last = time.Now(), ok, results
+	return ok, results
+}
+
+func (r *Registry) check(ctx context.Context) (ok bool, results map[string]CheckResult) {
+	r.mu.RLock()
+	checkers := append([]namedChecker{}, r.checkers...)
+	r.mu.RUnlock()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	ok = true
+	results = make(map[string]CheckResult, len(checkers))
+	for _, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			begin := time.Now()
+			err := c.checker.Check(checkCtx)
+			result := CheckResult{Status: StatusOk, DurationMs: float64(time.Since(begin).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = StatusFailing
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[c.name] = result
+			ok = ok && err == nil
+		}()
+	}
+	wg.Wait()
+
+	return ok, results
+}
+
+// Live answers as long as the process serves requests, draining included, the dependencies are
+// not checked since restarting would not bring them back
+func (r *Registry) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, Report{Status: StatusOk})
+}
+
+// Ready fails while the service starts, drains or misses a dependency
+func (r *Registry) Ready(c *gin.Context) {
+	switch {
+	case r.draining.Load():
+		c.JSON(http.StatusServiceUnavailable, Report{Status: StatusDraining})
+	case !r.started.Load():
+		c.JSON(http.StatusServiceUnavailable, Report{Status: StatusStarting})
+	default:
+		r.respond(c)
+	}
+}
+
+// Startup fails until the service started with its dependencies available
+func (r *Registry) Startup(c *gin.Context) {
+	if !r.started.Load() {
+		c.JSON(http.StatusServiceUnavailable, Report{Status: StatusStarting})
+		return
+	}
+
+	r.respond(c)
+}
+
+// Details answers the report of the checks with their errors, for the admin listener only since
+// the errors may tell about the infrastructure
+func (r *Registry) Details(c *gin.Context) {
+	r.report(c, true)
+}
+
+func (r *Registry) respond(c *gin.Context) {
+	r.report(c, false)
+}
+
+func (r *Registry) report(c *gin.Context, withErrors bool) {
+	ok, results := r.Check(c.Request.Context())
+	if !withErrors {
+		public := make(map[string]CheckResult, len(results))
+		for name, result := range results {
+			result.Error = ""
+			public[name] = result
+		}
+		results = public
+	}
+
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, Report{Status: StatusFailing, Checks: results})
+		return
+	}
+
+	c.JSON(http.StatusOK, Report{Status: StatusOk, Checks: results})
+}
+
+// RegisterRoutes serves the probes under `/healthz`
+func (r *Registry) RegisterRoutes(router gin.IRoutes) {
+	router.GET("/healthz/live", r.Live)
+	router.GET("/healthz/ready", r.Ready)
+	router.GET("/healthz/startup", r.Startup)
+}
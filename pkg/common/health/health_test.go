@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var dbErr error
+	registry := NewRegistry(10*time.Millisecond, 0)
+	registry.Register("db", CheckerFunc(func(ctx context.Context) error { return dbErr }))
+	registry.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	r := gin.New()
+	registry.RegisterRoutes(r)
+	r.GET("/details", func(c *gin.Context) { registry.Details(c) })
+	probe := func(t *testing.T, path string) (int, Report) {
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var report Report
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &report))
+		return res.Code, report
+	}
+
+	t.Run("starting", func(t *testing.T) {
+		code, report := probe(t, "/healthz/startup")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusStarting, report.Status)
+
+		code, _ = probe(t, "/healthz/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+
+		code, _ = probe(t, "/healthz/live")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	registry.Started()
+
+	t.Run("timeout", func(t *testing.T) {
+		code, report := probe(t, "/healthz/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusFailing, report.Status)
+		assert.Equal(t, StatusOk, report.Checks["db"].Status)
+		assert.Equal(t, StatusFailing, report.Checks["slow"].Status)
+		assert.Empty(t, report.Checks["slow"].Error, "the probes leave the errors out")
+
+		code, report = probe(t, "/details")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	})
+
+	registry = NewRegistry(0, 0)
+	registry.Register("db", CheckerFunc(func(ctx context.Context) error { return dbErr }))
+	registry.Started()
+	r = gin.New()
+	registry.RegisterRoutes(r)
+
+	t.Run("ready", func(t *testing.T) {
+		code, report := probe(t, "/healthz/ready")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, StatusOk, report.Checks["db"].Status)
+
+		dbErr = errors.New("connection refused")
+		code, report = probe(t, "/healthz/startup")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusFailing, report.Checks["db"].Status)
+		assert.Empty(t, report.Checks["db"].Error)
+		dbErr = nil
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		registry.Drain()
+
+		code, report := probe(t, "/healthz/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusDraining, report.Status)
+
+		code, _ = probe(t, "/healthz/live")
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry(0, time.Minute)
+	registry.Register("db", CheckerFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	ok, _ := registry.Check(context.Background())
+	assert.True(t, ok)
+
+	// a canceled probe neither runs the checks again nor fails the cached results
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ok, results := registry.Check(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, StatusOk, results["db"].Status)
+	assert.Equal(t, int32(1), calls.Load())
+
+	registry.checkedAt = time.Now().Add(-time.Minute)
+	registry.Check(context.Background())
+	assert.Equal(t, int32(2), calls.Load(), "the results expire")
+}
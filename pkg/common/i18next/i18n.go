@@ -2,7 +2,9 @@ package i18next
 
 import (
 	"context"
+	"embed"
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,9 +15,18 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+const langKey = "lang"
+
+// locales are the default messages, the files of `assets/locales` add to and override them
+//
+//go:embed locales/*.toml
+var locales embed.FS
+
 var (
 	languages   map[language.Tag]*i18n.Localizer
 	defaultLang language.Tag
+	supported   []language.Tag
+	matcher     language.Matcher
 )
 
 func NewLanguage(defaultLng language.Tag) error {
@@ -23,6 +34,17 @@ func NewLanguage(defaultLng language.Tag) error {
 	defaultLang = defaultLng
 	bundle := i18n.NewBundle(defaultLang)
 	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	if err := fs.WalkDir(locales, "locales", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if _, err = bundle.LoadMessageFileFS(locales, path); err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return errors.New("can not read the default locale files")
+	}
 	if err := filepath.Walk("assets/locales", func(path string, info os.FileInfo, err error) error {
 		if !strings.HasSuffix(path, "toml") {
 			return nil
@@ -35,15 +57,53 @@ func NewLanguage(defaultLng language.Tag) error {
 		return errors.New("can not read locale files")
 	}
 
+	// the first one is the fallback of the matcher
+	supported = []language.Tag{defaultLang}
 	for _, tag := range bundle.LanguageTags() {
 		languages[tag] = i18n.NewLocalizer(bundle, tag.String())
+		if tag != defaultLang {
+			supported = append(supported, tag)
+		}
 	}
+	matcher = language.NewMatcher(supported)
 
 	return nil
 }
 
+// Match is the loaded language closest to the accepted ones, e.g. of an `Accept-Language`
+// header, the default language when none is close
+func Match(accepted ...language.Tag) language.Tag {
+	if matcher == nil || len(accepted) == 0 {
+		return defaultLang
+	}
+	_, index, confidence := matcher.Match(accepted...)
+	if confidence == language.No {
+		return defaultLang
+	}
+
+	return supported[index]
+}
+
+// MatchHeader is the loaded language closest to an `Accept-Language` header
+func MatchHeader(acceptLanguage string) language.Tag {
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return defaultLang
+	}
+	return Match(accepted...)
+}
+
+// WithLang sets the language ByContext translates to
+func WithLang(ctx context.Context, lang language.Tag) context.Context {
+	//nolint:staticcheck // GetValue reads the plain key, like the grpc metadata
+	return context.WithValue(ctx, langKey, lang.String())
+}
+
 func ByLangWithData(lang language.Tag, id string, data interface{}) string {
 	local, ok := languages[lang]
+	if !ok && lang != language.Und {
+		local, ok = languages[Match(lang)]
+	}
 	if !ok {
 		if local, ok = languages[defaultLang]; !ok {
 			return id
@@ -74,7 +134,7 @@ func ByContext(ctx context.Context, id string) string {
 }
 
 func GetLang(ctx context.Context) (language.Tag, bool) {
-	value, ok := GetValue(ctx, "lang")
+	value, ok := GetValue(ctx, langKey)
 	if !ok {
 		return language.Und, false
 	}
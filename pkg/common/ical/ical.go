@@ -0,0 +1,328 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Minimal RFC 5545 reader and writer, enough to exchange VCALENDAR/VTODO data.
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	DateTimeLayout = "20060102T150405Z"
+	DateLayout     = "20060102"
+	localLayout    = "20060102T150405"
+
+	maxLineOctets = 75
+)
+
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []Component
+}
+
+func NewComponent(name string) Component {
+	return Component{Name: strings.ToUpper(name)}
+}
+
+// Add appends a property with a raw value, use AddText for TEXT values that need escaping
+func (c *Component) Add(name string, value string, params ...string) {
+	prop := Property{Name: strings.ToUpper(name), Value: value}
+	if len(params) > 0 {
+		prop.Params = make(map[string]string, len(params)/2)
+		for i := 0; i+1 < len(params); i += 2 {
+			prop.Params[strings.ToUpper(params[i])] = params[i+1]
+		}
+	}
+	c.Properties = append(c.Properties, prop)
+}
+
+func (c *Component) AddText(name string, value string) {
+	c.Add(name, EscapeText(value))
+}
+
+func (c *Component) AddTime(name string, t time.Time) {
+	c.Add(name, t.UTC().Format(DateTimeLayout))
+}
+
+func (c Component) Get(name string) (Property, bool) {
+	name = strings.ToUpper(name)
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+
+	return Property{}, false
+}
+
+func (c Component) GetText(name string) string {
+	prop, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+
+	return UnescapeText(prop.Value)
+}
+
+// GetTime reads DATE and DATE-TIME values, floating times and unknown TZIDs are taken as UTC
+func (c Component) GetTime(name string) (res time.Time, ok bool, err error) {
+	prop, ok := c.Get(name)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if prop.Params["VALUE"] == "DATE" || len(prop.Value) == len(DateLayout) {
+		res, err = time.Parse(DateLayout, prop.Value)
+		return res, true, err
+	}
+
+	if strings.HasSuffix(prop.Value, "Z") {
+		res, err = time.Parse(DateTimeLayout, prop.Value)
+		return res, true, err
+	}
+
+	loc := time.UTC
+	if tzid, ok := prop.Params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	res, err = time.ParseInLocation(localLayout, prop.Value, loc)
+	return res.UTC(), true, err
+}
+
+func (c Component) Find(name string) []Component {
+	name = strings.ToUpper(name)
+	var res []Component
+	for _, sub := range c.Components {
+		if sub.Name == name {
+			res = append(res, sub)
+		}
+		res = append(res, sub.Find(name)...)
+	}
+
+	return res
+}
+
+func (c Component) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := c.encode(bw); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (c Component) encode(w *bufio.Writer) error {
+	if err := writeLine(w, "BEGIN:"+c.Name); err != nil {
+		return err
+	}
+
+	for _, prop := range c.Properties {
+		if err := writeLine(w, prop.String()); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range c.Components {
+		if err := sub.encode(w); err != nil {
+			return err
+		}
+	}
+
+	return writeLine(w, "END:"+c.Name)
+}
+
+func (p Property) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Name)
+
+	keys := make([]string, 0, len(p.Params))
+	for key := range p.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := p.Params[key]
+		if strings.ContainsAny(value, ":;,") {
+			value = `"` + value + `"`
+		}
+		sb.WriteString(";" + key + "=" + value)
+	}
+
+	sb.WriteString(":" + p.Value)
+	return sb.String()
+}
+
+// writeLine folds content lines longer than 75 octets without splitting UTF-8 sequences
+func writeLine(w *bufio.Writer, line string) error {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		if _, err := w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+
+	_, err := w.WriteString(line + "\r\n")
+	return err
+}
+
+// Decode parses every top level component of the stream, usually a single VCALENDAR
+func Decode(r io.Reader) (res []Component, err error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformed, i+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			stack = append(stack, &Component{Name: strings.ToUpper(prop.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrMalformed, i+1, prop.Value)
+			}
+
+			done := *stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				res = append(res, done)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, done)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: line %d: property outside of a component", ErrMalformed, i+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrMalformed, stack[len(stack)-1].Name)
+	}
+
+	return res, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return Property{}, errors.New("missing ':' separator")
+	}
+
+	head := splitOutsideQuotes(line[:colon], ';')
+	prop := Property{
+		Name:  strings.ToUpper(head[0]),
+		Value: line[colon+1:],
+	}
+
+	for _, param := range head[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return Property{}, fmt.Errorf("invalid parameter %q", param)
+		}
+		if prop.Params == nil {
+			prop.Params = make(map[string]string)
+		}
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	var res []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			res = append(res, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(res, s[start:])
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func UnescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
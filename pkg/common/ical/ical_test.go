@@ -0,0 +1,54 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	todo := NewComponent("VTODO")
+	todo.Add("UID", "42@example.com")
+	todo.AddText("SUMMARY", strings.Repeat("Buy milk, eggs; and ünïcödé ", 5))
+	todo.Add("DUE", "20250906", "VALUE", "DATE")
+	cal := NewComponent("VCALENDAR")
+	cal.Components = append(cal.Components, todo)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cal.Encode(&buf))
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+
+	decoded, err := Decode(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 1)
+
+	todos := decoded[0].Find("VTODO")
+	assert.Len(t, todos, 1)
+	assert.Equal(t, "42@example.com", todos[0].GetText("UID"))
+	assert.Equal(t, strings.Repeat("Buy milk, eggs; and ünïcödé ", 5), todos[0].GetText("SUMMARY"))
+
+	due, ok, err := todos[0].GetTime("DUE")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 9, 6, 0, 0, 0, 0, time.UTC), due)
+}
+
+func TestGetTime_TZID(t *testing.T) {
+	decoded, err := Decode(strings.NewReader("BEGIN:VTODO\r\nDUE;TZID=\"Europe/Berlin\":20250905T180000\r\nEND:VTODO\r\n"))
+	assert.NoError(t, err)
+
+	due, ok, err := decoded[0].GetTime("DUE")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 9, 5, 16, 0, 0, 0, time.UTC), due)
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	_, err := Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n"))
+	assert.ErrorIs(t, err, ErrMalformed)
+}
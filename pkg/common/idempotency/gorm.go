@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewGormStore keeps the keys in the `idempotency_keys` table of db, shared by the replicas
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{
+		db:        db,
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Claim drops the key when it can be claimed anew, then inserts it unless another request did
+// first. The statements run apart from the transaction of the request.
+func (s *gormStore) Claim(ctx context.Context, in Record, lockTimeout time.Duration) (Record, bool, error) {
+	// in UTC, SQLite compares the times as text
+	now := s.now().UTC()
+	if err := s.sweep(ctx, now); err != nil {
+		return Record{}, false, err
+	}
+
+	stale := s.db.WithContext(ctx).Where("expires_at <= ?", now)
+	if lockTimeout > 0 {
+		stale = stale.Or("status = 0 AND created_at <= ?", now.Add(-lockTimeout))
+	}
+	err := s.db.WithContext(ctx).
+		Where("scope = ? AND idempotency_key = ?", in.Scope, in.Key).
+		Where(stale).
+		Delete(&Record{}).Error
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&in)
+	if res.Error != nil {
+		return Record{}, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return in, true, nil
+	}
+
+	var record Record
+	err = s.db.WithContext(ctx).Limit(1).Find(&record, "scope = ? AND idempotency_key = ?", in.Scope, in.Key).Error
+	if err != nil {
+		return Record{}, false, err
+	}
+	if record.Key == "" {
+		// dropped in between, by a request which claimed it once more
+		return s.Claim(ctx, in, lockTimeout)
+	}
+
+	return record, false, nil
+}
+
+func (s *gormStore) Complete(ctx context.Context, in Record) error {
+	return s.db.WithContext(ctx).Model(&Record{}).
+		Where("scope = ? AND idempotency_key = ? AND fingerprint = ? AND status = 0", in.Scope, in.Key, in.Fingerprint).
+		Updates(map[string]any{"status": in.Status, "header": in.Header, "body": in.Body}).Error
+}
+
+func (s *gormStore) Release(ctx context.Context, scope, key string) error {
+	return s.db.WithContext(ctx).
+		Where("scope = ? AND idempotency_key = ? AND status = 0", scope, key).
+		Delete(&Record{}).Error
+}
+
+// sweep deletes the expired keys, at most once per sweepInterval of each replica
+func (s *gormStore) sweep(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	return s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&Record{}).Error
+}
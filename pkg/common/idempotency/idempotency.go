@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a key of a client, with the response of its request once it is done
+type Record struct {
+	// Scope is the client the key belongs to, e.g. `user:<id>`, keys of different scopes never meet
+	Scope       string `gorm:"column:scope;type:text;primaryKey"`
+	Key         string `gorm:"column:idempotency_key;type:text;primaryKey"`
+	Fingerprint string `gorm:"column:fingerprint;type:text;not null"`
+	// Status of the response, zero while the request is in progress
+	Status    int       `gorm:"column:status;not null"`
+	Header    string    `gorm:"column:header;type:text;not null"`
+	Body      []byte    `gorm:"column:body"`
+	CreatedAt time.Time `gorm:"column:created_at;not null"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// InProgress tells the request of the key has no response yet
+func (r Record) InProgress() bool {
+	return r.Status == 0
+}
+
+// Store keeps the keys until they expire
+type Store interface {
+	// Claim claims the key of in for its request, it is false with the record of the key when the
+	// key is claimed already. An expired key, or one in progress since lockTimeout, is claimed anew.
+	Claim(ctx context.Context, in Record, lockTimeout time.Duration) (Record, bool, error)
+	// Complete stores the response of the claimed key
+	Complete(ctx context.Context, in Record) error
+	// Release drops a claimed key in progress, its request can be made again
+	Release(ctx context.Context, scope, key string) error
+}
+
+// sweepInterval is how often the expired keys are deleted
+const sweepInterval = time.Minute
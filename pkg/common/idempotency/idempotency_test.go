@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	glog "gorm.io/gorm/logger"
+)
+
+func TestStores(t *testing.T) {
+	gormDB, err := db.NewSqliteConn(context.Background(), config.Sqlite{
+		Path:               db.SqliteInMemory,
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}, glog.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+	require.NoError(t, gormDB.Exec(`CREATE TABLE idempotency_keys (
+		scope TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		header TEXT NOT NULL,
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY (scope, idempotency_key)
+	)`).Error)
+
+	now := time.Now().UTC()
+	memory := NewMemoryStore().(*memoryStore)
+	memory.now = func() time.Time { return now }
+	gormStore := NewGormStore(gormDB).(*gormStore)
+	gormStore.now = func() time.Time { return now }
+
+	for name, s := range map[string]Store{"memory": memory, "gorm": gormStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now = time.Now().UTC().Truncate(time.Microsecond)
+			claim := Record{Scope: "user:1", Key: "k", Fingerprint: "f", Header: "{}", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+			_, claimed, err := s.Claim(ctx, claim, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+
+			record, claimed, err := s.Claim(ctx, claim, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, claimed)
+			assert.True(t, record.InProgress())
+
+			other := claim
+			other.Scope = "user:2"
+			_, claimed, err = s.Claim(ctx, other, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, claimed, "the keys are by scope")
+
+			// a request in progress for longer than the lock timeout gives its key up
+			now = now.Add(time.Minute)
+			retry := claim
+			retry.CreatedAt = now
+			_, claimed, err = s.Claim(ctx, retry, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+
+			done := retry
+			done.Status = 201
+			done.Header = `{"Content-Type":["application/json"]}`
+			done.Body = []byte(`{"id":1}`)
+			require.NoError(t, s.Complete(ctx, done))
+			require.NoError(t, s.Release(ctx, done.Scope, done.Key), "completed keys are kept")
+
+			now = now.Add(30 * time.Minute)
+			record, claimed, err = s.Claim(ctx, retry, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, claimed)
+			assert.Equal(t, 201, record.Status)
+			assert.Equal(t, done.Header, record.Header)
+			assert.Equal(t, done.Body, record.Body)
+
+			require.NoError(t, s.Release(ctx, other.Scope, other.Key))
+			_, claimed, err = s.Claim(ctx, other, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, claimed, "released")
+
+			now = now.Add(time.Hour)
+			_, claimed, err = s.Claim(ctx, retry, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, claimed, "expired")
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryKey struct {
+	scope string
+	key   string
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	records   map[memoryKey]Record
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore keeps the keys in the process, for the memory storage
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records:   make(map[memoryKey]Record),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *memoryStore) Claim(_ context.Context, in Record, lockTimeout time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	k := memoryKey{scope: in.Scope, key: in.Key}
+	if record, ok := s.records[k]; ok && !reclaimable(record, now, lockTimeout) {
+		return record, false, nil
+	}
+
+	s.records[k] = in
+	return in, true, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, in Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey{scope: in.Scope, key: in.Key}
+	if record, ok := s.records[k]; ok && record.Fingerprint == in.Fingerprint && record.InProgress() {
+		s.records[k] = in
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := memoryKey{scope: scope, key: key}
+	if record, ok := s.records[k]; ok && record.InProgress() {
+		delete(s.records, k)
+	}
+	return nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for k, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+// reclaimable tells the record can be claimed by a new request
+func reclaimable(record Record, now time.Time, lockTimeout time.Duration) bool {
+	if !now.Before(record.ExpiresAt) {
+		return true
+	}
+	return record.InProgress() && lockTimeout > 0 && !now.Before(record.CreatedAt.Add(lockTimeout))
+}
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	configx "github.com/thealiakbari/todoapp/pkg/common/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	BackendSlogJson = "slog_json"
+	BackendSlogText = "slog_text"
+	BackendZap      = "zap"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+)
+
+// NewHandler builds the handler of the configured backend writing to the configured output. The
+// handler takes every level, the loggers filter them with their Levels. Close the closer on exit.
+func NewHandler(conf configx.Log) (slog.Handler, io.Closer, error) {
+	var out io.Writer
+	var closer io.Closer = nopCloser{}
+	switch conf.Output {
+	case "", OutputStdout:
+		out = os.Stdout
+	case OutputFile:
+		file, err := newRotatingFile(conf.File.Path, conf.File.MaxSizeMb, conf.File.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = file, file
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", conf.Output)
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch conf.Backend {
+	case "", BackendSlogJson:
+		return slog.NewJSONHandler(out, opts), closer, nil
+	case BackendSlogText:
+		return slog.NewTextHandler(out, opts), closer, nil
+	case BackendZap:
+		encoderConf := zap.NewProductionEncoderConfig()
+		encoderConf.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConf), zapcore.AddSync(out), zapcore.DebugLevel)
+		return &zapHandler{core: core}, closer, nil
+	default:
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("unknown log backend %q", conf.Backend)
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// zapHandler writes the slog records through a zap core
+type zapHandler struct {
+	core zapcore.Core
+}
+
+var _ slog.Handler = (*zapHandler)(nil)
+
+func (h *zapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *zapHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	checked := h.core.Check(entry, nil)
+	if checked == nil {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = append(fields, zapField(attr))
+		return true
+	})
+	checked.Write(fields...)
+
+	return nil
+}
+
+func (h *zapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, zapField(attr))
+	}
+
+	return &zapHandler{core: h.core.With(fields)}
+}
+
+func (h *zapHandler) WithGroup(name string) slog.Handler {
+	return &zapHandler{core: h.core.With([]zap.Field{zap.Namespace(name)})}
+}
+
+func zapField(attr slog.Attr) zap.Field {
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return zap.Any(attr.Key, value.Any())
+	}
+
+	group := value.Group()
+	fields := make([]zap.Field, 0, len(group))
+	for _, a := range group {
+		fields = append(fields, zapField(a))
+	}
+
+	return zap.Dict(attr.Key, fields...)
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
@@ -66,5 +66,7 @@ func (i infraLogger) CloneAsLogger() Logger {
 		servicePackageName: i.logger_impl.servicePackageName,
 		appName:            i.logger_impl.appName,
 		skipStack:          5,
+		redaction:          i.logger_impl.redaction,
+		levels:             i.logger_impl.levels,
 	}
 }
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	configx "github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+// Levels are the minimum levels logged, a default one and overrides by service. They can change
+// at runtime, every logger sharing them follows.
+type Levels struct {
+	base     slog.LevelVar
+	mu       sync.RWMutex
+	services map[string]slog.Level
+}
+
+// NewLevels starts at the level of mode in conf, debug when the mode has none
+func NewLevels(conf configx.Log, mode string) (*Levels, error) {
+	levels := &Levels{services: map[string]slog.Level{}}
+	levels.base.Set(slog.LevelDebug)
+
+	if name, ok := conf.Levels[mode]; ok {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels.base.Set(level)
+	}
+
+	return levels, nil
+}
+
+// ParseLevel reads `debug`, `info`, `warn` or `error`, in any case
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+
+	return level, nil
+}
+
+// serviceKey is the name a service is known by, without the `*` of the pointers
+func serviceKey(service *string) string {
+	if service == nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(*service, "*")
+}
+
+// Level is the minimum level of service, the default one when it has no override
+func (l *Levels) Level(service string) slog.Level {
+	l.mu.RLock()
+	level, ok := l.services[service]
+	l.mu.RUnlock()
+	if ok {
+		return level
+	}
+
+	return l.base.Level()
+}
+
+func (l *Levels) enabled(service *string, level slog.Level) bool {
+	if l == nil {
+		return true
+	}
+
+	return level >= l.Level(serviceKey(service))
+}
+
+// Default is the level of the services without an override
+func (l *Levels) Default() slog.Level {
+	return l.base.Level()
+}
+
+func (l *Levels) SetDefault(level slog.Level) {
+	l.base.Set(level)
+}
+
+// Set overrides the level of service, the name given to ForService
+func (l *Levels) Set(service string, level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.services[strings.TrimPrefix(service, "*")] = level
+}
+
+// Reset makes service follow the default level again
+func (l *Levels) Reset(service string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.services, strings.TrimPrefix(service, "*"))
+}
+
+// Services are the overrides by service name
+func (l *Levels) Services() map[string]slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	res := make(map[string]slog.Level, len(l.services))
+	for service, level := range l.services {
+		res[service] = level
+	}
+
+	return res
+}
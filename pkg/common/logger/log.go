@@ -8,9 +8,10 @@ import (
 	"reflect"
 
 	"github.com/google/uuid"
-	configx "github.com/thealiakbari/todoapp/pkg/common/config"
 	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/redact"
 	"github.com/thealiakbari/todoapp/pkg/common/utiles"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Option func(*logger)
@@ -21,6 +22,29 @@ type logger struct {
 	appName            string
 	servicePackageName string
 	service            *string
+	redaction          redact.Rules
+	levels             *Levels
+}
+
+// WithHandler writes the logs with handler, see NewHandler, rather than as JSON on stdout
+func WithHandler(handler slog.Handler) Option {
+	return func(l *logger) {
+		l.slogger = slog.New(handler)
+	}
+}
+
+// WithLevels filters the logs by the minimum level of their service, everything is logged without
+func WithLevels(levels *Levels) Option {
+	return func(l *logger) {
+		l.levels = levels
+	}
+}
+
+// WithRedactor masks the fields of the logs like the redactor masks the request log
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(l *logger) {
+		l.redaction = redactor.Global()
+	}
 }
 
 func New(mode, serviceName string, servicePackageName string, opts ...Option) (Logger, error) {
@@ -42,10 +66,8 @@ func new(mode, serviceName string, servicePackageName string, opts ...Option) (l
 		opt(&logger)
 	}
 
-	logger.slogger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-
-	if mode != configx.ModeProd {
-		// TODO: Somthing production related...
+	if logger.slogger == nil {
+		logger.slogger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	}
 
 	return logger, nil
@@ -64,6 +86,8 @@ func (l *logger) forService(service interface{}) logger {
 		service:            serviceName,
 		appName:            l.appName,
 		skipStack:          l.skipStack,
+		redaction:          l.redaction,
+		levels:             l.levels,
 	}
 }
 
@@ -142,20 +166,33 @@ func getTraceId(ctx context.Context) *string {
 }
 
 func (l logger) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !l.levels.enabled(l.service, level) {
+		return
+	}
+
 	traceId := getTraceId(ctx)
 
 	outAttrs := []slog.Attr{
 		slog.String(middleware.App, l.appName),
 	}
 
-	if len(attrs) > 0 {
-		outAttrs = append(outAttrs, attrs...)
+	for _, attr := range attrs {
+		outAttrs = append(outAttrs, l.redact(attr))
 	}
 
 	if traceId != nil {
 		outAttrs = append(outAttrs, slog.String(middleware.TraceIdKey, *traceId))
 	}
 
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			outAttrs = append(outAttrs,
+				slog.String(middleware.OtelTraceIdKey, spanContext.TraceID().String()),
+				slog.String(middleware.OtelSpanIdKey, spanContext.SpanID().String()),
+			)
+		}
+	}
+
 	if l.service != nil {
 		outAttrs = append(outAttrs, slog.String(middleware.Service, *l.service))
 	}
@@ -168,6 +205,20 @@ func (l logger) log(ctx context.Context, level slog.Level, msg string, attrs ...
 	l.slogger.LogAttrs(ctx, level, msg, outAttrs...)
 }
 
+// redact masks the value of attr when its key, or a field in it, is redacted
+func (l logger) redact(attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindAny:
+		return slog.Any(attr.Key, l.redaction.Field(attr.Key, attr.Value.Any()))
+	case slog.KindString:
+		if l.redaction.Field(attr.Key, "") == redact.Mask {
+			return slog.String(attr.Key, redact.Mask)
+		}
+	}
+
+	return attr
+}
+
 func (l logger) CloneAsInfra() InfraLogger {
 	return &infraLogger{
 		logger_impl: logger{
@@ -175,6 +226,8 @@ func (l logger) CloneAsInfra() InfraLogger {
 			servicePackageName: l.servicePackageName,
 			appName:            l.appName,
 			skipStack:          6,
+			redaction:          l.redaction,
+			levels:             l.levels,
 		},
 	}
 }
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	configx "github.com/thealiakbari/todoapp/pkg/common/config"
+	"go.uber.org/zap/zapcore"
+)
+
+type todoItemService struct{}
+
+func TestLevels(t *testing.T) {
+	var out bytes.Buffer
+	levels, err := NewLevels(configx.Log{Levels: map[string]string{configx.ModeProd: "warn"}}, configx.ModeProd)
+	require.NoError(t, err)
+
+	log, err := New(configx.ModeProd, "todoapp", "todoapp", WithHandler(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})), WithLevels(levels))
+	require.NoError(t, err)
+	svcLog := log.ForService(&todoItemService{})
+	lines := func() int {
+		n := strings.Count(out.String(), "\n")
+		out.Reset()
+		return n
+	}
+
+	svcLog.Info(context.Background(), "dropped")
+	svcLog.Warn(context.Background(), "kept")
+	assert.Equal(t, 1, lines())
+
+	levels.Set("todoItemService", slog.LevelDebug)
+	svcLog.Debug(context.Background(), "kept")
+	log.Info(context.Background(), "dropped, another service")
+	log.CloneAsInfra().ForService(todoItemService{}).Debug("kept")
+	assert.Equal(t, 2, lines())
+
+	levels.Reset("todoItemService")
+	svcLog.Info(context.Background(), "dropped")
+	assert.Equal(t, 0, lines())
+
+	_, err = NewLevels(configx.Log{Levels: map[string]string{configx.ModeProd: "verbose"}}, configx.ModeProd)
+	assert.Error(t, err)
+}
+
+func TestNewHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todoapp.log")
+
+	for _, backend := range []string{BackendSlogJson, BackendSlogText, BackendZap} {
+		t.Run(backend, func(t *testing.T) {
+			handler, closer, err := NewHandler(configx.Log{Backend: backend, Output: OutputFile, File: configx.LogFile{Path: path}})
+			require.NoError(t, err)
+
+			log, err := New(configx.ModeProd, "todoapp", "todoapp", WithHandler(handler))
+			require.NoError(t, err)
+			log.Info(context.Background(), "written", String("backend", backend))
+			require.NoError(t, closer.Close())
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			assert.Contains(t, lines[len(lines)-1], backend)
+		})
+	}
+
+	t.Run("zap", func(t *testing.T) {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &record))
+		assert.Equal(t, zapcore.InfoLevel.String(), record["level"])
+		assert.Equal(t, "written", record["msg"])
+		assert.Equal(t, "todoapp", record["app"])
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, _, err := NewHandler(configx.Log{Backend: "logrus"})
+		assert.Error(t, err)
+
+		_, _, err = NewHandler(configx.Log{Output: "syslog"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todoapp.log")
+	file, err := newRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	file.maxSize = 10
+	t.Cleanup(func() { _ = file.Close() })
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	for name, want := range map[string]string{"": "fourth\n", ".1": "third\n", ".2": "second\n"} {
+		content, err := os.ReadFile(path + name)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), name)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), "only max backups are kept")
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"github.com/thealiakbari/todoapp/pkg/common/db"
+	glog "gorm.io/gorm/logger"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+
+	r := gin.New()
+	r.Use(HTTPMiddleware(reg))
+	r.GET("/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_total HTTP requests by method, route and status.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/items/:id",status="204"} 2
+http_requests_total{method="GET",route="unmatched",status="404"} 1
+`), "http_requests_total"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_in_flight HTTP requests being served by method and route.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight{method="GET",route="/items/:id"} 0
+http_requests_in_flight{method="GET",route="unmatched"} 0
+`), "http_requests_in_flight"))
+
+	count, err := testutil.GatherAndCount(reg, "http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestGormPlugin(t *testing.T) {
+	gormDB, err := db.NewSqliteConn(context.Background(), config.Sqlite{
+		Path:               db.SqliteInMemory,
+		BusyTimeout:        5000,
+		TransactionTimeout: 120000,
+	}, glog.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sdb, _ := gormDB.DB()
+		_ = sdb.Close()
+	})
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, gormDB.Use(NewGormPlugin(reg)))
+
+	require.NoError(t, gormDB.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").Error)
+	require.NoError(t, gormDB.Exec("INSERT INTO notes (body) VALUES ('a')").Error)
+	var bodies []string
+	require.NoError(t, gormDB.Table("notes").Pluck("body", &bodies).Error)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+
+	observed := map[string]uint64{}
+	for _, m := range families[0].GetMetric() {
+		observed[m.GetLabel()[0].GetValue()] = m.GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, map[string]uint64{"raw": 2, "query": 1}, observed)
+}
@@ -14,6 +14,8 @@ const (
 	XTraceIdKey         = "x-trace-id"
 	GTraceIdKey         = "trace-id"
 	TraceIdKey          = "traceId"
+	OtelTraceIdKey      = "trace_id"
+	OtelSpanIdKey       = "span_id"
 	Stack               = "stack"
 	App                 = "app"
 	Service             = "service"
@@ -26,6 +28,7 @@ const (
 	Context             = "context"
 	Error               = "error"
 	UserReferenceIdKey  = "userReferenceId"
+	ApiKeyIdKey         = "apiKeyId"
 )
 
 func ParseBearerToken(r *http.Request) (string, error) {
@@ -56,3 +59,12 @@ func GetUserReferenceId(ctx context.Context) (string, error) {
 	}
 	return "", errors.New("context has not vote in it")
 }
+
+// GetApiKeyId is the id of the API key of the request, set in the context by the authentication
+// once it verified the key
+func GetApiKeyId(ctx context.Context) (string, error) {
+	if apiKeyId, ok := ctx.Value(ApiKeyIdKey).(string); ok {
+		return apiKeyId, nil
+	}
+	return "", errors.New("context has no api key id in it")
+}
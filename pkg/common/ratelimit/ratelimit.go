@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket, it holds up to Burst tokens and gains Rate of them per second. A bucket
+// seen the first time is full.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the bucket after a request took, or could not take, its token
+type Result struct {
+	Allowed bool
+	// Remaining is how many tokens are left
+	Remaining int
+	// RetryAfter is when the next token is there, zero when the request is allowed
+	RetryAfter time.Duration
+	// Reset is when the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the buckets, by key
+type Store interface {
+	// Take takes a token of the bucket of key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill is how many tokens the bucket with tokens has elapsed later
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// result is the bucket which has tokens left after the take
+func (l Limit) result(allowed bool, tokens float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     l.after(float64(l.Burst) - tokens),
+	}
+	if !allowed {
+		res.RetryAfter = l.after(1 - tokens)
+	}
+
+	return res
+}
+
+// after is when the bucket gained tokens
+func (l Limit) after(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore().(*memoryStore)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := s.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed, "the bucket is empty")
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, res.Reset)
+
+	res, err = s.Take(ctx, "b", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "the buckets are by key")
+
+	now = now.Add(500 * time.Millisecond)
+	res, err = s.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "refilled")
+	assert.Equal(t, 0, res.Remaining)
+
+	// full buckets are dropped
+	now = now.Add(sweepInterval)
+	_, err = s.Take(ctx, "c", limit)
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 1)
+}
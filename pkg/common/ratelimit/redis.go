@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes from the bucket atomically, on the clock of Redis so the replicas
+// agree on it. It answers whether the token was taken and the tokens left, as a string since
+// Redis truncates the numbers of Lua. The bucket expires once it is full again.
+var takeScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1])
+if tokens == nil then
+	tokens = burst
+else
+	tokens = math.min(burst, tokens + math.max(0, now - tonumber(bucket[2])) * rate)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+type redisStore struct {
+	client goredis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore keeps the buckets in Redis, under prefix, so the limits hold across the replicas
+func NewRedisStore(client goredis.UniversalClient, prefix string) Store {
+	return redisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s redisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key}, limit.Rate, limit.Burst).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	left, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit tokens %q: %w", left, err)
+	}
+
+	return limit.result(allowed == 1, tokens), nil
+}
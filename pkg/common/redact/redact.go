@@ -0,0 +1,275 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+// Mask replaces the redacted values
+const Mask = "[REDACTED]"
+
+// Redactor holds the rules of a config.Redaction, the global ones and those of each route
+type Redactor struct {
+	global Rules
+	routes map[string]Rules
+}
+
+// Rules are the redactions that apply to one request
+type Rules struct {
+	headers     map[string]struct{}
+	fields      [][]string
+	params      map[string]struct{}
+	maxBodySize int
+}
+
+func New(conf config.Redaction) *Redactor {
+	r := &Redactor{
+		global: Rules{maxBodySize: conf.MaxBodySize}.with(conf.Headers, conf.Fields, nil),
+		routes: map[string]Rules{},
+	}
+
+	for _, route := range conf.Routes {
+		rules := r.global.with(route.Headers, route.Fields, route.Params)
+		if route.MaxBodySize != 0 {
+			rules.maxBodySize = route.MaxBodySize
+		}
+		r.routes[routeKey(route.Method, route.Route)] = rules
+	}
+
+	return r
+}
+
+func routeKey(method, route string) string {
+	return strings.ToUpper(method) + " " + route
+}
+
+// Global are the rules of every request, they are what the logger applies to its fields
+func (r *Redactor) Global() Rules {
+	if r == nil {
+		return Rules{}
+	}
+
+	return r.global
+}
+
+// ForRoute adds the rules of the route pattern to the global ones, a route configured without a
+// method applies to all of them
+func (r *Redactor) ForRoute(method, route string) Rules {
+	if r == nil {
+		return Rules{}
+	}
+
+	if rules, ok := r.routes[routeKey(method, route)]; ok {
+		return rules
+	}
+	if rules, ok := r.routes[routeKey("", route)]; ok {
+		return rules
+	}
+
+	return r.global
+}
+
+// with returns a copy of rules extended by the headers, fields and path params given
+func (rules Rules) with(headers, fields, params []string) Rules {
+	res := Rules{
+		headers:     make(map[string]struct{}, len(rules.headers)+len(headers)),
+		fields:      append([][]string{}, rules.fields...),
+		params:      make(map[string]struct{}, len(rules.params)+len(params)),
+		maxBodySize: rules.maxBodySize,
+	}
+	for h := range rules.headers {
+		res.headers[h] = struct{}{}
+	}
+	for _, h := range headers {
+		res.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	for p := range rules.params {
+		res.params[p] = struct{}{}
+	}
+	for _, p := range params {
+		res.params[p] = struct{}{}
+	}
+	for _, f := range fields {
+		if f != "" {
+			res.fields = append(res.fields, strings.Split(strings.ToLower(f), "."))
+		}
+	}
+
+	return res
+}
+
+// Headers copies h with the values of the denied headers masked
+func (rules Rules) Headers(h http.Header) map[string]any {
+	res := make(map[string]any, len(h))
+	for key, values := range h {
+		switch {
+		case rules.deniesHeader(key):
+			res[key] = Mask
+		case len(values) == 1:
+			res[key] = values[0]
+		default:
+			res[key] = values
+		}
+	}
+
+	return res
+}
+
+func (rules Rules) deniesHeader(key string) bool {
+	_, ok := rules.headers[http.CanonicalHeaderKey(key)]
+	return ok
+}
+
+// Path masks the values of the redacted params of the route pattern in path, e.g. the token of
+// `/feeds/:token`
+func (rules Rules) Path(route, path string) string {
+	if len(rules.params) == 0 || route == "" {
+		return path
+	}
+
+	routeParts := strings.Split(route, "/")
+	pathParts := strings.Split(path, "/")
+	if len(routeParts) != len(pathParts) {
+		return path
+	}
+
+	for i, part := range routeParts {
+		if !strings.HasPrefix(part, ":") {
+			continue
+		}
+		if _, ok := rules.params[part[1:]]; ok {
+			pathParts[i] = Mask
+		}
+	}
+
+	return strings.Join(pathParts, "/")
+}
+
+// Value masks the fields of v that match the field paths. A path is a dot separated list of
+// keys, `*` matches any key, and it matches at any depth: `token` masks every token and
+// `user.token` the tokens under a user. Arrays are looked through. Structs are read as JSON.
+func (rules Rules) Value(v any) any {
+	if len(rules.fields) == 0 {
+		return v
+	}
+
+	return rules.value(nil, normalize(v))
+}
+
+// Field masks the value of the log field key like a field of a body
+func (rules Rules) Field(key string, v any) any {
+	if len(rules.fields) == 0 {
+		return v
+	}
+
+	path := []string{strings.ToLower(key)}
+	if rules.matches(path) {
+		return Mask
+	}
+
+	return rules.value(path, normalize(v))
+}
+
+func (rules Rules) value(path []string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for key, value := range v {
+			keyPath := append(path[:len(path):len(path)], strings.ToLower(key))
+			if rules.matches(keyPath) {
+				res[key] = Mask
+				continue
+			}
+			res[key] = rules.value(keyPath, value)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, value := range v {
+			res[i] = rules.value(path, value)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// matches reports whether a field path ends with the key path
+func (rules Rules) matches(path []string) bool {
+	for _, field := range rules.fields {
+		if len(field) > len(path) {
+			continue
+		}
+
+		tail := path[len(path)-len(field):]
+		matched := true
+		for i, key := range field {
+			if key != "*" && key != tail[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize turns structs and typed maps or slices into their JSON shape, the rest is kept
+func normalize(v any) any {
+	switch v.(type) {
+	case nil, string, bool, int, int64, float64, error, map[string]any, []any:
+		return v
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var res any
+	if err = json.Unmarshal(b, &res); err != nil {
+		return v
+	}
+
+	return res
+}
+
+// Body is how a body is logged: a JSON body with its fields masked, anything else as text. A
+// body longer than the cap is cut and ends with a truncation marker.
+func (rules Rules) Body(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var parsed any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return rules.truncate(string(body))
+	}
+
+	masked := rules.Value(parsed)
+	if rules.maxBodySize <= 0 || len(body) <= rules.maxBodySize {
+		return masked
+	}
+
+	b, err := json.Marshal(masked)
+	if err != nil {
+		return rules.truncate(string(body))
+	}
+
+	return rules.truncate(string(b))
+}
+
+func (rules Rules) truncate(s string) string {
+	if rules.maxBodySize <= 0 || len(s) <= rules.maxBodySize {
+		return s
+	}
+
+	return fmt.Sprintf("%s...[truncated %d bytes]", s[:rules.maxBodySize], len(s)-rules.maxBodySize)
+}
@@ -0,0 +1,91 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := New(config.Redaction{
+		Headers:     []string{"authorization"},
+		Fields:      []string{"password", "user.token", "keys.*"},
+		MaxBodySize: 64,
+		Routes: []config.RouteRedaction{
+			{Method: http.MethodGet, Route: "/feeds/:token", Params: []string{"token"}},
+			{Route: "/feeds", Fields: []string{"url"}, MaxBodySize: 16},
+		},
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		rules := redactor.Global()
+		h := http.Header{}
+		h.Set("Authorization", "Bearer secret")
+		h.Add("Accept", "text/plain")
+		h.Add("Accept", "application/json")
+
+		assert.Equal(t, map[string]any{
+			"Authorization": Mask,
+			"Accept":        []string{"text/plain", "application/json"},
+		}, rules.Headers(h))
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		rules := redactor.Global()
+
+		assert.Equal(t, map[string]any{
+			"Password": Mask,
+			"token":    "kept",
+			"items": []any{
+				map[string]any{"user": map[string]any{"token": Mask, "name": "ali"}},
+			},
+			"keys": map[string]any{"a": Mask, "b": Mask},
+		}, rules.Value(map[string]any{
+			"Password": "secret",
+			"token":    "kept",
+			"items": []any{
+				map[string]any{"user": map[string]any{"token": "secret", "name": "ali"}},
+			},
+			"keys": map[string]any{"a": "1", "b": "2"},
+		}))
+
+		type user struct {
+			Name  string `json:"name"`
+			Token string `json:"token"`
+		}
+		assert.Equal(t, map[string]any{"name": "ali", "token": Mask}, rules.Field("user", user{Name: "ali", Token: "secret"}))
+		assert.Equal(t, Mask, rules.Field("password", "secret"))
+		assert.Equal(t, "kept", rules.Field("name", "kept"))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		rules := redactor.Global()
+
+		assert.Equal(t, map[string]any{"password": Mask}, rules.Body([]byte(`{"password":"secret"}`)))
+		assert.Equal(t, "plain", rules.Body([]byte("plain")))
+		assert.Nil(t, rules.Body(nil))
+
+		long := []byte(`{"password":"secret","description":"a description longer than the cap of the body"}`)
+		assert.Equal(t, `{"description":"a description longer than the cap of the body","...[truncated 23 bytes]`, rules.Body(long))
+	})
+
+	t.Run("route", func(t *testing.T) {
+		rules := redactor.ForRoute(http.MethodGet, "/feeds/:token")
+		assert.Equal(t, "/feeds/"+Mask, rules.Path("/feeds/:token", "/feeds/abc"))
+		assert.Equal(t, map[string]any{"password": Mask}, rules.Body([]byte(`{"password":"secret"}`)), "the global rules apply too")
+
+		rules = redactor.ForRoute(http.MethodPost, "/feeds")
+		assert.Equal(t, `{"url":"[REDACTE...[truncated 4 bytes]`, rules.Body([]byte(`{"url":"https://todo/feeds/abc"}`)))
+
+		rules = redactor.ForRoute(http.MethodDelete, "/feeds/:token")
+		assert.Equal(t, "/feeds/abc", rules.Path("/feeds/:token", "/feeds/abc"), "the route is of another method")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var redactor *Redactor
+		rules := redactor.ForRoute(http.MethodGet, "/feeds")
+		assert.Equal(t, map[string]any{"password": "secret"}, rules.Body([]byte(`{"password":"secret"}`)))
+	})
+}
@@ -0,0 +1,35 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a list ordered by (sort key, id), clients get it base64 encoded and
+// should not look into it. A backward cursor reads the page before the position.
+type Cursor struct {
+	Key      string `json:"k"`
+	Id       string `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (res Cursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if err = json.Unmarshal(b, &res); err != nil || res.Key == "" || res.Id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return res, nil
+}
@@ -37,6 +37,39 @@ type Portion struct {
 	Limit  int
 }
 
+// PagingMode picks how a list is paged, by page number unless cursors are asked for
+type PagingMode = string
+
+const (
+	PagingPage   PagingMode = "page"
+	PagingCursor PagingMode = "cursor"
+)
+
+type CountMode = string
+
+const (
+	CountNone      CountMode = ""
+	CountExact     CountMode = "exact"
+	CountEstimated CountMode = "estimated"
+)
+
+// Keyset is the cursor version of `Portion`, the cursor comes from the previous page and is empty
+// for the first one. Unlike `Portion` the total count is only read when Count asks for it.
+type Keyset struct {
+	Cursor string
+	Limit  int
+	Count  CountMode
+}
+
+// KeysetPage describes a page read with `Keyset`, a cursor is empty when there is no page in its
+// direction and Count is nil when it was not asked for
+type KeysetPage struct {
+	NextCursor string
+	PrevCursor string
+	Count      *int64
+	Estimated  bool
+}
+
 type NumberRange struct {
 	From *int64
 	To   *int64
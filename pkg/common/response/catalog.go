@@ -0,0 +1,107 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Code is an entry of the error catalog: a stable number clients can rely on, with the class and
+// HTTP status of its errors and the id of its message in the locales
+type Code struct {
+	Id        int64
+	Class     ErrClass
+	Status    int
+	MessageId string
+}
+
+var catalog = map[int64]Code{}
+
+// NewCode registers a code in the catalog, registering an id twice panics. Codes are declared as
+// package variables, 1000-1999 are the generic ones of this package.
+func NewCode(id int64, class ErrClass, status int, messageId string) Code {
+	if registered, ok := catalog[id]; ok {
+		panic(fmt.Sprintf("error code %d is registered for %q already", id, registered.MessageId))
+	}
+
+	code := Code{Id: id, Class: class, Status: status, MessageId: messageId}
+	catalog[id] = code
+	return code
+}
+
+// The generic codes, also given to the errors without a code by their class
+var (
+	CodeUnknown      = NewCode(1000, EUnknown, http.StatusInternalServerError, "error.unknown")
+	CodeBadRequest   = NewCode(1001, EBadArg, http.StatusBadRequest, "error.bad_request")
+	CodeValidation   = NewCode(1002, EValidation, http.StatusUnprocessableEntity, "error.validation")
+	CodeNotFound     = NewCode(1003, ENotFound, http.StatusNotFound, "error.not_found")
+	CodeConflict     = NewCode(1004, EConflict, http.StatusConflict, "error.conflict")
+	CodeAccessDenied = NewCode(1005, EAccess, http.StatusForbidden, "error.access_denied")
+	CodeUnauthorized = NewCode(1006, EUnauthorized, http.StatusUnauthorized, "error.unauthorized")
+	CodeRateLimited  = NewCode(1007, ELimited, http.StatusTooManyRequests, "error.rate_limited")
+
+	CodeIdempotencyKeyReused     = NewCode(1008, EConflict, http.StatusConflict, "error.idempotency_key_reused")
+	CodeIdempotencyKeyInProgress = NewCode(1009, EConflict, http.StatusConflict, "error.idempotency_key_in_progress")
+)
+
+var classCodes = map[ErrClass]Code{
+	EBadArg:       CodeBadRequest,
+	EValidation:   CodeValidation,
+	ENotFound:     CodeNotFound,
+	EConflict:     CodeConflict,
+	EAccess:       CodeAccessDenied,
+	EUnauthorized: CodeUnauthorized,
+	ELimited:      CodeRateLimited,
+}
+
+// LookupCode finds a code of the catalog
+func LookupCode(id int64) (Code, bool) {
+	code, ok := catalog[id]
+	return code, ok
+}
+
+// Codes lists the catalog by id
+func Codes() []Code {
+	res := make([]Code, 0, len(catalog))
+	for _, code := range catalog {
+		res = append(res, code)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+
+	return res
+}
+
+// New is an error of the code caused by cause. The cause is logged, clients only get the message
+// of the code.
+func (c Code) New(cause error) *Error {
+	message := c.MessageId
+	if cause != nil {
+		message = cause.Error()
+	}
+
+	return &Error{
+		Message: message,
+		Cause:   cause,
+		Class:   c.Class,
+		ErrCode: c.Id,
+	}
+}
+
+// NewWithDetail also gives clients detail, for the errors of their input they can fix
+func (c Code) NewWithDetail(cause error, detail string) *Error {
+	err := c.New(cause)
+	err.Detail = detail
+	return err
+}
+
+// Code is the code of the error, the generic one of its class when it has none
+func (e *Error) Code() Code {
+	if code, ok := catalog[e.ErrCode]; ok {
+		return code
+	}
+	if code, ok := classCodes[e.Class]; ok {
+		return code
+	}
+
+	return CodeUnknown
+}
@@ -1,71 +1,92 @@
 package response
 
 import (
+	"context"
 	"errors"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thealiakbari/todoapp/pkg/common/i18next"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
 )
 
+const (
+	MIMEProblemJSON = "application/problem+json"
+
+	problemTypePrefix = "urn:todoapp:error:"
+)
+
+// Problem is the RFC 7807 body of an error, sent to the clients accepting `application/problem+json`
+type Problem struct {
+	Type     string                          `json:"type"`
+	Title    string                          `json:"title"`
+	Status   int                             `json:"status"`
+	Detail   string                          `json:"detail,omitempty"`
+	Instance string                          `json:"instance,omitempty"`
+	Code     int64                           `json:"code"`
+	Errors   []validation.ResponseValidation `json:"errors,omitempty"`
+}
+
+// HandelError aborts with the status of the code of err and its localized message. Clients only
+// see the detail and the field errors of err, the rest of it is kept in the errors of ctx for the
+// logs. The body is a Problem when the client accepts `application/problem+json` over JSON.
 func HandelError(ctx *gin.Context, err error) {
-	status := -1
 	var serviceError *Error
-	ok := errors.As(err, &serviceError)
-	if !ok {
-		err = &Error{
-			Cause:   err,
-			Message: err.Error(),
-			Class:   EUnknown,
-		}
-	} else {
-		err = serviceError
+	if !errors.As(err, &serviceError) {
+		serviceError = CodeUnknown.New(err)
 	}
+	_ = ctx.Error(serviceError)
 
-	var causeErr *Error
-	errors.As(err, &causeErr)
-	cause := causeErr.Cause
+	code := serviceError.Code()
+	title := i18next.ByContext(ctx.Request.Context(), code.MessageId)
 
-	if err.(*Error).Cause == nil {
-		errors.As(err, &cause)
+	var fieldErrors validation.ErrValidation
+	errors.As(serviceError, &fieldErrors)
+
+	if ctx.NegotiateFormat(gin.MIMEJSON, MIMEProblemJSON) == MIMEProblemJSON {
+		problem := Problem{
+			Type:   problemTypePrefix + code.MessageId,
+			Title:  title,
+			Status: code.Status,
+			Detail: serviceError.Detail,
+			Code:   code.Id,
+			Errors: fieldErrors,
+		}
+		if traceId, ok := ctx.Get(middleware.XTraceIdKey); ok {
+			if traceId, ok := traceId.(uuid.UUID); ok {
+				problem.Instance = traceId.String()
+			}
+		}
+
+		// kept by the JSON render, it only sets the content type when there is none
+		ctx.Header("Content-Type", MIMEProblemJSON)
+		ctx.AbortWithStatusJSON(code.Status, problem)
+		return
 	}
 
-	switch {
-	case IsBadArg(err):
-		status = http.StatusBadRequest
-	case IsAccess(err):
-		status = http.StatusForbidden
-	case IsNotFound(err):
-		status = http.StatusNotFound
-	case IsConflict(err):
-		status = http.StatusConflict
-	case IsValidation(err):
-		status = http.StatusUnprocessableEntity
-	case IsUnauthorized(err):
-		status = http.StatusUnauthorized
-	default:
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, BaseResponse{
-			Payload: nil,
-			Meta: ErrResponse{
-				Message: err.Error(),
-				Causes:  cause,
-				Code:    serviceError.ErrCode,
-			},
-		})
+	message := Message(ctx.Request.Context(), serviceError)
+	var causes any = []any{}
+	if len(fieldErrors) > 0 {
+		causes = fieldErrors
 	}
 
-	if status != -1 {
-		ctx.AbortWithStatusJSON(status,
-			BaseResponse{
-				Payload: nil,
-				Meta: ErrResponse{
-					Message: err.Error(),
-					Causes:  cause,
-					Code:    serviceError.ErrCode,
-				},
-			},
-		)
+	ctx.AbortWithStatusJSON(code.Status, BaseResponse{
+		Payload: nil,
+		Meta: ErrResponse{
+			Message: message,
+			Causes:  causes,
+			Code:    code.Id,
+		},
+	})
+}
+
+// Message is what clients see of err: the localized message of its code and its detail
+func Message(ctx context.Context, err *Error) string {
+	message := i18next.ByContext(ctx, err.Code().MessageId)
+	if err.Detail != "" {
+		message += ": " + err.Detail
 	}
 
-	// nolinter
-	_ = ctx.AbortWithError(status, err.(*Error))
+	return message
 }
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thealiakbari/todoapp/pkg/common/i18next"
+	"github.com/thealiakbari/todoapp/pkg/common/middleware"
+	"github.com/thealiakbari/todoapp/pkg/common/validation"
+	"golang.org/x/text/language"
+)
+
+func TestCatalog(t *testing.T) {
+	code, ok := LookupCode(CodeNotFound.Id)
+	require.True(t, ok)
+	assert.Equal(t, CodeNotFound, code)
+
+	codes := Codes()
+	for i := 1; i < len(codes); i++ {
+		assert.Less(t, codes[i-1].Id, codes[i].Id)
+	}
+
+	assert.Panics(t, func() { NewCode(CodeUnknown.Id, EUnknown, http.StatusInternalServerError, "error.again") })
+
+	assert.Equal(t, CodeConflict, (&Error{Class: EConflict}).Code(), "the code of the class")
+	assert.Equal(t, CodeUnknown, (&Error{Class: ETimeout, ErrCode: 1024}).Code(), "unknown codes fall back")
+}
+
+func TestHandelError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, i18next.NewLanguage(language.English))
+
+	traceId := uuid.New()
+	fieldErrors := validation.ErrValidation{{Field: "description", Message: "description is required"}}
+	handle := func(err error, accept, acceptLanguage string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(res)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request.Header.Set("Accept", accept)
+		if acceptLanguage != "" {
+			ctx.Request = ctx.Request.WithContext(i18next.WithLang(ctx.Request.Context(), i18next.MatchHeader(acceptLanguage)))
+		}
+		ctx.Set(middleware.XTraceIdKey, traceId)
+
+		HandelError(ctx, err)
+		return res
+	}
+
+	t.Run("json", func(t *testing.T) {
+		res := handle(errors.New("pq: connection refused"), "", "")
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Equal(t, gin.MIMEJSON+"; charset=utf-8", res.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"payload":null,"meta":{"code":1000,"message":"Something went wrong","causes":[]}}`, res.Body.String())
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		err := errors.New("cursor cannot be used with page")
+		res := handle(CodeValidation.NewWithDetail(err, err.Error()), gin.MIMEJSON, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		assert.JSONEq(t, `{"payload":null,"meta":{"code":1002,"message":"The request is invalid: cursor cannot be used with page","causes":[]}}`, res.Body.String())
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		res := handle(CodeValidation.New(fieldErrors), MIMEProblemJSON+", application/json;q=0.5", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		assert.Equal(t, MIMEProblemJSON, res.Header().Get("Content-Type"))
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &problem))
+		assert.Equal(t, Problem{
+			Type:     "urn:todoapp:error:error.validation",
+			Title:    "The request is invalid",
+			Status:   http.StatusUnprocessableEntity,
+			Instance: traceId.String(),
+			Code:     CodeValidation.Id,
+			Errors:   fieldErrors,
+		}, problem)
+	})
+
+	t.Run("language", func(t *testing.T) {
+		res := handle(&Error{Class: ENotFound, Message: "todo item not found"}, MIMEProblemJSON, "de-CH, en;q=0.8")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Contains(t, res.Body.String(), `"title":"Die Ressource wurde nicht gefunden"`)
+		assert.NotContains(t, res.Body.String(), "todo item not found")
+	})
+}
+
+func TestMessage(t *testing.T) {
+	require.NoError(t, i18next.NewLanguage(language.English))
+	ctx := context.Background()
+
+	err := CodeBadRequest.NewWithDetail(errors.New("parse error on line 2"), "line 2 has a bare quote")
+	assert.Equal(t, "The request is malformed: line 2 has a bare quote", Message(ctx, err))
+
+	err = CodeUnknown.New(errors.New("pq: password authentication failed"))
+	assert.Equal(t, "Something went wrong", Message(ctx, err))
+}
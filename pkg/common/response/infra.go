@@ -21,6 +21,7 @@ const (
 	EConflict                     // Conflict
 	EValidation                   // Validation
 	EUnauthorized                 // Validation,
+	ELimited                      // Rate limited
 )
 
 var errCLasses = map[ErrClass]string{
@@ -35,6 +36,7 @@ var errCLasses = map[ErrClass]string{
 	EConflict:     "conflict",
 	EValidation:   "validation",
 	EUnauthorized: "unauthorized",
+	ELimited:      "limited",
 }
 
 // String returns the response class name.
@@ -53,6 +55,7 @@ type Error struct {
 	Class   ErrClass `json:"class"`   // Error class.
 	IsTemp  bool     `json:"isTemp"`  // Is the response temporary?
 	ErrCode int64    `json:"errCode"`
+	Detail  string   `json:"detail"` // Detail clients may see, the rest stays in the logs.
 }
 
 // Error returns the full response message.
@@ -34,10 +34,15 @@ type BaseResponse struct {
 	Meta    ErrResponse `json:"meta"`
 }
 
+// PaginationInfo has Page and TotalItems for page lists, cursor lists have the cursors instead and
+// TotalItems only when it was asked for
 type PaginationInfo struct {
-	PageSize   int64 `json:"pageSize" form:"pageSize"`
-	Page       int64 `json:"page" form:"page"`
-	TotalItems int64 `json:"totalItems"`
+	PageSize       int64  `json:"pageSize" form:"pageSize"`
+	Page           int64  `json:"page,omitempty" form:"page"`
+	TotalItems     *int64 `json:"totalItems,omitempty"`
+	TotalEstimated bool   `json:"totalEstimated,omitempty"`
+	NextCursor     string `json:"nextCursor,omitempty"`
+	PrevCursor     string `json:"prevCursor,omitempty"`
 }
 
 type DefaultSort struct {
@@ -58,13 +63,26 @@ func PaginationListResponse(items any, count int64, pageSize int64, page int64)
 	return ListResponse{
 		Pagination: PaginationInfo{
 			PageSize:   pageSize,
-			TotalItems: count,
+			TotalItems: &count,
 			Page:       page,
 		},
 		Items: items,
 	}
 }
 
+func CursorListResponse(items any, pageSize int64, nextCursor, prevCursor string, count *int64, estimated bool) ListResponse {
+	return ListResponse{
+		Pagination: PaginationInfo{
+			PageSize:       pageSize,
+			TotalItems:     count,
+			TotalEstimated: estimated,
+			NextCursor:     nextCursor,
+			PrevCursor:     prevCursor,
+		},
+		Items: items,
+	}
+}
+
 func PaginationAndSortListResponse(items any, count int64, pageSize int64, page int64, sortBy, sortType string) ListResponse {
 	if page == 0 {
 		page = 1
@@ -72,7 +90,7 @@ func PaginationAndSortListResponse(items any, count int64, pageSize int64, page
 	response := ListResponse{
 		Pagination: PaginationInfo{
 			PageSize:   pageSize,
-			TotalItems: count,
+			TotalItems: &count,
 			Page:       page,
 		},
 		Items: items,
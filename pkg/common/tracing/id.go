@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type traceIdKey struct{}
+
+// WithTraceId makes the next root span started with ctx use id as its trace id, it is how a
+// legacy `X-Trace-Id` keeps identifying the request when the caller sends no `traceparent`
+func WithTraceId(ctx context.Context, id trace.TraceID) context.Context {
+	return context.WithValue(ctx, traceIdKey{}, id)
+}
+
+// idGenerator generates random ids like the default one of the SDK, except for the trace ids
+// given with WithTraceId
+type idGenerator struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+var _ sdktrace.IDGenerator = (*idGenerator)(nil)
+
+func newIdGenerator() *idGenerator {
+	var seed int64
+	_ = binary.Read(crand.Reader, binary.LittleEndian, &seed)
+
+	return &idGenerator{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (g *idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceId, ok := ctx.Value(traceIdKey{}).(trace.TraceID)
+	if !ok || !traceId.IsValid() {
+		g.mu.Lock()
+		for !traceId.IsValid() {
+			_, _ = g.rand.Read(traceId[:])
+		}
+		g.mu.Unlock()
+	}
+
+	return traceId, g.NewSpanID(ctx, traceId)
+}
+
+func (g *idGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var spanId trace.SpanID
+	for !spanId.IsValid() {
+		_, _ = g.rand.Read(spanId[:])
+	}
+
+	return spanId
+}
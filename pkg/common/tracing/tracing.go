@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thealiakbari/todoapp/pkg/common/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	SamplerAlwaysOn  = "always_on"
+	SamplerAlwaysOff = "always_off"
+	SamplerRatio     = "ratio"
+)
+
+// TracerName is the instrumentation name of the spans the service starts itself
+const TracerName = "github.com/thealiakbari/todoapp"
+
+// NewTracerProvider builds the provider of conf and installs it, with the W3C trace context and
+// baggage propagators, as the global one. Spans are exported over OTLP/HTTP when an endpoint is
+// set, without one they only give the logs their trace and span ids. Shut the provider down on
+// exit to flush the spans left.
+func NewTracerProvider(ctx context.Context, conf config.Tracing, serviceName, mode string) (*sdktrace.TracerProvider, error) {
+	sampler, err := newSampler(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(mode),
+	}
+	for _, pair := range strings.Split(conf.ResourceAttributes, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("tracing resource attribute %q is not key=value", pair)
+		}
+		attrs = append(attrs, attribute.String(strings.TrimSpace(key), strings.TrimSpace(value)))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+		sdktrace.WithIDGenerator(newIdGenerator()),
+	}
+
+	if conf.Endpoint != "" {
+		exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
+
+// newSampler follows the sampling decision of a parent span, conf only decides for root spans
+func newSampler(conf config.Tracing) (sdktrace.Sampler, error) {
+	var root sdktrace.Sampler
+	switch conf.Sampler {
+	case "", SamplerAlwaysOn:
+		root = sdktrace.AlwaysSample()
+	case SamplerAlwaysOff:
+		root = sdktrace.NeverSample()
+	case SamplerRatio:
+		if conf.SampleRatio < 0 || conf.SampleRatio > 1 {
+			return nil, fmt.Errorf("tracing sample ratio %v is not between 0 and 1", conf.SampleRatio)
+		}
+		root = sdktrace.TraceIDRatioBased(conf.SampleRatio)
+	default:
+		return nil, fmt.Errorf("unknown tracing sampler %q", conf.Sampler)
+	}
+
+	return sdktrace.ParentBased(root), nil
+}
+
+// Tracer is the tracer of the spans the service starts itself
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}